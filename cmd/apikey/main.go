@@ -0,0 +1,72 @@
+// Команда apikey выпускает API-ключ напрямую в базе данных. Нужна, чтобы получить первый
+// ключ с правом admin; остальные ключи выпускаются через API.
+//
+//	go run ./cmd/apikey -name ops -scopes admin
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository/postgres"
+	"github.com/Nzyazin/itk/internal/core/usecase"
+	"github.com/Nzyazin/itk/pkg/config"
+	"github.com/Nzyazin/itk/pkg/postgresdb"
+	"github.com/google/uuid"
+)
+
+func main() {
+	name := flag.String("name", "", "название ключа")
+	scopes := flag.String("scopes", string(models.ScopeAdmin), "права через запятую: wallet:read, wallet:write, admin")
+	wallets := flag.String("wallets", "", "идентификаторы доступных кошельков через запятую; пусто - все кошельки")
+	flag.Parse()
+
+	if err := run(*name, *scopes, *wallets); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(name, scopes, wallets string) error {
+	req := models.APIKeyRequest{Name: name}
+	for _, scope := range strings.Split(scopes, ",") {
+		req.Scopes = append(req.Scopes, models.Scope(strings.TrimSpace(scope)))
+	}
+	for _, value := range strings.Split(wallets, ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return fmt.Errorf("invalid wallet id %q: %w", value, err)
+		}
+		req.WalletIDs = append(req.WalletIDs, id)
+	}
+
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	cfgDB, err := config.LoadConfigDB()
+	if err != nil {
+		return err
+	}
+	db, err := postgresdb.NewPostgresDB(*cfgDB, log)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	keys := usecase.NewAPIKeyUsecase(postgres.NewPostgresAPIKeyRepo(db.DB, log), log)
+	issued, err := keys.CreateAPIKey(context.Background(), req)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("id:  %s\nkey: %s\n", issued.ID, issued.Key)
+	return nil
+}
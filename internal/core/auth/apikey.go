@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+)
+
+// apiKeyPrefix отличает API-ключи сервиса от других секретов, например при поиске утечек
+const apiKeyPrefix = "itk_"
+
+// GenerateAPIKey создаёт новый ключ и возвращает его вместе с видимым префиксом и хэшем для хранения
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", fmt.Errorf("generate api key: %w", err)
+	}
+	key = apiKeyPrefix + hex.EncodeToString(buf)
+	return key, key[:len(apiKeyPrefix)+8], HashAPIKey(key), nil
+}
+
+// HashAPIKey возвращает SHA-256 ключа. Ключ случаен и достаточно длинный, поэтому
+// медленное хэширование с солью не требуется.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyAuthenticator проверяет API-ключи по хэшам в хранилище
+type APIKeyAuthenticator struct {
+	store repository.APIKeyRepository
+	now   func() time.Time
+}
+
+func NewAPIKeyAuthenticator(store repository.APIKeyRepository) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{store: store, now: time.Now}
+}
+
+func (a *APIKeyAuthenticator) Authenticate(ctx context.Context, credential string) (*models.Principal, error) {
+	if !strings.HasPrefix(credential, apiKeyPrefix) {
+		return nil, ErrUnsupportedCredential
+	}
+
+	key, err := a.store.GetAPIKeyByHash(ctx, HashAPIKey(credential))
+	if err != nil {
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			return nil, ErrInvalidCredential
+		}
+		return nil, err
+	}
+	if !key.IsActive(a.now()) {
+		return nil, ErrInvalidCredential
+	}
+
+	return &models.Principal{
+		Actor:     "api_key:" + key.ID.String(),
+		Scopes:    key.Scopes,
+		WalletIDs: key.WalletIDs,
+	}, nil
+}
@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/Nzyazin/itk/internal/core/models"
+)
+
+type principalKey struct{}
+
+// WithPrincipal сохраняет аутентифицированного клиента в контексте запроса
+func WithPrincipal(ctx context.Context, principal *models.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext возвращает клиента, выполняющего запрос. Контекст без клиента принадлежит
+// самому сервису, например фоновым процессам.
+func FromContext(ctx context.Context) (*models.Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*models.Principal)
+	return principal, ok
+}
+
+// Actor возвращает идентификатор клиента для истории операций; пусто для внутренних операций
+func Actor(ctx context.Context) string {
+	if principal, ok := FromContext(ctx); ok {
+		return principal.Actor
+	}
+	return ""
+}
@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/models"
+)
+
+// clockSkew - допустимое расхождение часов сервиса и издателя токенов
+const clockSkew = time.Minute
+
+// JWTConfig задаёт ожидаемого издателя и получателя токенов; пустое значение не проверяется
+type JWTConfig struct {
+	Issuer   string
+	Audience string
+}
+
+// JWTAuthenticator проверяет токены пользователей, подписанные RS256 или ES256 ключами из JWKS.
+// Субъект токена сопоставляется с владельцем кошелька (owner_ref), поэтому пользователю
+// доступны только его кошельки. Право admin из токена не принимается.
+type JWTAuthenticator struct {
+	keys   map[string]verificationKey
+	config JWTConfig
+	now    func() time.Time
+}
+
+func NewJWTAuthenticator(jwksPath string, config JWTConfig) (*JWTAuthenticator, error) {
+	keys, err := LoadJWKS(jwksPath)
+	if err != nil {
+		return nil, err
+	}
+	return &JWTAuthenticator{keys: keys, config: config, now: time.Now}, nil
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwtClaims struct {
+	Subject   string          `json:"sub"`
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"` // строка или массив строк
+	ExpiresAt *int64          `json:"exp"`
+	NotBefore *int64          `json:"nbf"`
+	Scope     string          `json:"scope"`
+}
+
+func (a *JWTAuthenticator) Authenticate(_ context.Context, credential string) (*models.Principal, error) {
+	parts := strings.Split(credential, ".")
+	if len(parts) != 3 {
+		return nil, ErrUnsupportedCredential
+	}
+
+	claims, err := a.verify(parts)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredential, err)
+	}
+
+	scopes := []models.Scope{models.ScopeWalletWrite}
+	if claims.Scope != "" {
+		scopes = scopes[:0]
+		for _, scope := range strings.Fields(claims.Scope) {
+			if s := models.Scope(scope); s == models.ScopeWalletRead || s == models.ScopeWalletWrite {
+				scopes = append(scopes, s)
+			}
+		}
+	}
+
+	return &models.Principal{
+		Actor:   "user:" + claims.Subject,
+		Subject: claims.Subject,
+		Scopes:  scopes,
+	}, nil
+}
+
+func (a *JWTAuthenticator) verify(parts []string) (*jwtClaims, error) {
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("header: %w", err)
+	}
+
+	key, ok := a.keys[header.Kid]
+	if !ok && header.Kid == "" && len(a.keys) == 1 {
+		for _, k := range a.keys {
+			key, ok = k, true
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", header.Kid)
+	}
+	// Алгоритм определяется ключом, а не заголовком: иначе подпись можно подменить, указав "none" или HS256
+	if header.Alg != key.alg {
+		return nil, fmt.Errorf("algorithm %q does not match key", header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed signature")
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := verifySignature(key, digest[:], signature); err != nil {
+		return nil, err
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("claims: %w", err)
+	}
+	return &claims, a.validateClaims(&claims)
+}
+
+func verifySignature(key verificationKey, digest, signature []byte) error {
+	switch pub := key.key.(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest, signature); err != nil {
+			return errors.New("invalid signature")
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		// Подпись ES256 - конкатенация r и s по 32 байта, а не DER
+		if len(signature) != 64 {
+			return errors.New("invalid signature")
+		}
+		r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	}
+	return errors.New("unsupported key")
+}
+
+func (a *JWTAuthenticator) validateClaims(claims *jwtClaims) error {
+	now := a.now()
+	switch {
+	case claims.Subject == "":
+		return errors.New("missing subject")
+	case claims.ExpiresAt == nil:
+		return errors.New("missing expiration")
+	case now.After(time.Unix(*claims.ExpiresAt, 0).Add(clockSkew)):
+		return errors.New("token expired")
+	case claims.NotBefore != nil && now.Add(clockSkew).Before(time.Unix(*claims.NotBefore, 0)):
+		return errors.New("token not yet valid")
+	case a.config.Issuer != "" && claims.Issuer != a.config.Issuer:
+		return fmt.Errorf("unexpected issuer %q", claims.Issuer)
+	}
+
+	if a.config.Audience != "" && !hasAudience(claims.Audience, a.config.Audience) {
+		return errors.New("unexpected audience")
+	}
+	return nil
+}
+
+func hasAudience(raw json.RawMessage, audience string) bool {
+	var single string
+	if json.Unmarshal(raw, &single) == nil {
+		return single == audience
+	}
+	var list []string
+	if json.Unmarshal(raw, &list) == nil {
+		for _, a := range list {
+			if a == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errors.New("malformed segment")
+	}
+	return json.Unmarshal(data, v)
+}
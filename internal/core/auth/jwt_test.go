@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Unix(1700000000, 0)
+
+func newTestAuthenticator(t *testing.T) (*JWTAuthenticator, *rsa.PrivateKey, *ecdsa.PrivateKey) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	jwks := map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa", "alg": "RS256", "use": "sig",
+			"n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec", "crv": "P-256",
+			"x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+	}}
+	data, err := json.Marshal(jwks)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, data, 0600))
+
+	authenticator, err := NewJWTAuthenticator(path, JWTConfig{Issuer: "mobile", Audience: "wallet"})
+	require.NoError(t, err)
+	authenticator.now = func() time.Time { return now }
+	return authenticator, rsaKey, ecKey
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signToken(t *testing.T, header, claims map[string]any, key crypto.Signer) string {
+	h, err := json.Marshal(header)
+	require.NoError(t, err)
+	c, err := json.Marshal(claims)
+	require.NoError(t, err)
+	input := b64(h) + "." + b64(c)
+	digest := sha256.Sum256([]byte(input))
+
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		require.NoError(t, err)
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return input + "." + b64(signature)
+}
+
+func validClaims() map[string]any {
+	return map[string]any{"sub": "user-42", "iss": "mobile", "aud": []string{"wallet"}, "exp": now.Add(time.Hour).Unix()}
+}
+
+func TestJWTAuthenticate(t *testing.T) {
+	authenticator, rsaKey, ecKey := newTestAuthenticator(t)
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		alg string
+		key crypto.Signer
+	}{{"RS256", rsaKey}, {"ES256", ecKey}} {
+		kid := map[string]string{"RS256": "rsa", "ES256": "ec"}[tc.alg]
+		token := signToken(t, map[string]any{"alg": tc.alg, "kid": kid}, validClaims(), tc.key)
+
+		principal, err := authenticator.Authenticate(ctx, token)
+		require.NoError(t, err, tc.alg)
+		assert.Equal(t, "user-42", principal.Subject)
+		assert.Equal(t, "user:user-42", principal.Actor)
+		assert.True(t, principal.HasScope(models.ScopeWalletWrite))
+		assert.False(t, principal.HasScope(models.ScopeAdmin))
+	}
+}
+
+func TestJWTRejected(t *testing.T) {
+	authenticator, rsaKey, ecKey := newTestAuthenticator(t)
+	ctx := context.Background()
+	rsaHeader := map[string]any{"alg": "RS256", "kid": "rsa"}
+
+	withClaim := func(key string, value any) map[string]any {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+
+	tests := map[string]string{
+		"expired":        signToken(t, rsaHeader, withClaim("exp", now.Add(-time.Hour).Unix()), rsaKey),
+		"no expiration":  signToken(t, rsaHeader, withClaim("exp", nil), rsaKey),
+		"no subject":     signToken(t, rsaHeader, withClaim("sub", nil), rsaKey),
+		"wrong issuer":   signToken(t, rsaHeader, withClaim("iss", "other"), rsaKey),
+		"wrong audience": signToken(t, rsaHeader, withClaim("aud", "other"), rsaKey),
+		"not yet valid":  signToken(t, rsaHeader, withClaim("nbf", now.Add(time.Hour).Unix()), rsaKey),
+		"unknown kid":    signToken(t, map[string]any{"alg": "RS256", "kid": "other"}, validClaims(), rsaKey),
+		"alg mismatch":   signToken(t, map[string]any{"alg": "ES256", "kid": "rsa"}, validClaims(), ecKey),
+		"wrong key":      signToken(t, map[string]any{"alg": "ES256", "kid": "ec"}, validClaims(), mustECKey(t)),
+		"alg none":       b64([]byte(`{"alg":"none","kid":"rsa"}`)) + "." + b64([]byte(`{"sub":"user-42"}`)) + ".",
+	}
+	for name, token := range tests {
+		_, err := authenticator.Authenticate(ctx, token)
+		assert.ErrorIs(t, err, ErrInvalidCredential, name)
+	}
+
+	_, err := authenticator.Authenticate(ctx, "itk_0123456789")
+	assert.ErrorIs(t, err, ErrUnsupportedCredential)
+}
+
+func TestJWTScopeClaim(t *testing.T) {
+	authenticator, rsaKey, _ := newTestAuthenticator(t)
+	claims := validClaims()
+	claims["scope"] = "wallet:read admin"
+
+	principal, err := authenticator.Authenticate(context.Background(),
+		signToken(t, map[string]any{"alg": "RS256", "kid": "rsa"}, claims, rsaKey))
+	require.NoError(t, err)
+	assert.Equal(t, []models.Scope{models.ScopeWalletRead}, principal.Scopes)
+}
+
+func TestSubjectWalletAccess(t *testing.T) {
+	owner, other := "user-42", "user-7"
+	principal := &models.Principal{Subject: owner}
+
+	assert.True(t, principal.CanAccessWallet(&models.Wallet{OwnerRef: &owner}))
+	assert.False(t, principal.CanAccessWallet(&models.Wallet{OwnerRef: &other}))
+	assert.False(t, principal.CanAccessWallet(&models.Wallet{}))
+}
+
+func mustECKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return key
+}
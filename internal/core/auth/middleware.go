@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+)
+
+var (
+	// ErrUnsupportedCredential - учётные данные не относятся к этому способу аутентификации
+	ErrUnsupportedCredential = errors.New("unsupported credential")
+	// ErrInvalidCredential - учётные данные не действительны
+	ErrInvalidCredential = errors.New("invalid credential")
+)
+
+// Authenticator определяет клиента по учётным данным запроса
+type Authenticator interface {
+	Authenticate(ctx context.Context, credential string) (*models.Principal, error)
+}
+
+// Chain проверяет учётные данные способами по порядку: каждый способ отказывается
+// от чужих учётных данных ошибкой ErrUnsupportedCredential
+func Chain(authenticators ...Authenticator) Authenticator {
+	return chain(authenticators)
+}
+
+type chain []Authenticator
+
+func (c chain) Authenticate(ctx context.Context, credential string) (*models.Principal, error) {
+	for _, authenticator := range c {
+		principal, err := authenticator.Authenticate(ctx, credential)
+		if !errors.Is(err, ErrUnsupportedCredential) {
+			return principal, err
+		}
+	}
+	return nil, ErrUnsupportedCredential
+}
+
+// Middleware аутентифицирует каждый запрос по заголовку "Authorization: Bearer <ключ или JWT>"
+// или "X-API-Key" и сохраняет клиента в контексте. Запросы без действительных учётных данных
+// отклоняются с 401.
+func Middleware(authenticator Authenticator, log logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			credential := credentialFromRequest(r)
+			if credential == "" {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				respondWithError(w, http.StatusUnauthorized, "Authentication required")
+				return
+			}
+
+			principal, err := authenticator.Authenticate(r.Context(), credential)
+			if err != nil {
+				if !errors.Is(err, ErrInvalidCredential) && !errors.Is(err, ErrUnsupportedCredential) {
+					log.Error("Authentication failed", logger.ErrorField("error", err))
+					respondWithError(w, http.StatusInternalServerError, "Authentication failed")
+					return
+				}
+				log.Warn("Invalid credentials",
+					logger.ErrorField("error", err),
+					logger.StringField("path", r.URL.Path),
+					logger.StringField("remote_addr", r.RemoteAddr))
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				respondWithError(w, http.StatusUnauthorized, "Invalid credentials")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// Require пропускает запрос, только если у клиента есть право scope
+func Require(scope models.Scope, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := FromContext(r.Context())
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "Authentication required")
+			return
+		}
+		if !principal.HasScope(scope) {
+			respondWithError(w, http.StatusForbidden, "Missing scope "+string(scope))
+			return
+		}
+		next(w, r)
+	})
+}
+
+func credentialFromRequest(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return strings.TrimSpace(key)
+	}
+	scheme, credential, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(credential)
+}
+
+func respondWithError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
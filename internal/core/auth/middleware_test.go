@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type staticAuthenticator map[string]*models.Principal
+
+func (a staticAuthenticator) Authenticate(_ context.Context, credential string) (*models.Principal, error) {
+	if principal, ok := a[credential]; ok {
+		return principal, nil
+	}
+	return nil, ErrInvalidCredential
+}
+
+func TestMiddlewareRequire(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	authenticator := staticAuthenticator{
+		"reader": {Actor: "api_key:reader", Scopes: []models.Scope{models.ScopeWalletRead}},
+		"writer": {Actor: "api_key:writer", Scopes: []models.Scope{models.ScopeWalletWrite}},
+		"admin":  {Actor: "api_key:admin", Scopes: []models.Scope{models.ScopeAdmin}},
+	}
+	handler := Middleware(authenticator, log)(Require(models.ScopeWalletWrite, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(Actor(r.Context())))
+	}))
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		status int
+		body   string
+	}{
+		{name: "no credentials", status: http.StatusUnauthorized},
+		{name: "unknown key", header: "X-API-Key", value: "other", status: http.StatusUnauthorized},
+		{name: "missing scope", header: "X-API-Key", value: "reader", status: http.StatusForbidden},
+		{name: "bearer", header: "Authorization", value: "Bearer writer", status: http.StatusOK, body: "api_key:writer"},
+		{name: "admin", header: "X-API-Key", value: "admin", status: http.StatusOK, body: "api_key:admin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/wallet", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+			if tt.body != "" {
+				assert.Equal(t, tt.body, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestPrincipalWalletAccess(t *testing.T) {
+	allowed, other := uuid.New(), uuid.New()
+
+	restricted := &models.Principal{WalletIDs: []uuid.UUID{allowed}}
+	assert.True(t, restricted.CanAccessWallet(&models.Wallet{ID: allowed}))
+	assert.False(t, restricted.CanAccessWallet(&models.Wallet{ID: other}))
+
+	unrestricted := &models.Principal{}
+	assert.True(t, unrestricted.CanAccessWallet(&models.Wallet{ID: other}))
+}
+
+func TestHashAPIKey(t *testing.T) {
+	key, prefix, hash, err := GenerateAPIKey()
+	assert.NoError(t, err)
+	assert.Equal(t, key[:len(prefix)], prefix)
+	assert.Equal(t, HashAPIKey(key), hash)
+	assert.Len(t, hash, 64)
+}
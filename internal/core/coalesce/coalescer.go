@@ -0,0 +1,192 @@
+// Package coalesce объединяет одновременные зачисления и списания одного кошелька
+// в одну транзакцию базы данных.
+package coalesce
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/google/uuid"
+)
+
+// DefaultMaxBatch - число операций в группе по умолчанию
+const DefaultMaxBatch = 100
+
+// ErrClosed возвращается операциям, поставленным после Close или не дождавшимся выполнения группы до него
+var ErrClosed = errors.New("coalescer is closed")
+
+// Config задаёт группировку операций
+type Config struct {
+	// Window - сколько после первой операции группы ждать остальные операции того же кошелька
+	Window time.Duration
+	// MaxBatch - размер группы, при котором она выполняется, не дожидаясь конца окна
+	MaxBatch int
+}
+
+// Repository оборачивает репозиторий кошельков: зачисления и списания, поступившие для одного
+// кошелька в течение окна, выполняются одной транзакцией в порядке поступления. Каждый вызывающий
+// получает свой баланс или свою ошибку; остальные методы передаются репозиторию без изменений.
+type Repository struct {
+	repository.WalletRepository
+	cfg Config
+	log logger.Logger
+
+	// ctx ограничивает выполнение групп временем жизни Repository и отменяется в Close
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	groups map[uuid.UUID]*group
+}
+
+type group struct {
+	requests []*request
+	timer    *time.Timer
+}
+
+type request struct {
+	ctx    context.Context
+	params repository.OperationParams
+	done   chan result
+}
+
+type result struct {
+	balance int64
+	err     error
+}
+
+func New(wallets repository.WalletRepository, cfg Config, log logger.Logger) *Repository {
+	if cfg.MaxBatch <= 0 {
+		cfg.MaxBatch = DefaultMaxBatch
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Repository{
+		WalletRepository: wallets,
+		cfg:              cfg,
+		log:              log,
+		ctx:              ctx,
+		cancel:           cancel,
+		groups:           make(map[uuid.UUID]*group),
+	}
+}
+
+// Close прерывает выполняющиеся группы и отклоняет ожидающие и новые операции с ErrClosed.
+// Вызывается после остановки HTTP-сервера, до закрытия соединений с базой.
+func (r *Repository) Close() {
+	r.mu.Lock()
+	r.cancel()
+	groups := r.groups
+	r.groups = make(map[uuid.UUID]*group)
+	r.mu.Unlock()
+
+	for _, g := range groups {
+		g.timer.Stop()
+		for _, req := range g.requests {
+			req.done <- result{err: ErrClosed}
+		}
+	}
+}
+
+// ExecuteTxWithRetry ставит зачисление или списание в группу кошелька и ждёт её выполнения.
+// Запрос, отменённый до выполнения группы, из неё исключается; после начала выполнения
+// результат возвращается независимо от отмены, чтобы вызывающий не принял выполненную операцию за невыполненную.
+func (r *Repository) ExecuteTxWithRetry(ctx context.Context, params repository.OperationParams) (int64, error) {
+	if params.OperationType != models.OperationDeposit && params.OperationType != models.OperationWithdraw {
+		return r.WalletRepository.ExecuteTxWithRetry(ctx, params)
+	}
+
+	req := &request{ctx: ctx, params: params, done: make(chan result, 1)}
+	if !r.enqueue(req) {
+		return 0, ErrClosed
+	}
+	res := <-req.done
+	return res.balance, res.err
+}
+
+// enqueue ставит запрос в группу кошелька; после Close запрос не принимается
+func (r *Repository) enqueue(req *request) bool {
+	walletID := req.params.WalletID
+
+	r.mu.Lock()
+	if r.ctx.Err() != nil {
+		r.mu.Unlock()
+		return false
+	}
+	g, ok := r.groups[walletID]
+	if !ok {
+		g = &group{}
+		r.groups[walletID] = g
+		g.timer = time.AfterFunc(r.cfg.Window, func() { r.flush(walletID, g) })
+	}
+	g.requests = append(g.requests, req)
+	full := len(g.requests) >= r.cfg.MaxBatch
+	r.mu.Unlock()
+
+	if full {
+		r.flush(walletID, g)
+	}
+	return true
+}
+
+// flush выполняет группу, если её ещё не выполнили по таймеру или по заполнению
+func (r *Repository) flush(walletID uuid.UUID, g *group) {
+	r.mu.Lock()
+	if r.groups[walletID] != g {
+		r.mu.Unlock()
+		return
+	}
+	delete(r.groups, walletID)
+	g.timer.Stop()
+	r.mu.Unlock()
+
+	r.execute(walletID, g.requests)
+}
+
+func (r *Repository) execute(walletID uuid.UUID, requests []*request) {
+	pending := requests[:0:0]
+	for _, req := range requests {
+		if err := req.ctx.Err(); err != nil {
+			req.done <- result{err: err}
+			continue
+		}
+		pending = append(pending, req)
+	}
+
+	switch len(pending) {
+	case 0:
+		return
+	case 1:
+		req := pending[0]
+		balance, err := r.WalletRepository.ExecuteTxWithRetry(req.ctx, req.params)
+		req.done <- result{balance: balance, err: err}
+		return
+	}
+
+	items := make([]repository.OperationParams, len(pending))
+	for i, req := range pending {
+		items[i] = req.params
+	}
+
+	// Группа общая для нескольких запросов, поэтому не зависит от отмены каждого из них,
+	// а прерывается только закрытием Repository
+	outcomes, err := r.WalletRepository.ExecuteWalletOperationsWithRetry(r.ctx, walletID, items)
+	if err != nil {
+		r.log.Warn("Coalesced wallet operations failed",
+			logger.StringField("wallet_id", walletID.String()),
+			logger.Int64Field("operations", int64(len(items))),
+			logger.ErrorField("error", err))
+		for _, req := range pending {
+			req.done <- result{err: err}
+		}
+		return
+	}
+
+	for i, req := range pending {
+		req.done <- result{balance: outcomes[i].Balance, err: outcomes[i].Err}
+	}
+}
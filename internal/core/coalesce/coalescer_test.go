@@ -0,0 +1,256 @@
+package coalesce
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryWallets хранит балансы в памяти и, как и репозиторий в базе, проверяет списания
+// группы по порядку относительно баланса после предыдущих операций
+type memoryWallets struct {
+	repository.WalletRepository
+
+	mu       sync.Mutex
+	balances map[uuid.UUID]int64
+	groups   [][]repository.OperationParams
+	singles  []repository.OperationParams
+	groupErr error
+	// running, если задан, получает сигнал о начале группы, и группа ждёт отмены контекста
+	running chan struct{}
+}
+
+func newMemoryWallets() *memoryWallets {
+	return &memoryWallets{balances: make(map[uuid.UUID]int64)}
+}
+
+func (m *memoryWallets) apply(params repository.OperationParams) (int64, error) {
+	next := m.balances[params.WalletID] + params.OperationType.Sign()*params.Amount
+	if next < 0 {
+		return 0, repository.ErrInsufficientFunds
+	}
+	m.balances[params.WalletID] = next
+	return next, nil
+}
+
+func (m *memoryWallets) ExecuteTxWithRetry(_ context.Context, params repository.OperationParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.singles = append(m.singles, params)
+	return m.apply(params)
+}
+
+func (m *memoryWallets) ExecuteWalletOperationsWithRetry(ctx context.Context, _ uuid.UUID, items []repository.OperationParams) ([]repository.BatchItemOutcome, error) {
+	if m.running != nil {
+		m.running <- struct{}{}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.groups = append(m.groups, items)
+	if m.groupErr != nil {
+		return nil, m.groupErr
+	}
+
+	outcomes := make([]repository.BatchItemOutcome, len(items))
+	for i, params := range items {
+		outcomes[i].Balance, outcomes[i].Err = m.apply(params)
+	}
+	return outcomes, nil
+}
+
+type callResult struct {
+	balance int64
+	err     error
+}
+
+// submit отправляет операцию и ждёт, пока она встанет в группу, чтобы порядок в группе был известен
+func submit(t *testing.T, r *Repository, ctx context.Context, params repository.OperationParams) <-chan callResult {
+	t.Helper()
+
+	r.mu.Lock()
+	queued := 0
+	if g, ok := r.groups[params.WalletID]; ok {
+		queued = len(g.requests)
+	}
+	r.mu.Unlock()
+
+	done := make(chan callResult, 1)
+	go func() {
+		balance, err := r.ExecuteTxWithRetry(ctx, params)
+		done <- callResult{balance: balance, err: err}
+	}()
+
+	require.Eventually(t, func() bool {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		g, ok := r.groups[params.WalletID]
+		return !ok || len(g.requests) > queued
+	}, time.Second, time.Millisecond)
+	return done
+}
+
+func operation(walletID uuid.UUID, operationType models.OperationType, amount int64) repository.OperationParams {
+	return repository.OperationParams{WalletID: walletID, OperationType: operationType, Amount: amount}
+}
+
+func receive(t *testing.T, done <-chan callResult) callResult {
+	t.Helper()
+	select {
+	case res := <-done:
+		return res
+	case <-time.After(time.Second):
+		t.Fatal("operation did not complete")
+		return callResult{}
+	}
+}
+
+func TestGroupChecksWithdrawalsInOrder(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	wallets := newMemoryWallets()
+	r := New(wallets, Config{Window: time.Minute, MaxBatch: 4}, log)
+	walletID := uuid.New()
+
+	ctx := context.Background()
+	results := []<-chan callResult{
+		submit(t, r, ctx, operation(walletID, models.OperationDeposit, 100)),
+		submit(t, r, ctx, operation(walletID, models.OperationWithdraw, 70)),
+		submit(t, r, ctx, operation(walletID, models.OperationWithdraw, 50)),
+		submit(t, r, ctx, operation(walletID, models.OperationWithdraw, 30)),
+	}
+
+	expected := []callResult{{balance: 100}, {balance: 30}, {err: repository.ErrInsufficientFunds}, {balance: 0}}
+	for i, done := range results {
+		res := receive(t, done)
+		assert.Equal(t, expected[i].balance, res.balance, "operation %d", i)
+		assert.ErrorIs(t, res.err, expected[i].err, "operation %d", i)
+	}
+
+	require.Len(t, wallets.groups, 1, "operations must run in one group")
+	assert.Len(t, wallets.groups[0], 4)
+	assert.Empty(t, wallets.singles)
+}
+
+func TestGroupsPerWalletAfterWindow(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	wallets := newMemoryWallets()
+	r := New(wallets, Config{Window: 20 * time.Millisecond}, log)
+	first, second := uuid.New(), uuid.New()
+
+	ctx := context.Background()
+	a := submit(t, r, ctx, operation(first, models.OperationDeposit, 10))
+	b := submit(t, r, ctx, operation(first, models.OperationDeposit, 5))
+	c := submit(t, r, ctx, operation(second, models.OperationDeposit, 7))
+
+	assert.Equal(t, callResult{balance: 10}, receive(t, a))
+	assert.Equal(t, callResult{balance: 15}, receive(t, b))
+	assert.Equal(t, callResult{balance: 7}, receive(t, c))
+
+	// Единственная операция кошелька выполняется обычным способом
+	require.Len(t, wallets.groups, 1)
+	assert.Len(t, wallets.groups[0], 2)
+	require.Len(t, wallets.singles, 1)
+	assert.Equal(t, second, wallets.singles[0].WalletID)
+}
+
+func TestGroupErrorReturnedToEveryCaller(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	wallets := newMemoryWallets()
+	wallets.groupErr = repository.ErrRetriesExhausted
+	r := New(wallets, Config{Window: time.Minute, MaxBatch: 2}, log)
+	walletID := uuid.New()
+
+	ctx := context.Background()
+	a := submit(t, r, ctx, operation(walletID, models.OperationDeposit, 1))
+	b := submit(t, r, ctx, operation(walletID, models.OperationDeposit, 1))
+
+	assert.ErrorIs(t, receive(t, a).err, repository.ErrRetriesExhausted)
+	assert.ErrorIs(t, receive(t, b).err, repository.ErrRetriesExhausted)
+}
+
+func TestCanceledRequestLeavesGroup(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	wallets := newMemoryWallets()
+	r := New(wallets, Config{Window: time.Minute, MaxBatch: 3}, log)
+	walletID := uuid.New()
+
+	canceled, cancel := context.WithCancel(context.Background())
+	a := submit(t, r, canceled, operation(walletID, models.OperationDeposit, 1))
+	b := submit(t, r, context.Background(), operation(walletID, models.OperationDeposit, 2))
+	cancel()
+	c := submit(t, r, context.Background(), operation(walletID, models.OperationDeposit, 3))
+
+	assert.True(t, errors.Is(receive(t, a).err, context.Canceled))
+	assert.Equal(t, callResult{balance: 2}, receive(t, b))
+	assert.Equal(t, callResult{balance: 5}, receive(t, c))
+
+	require.Len(t, wallets.groups, 1)
+	assert.Len(t, wallets.groups[0], 2)
+}
+
+func TestCloseCancelsRunningGroup(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	wallets := newMemoryWallets()
+	wallets.running = make(chan struct{}, 1)
+	r := New(wallets, Config{Window: time.Minute, MaxBatch: 2}, log)
+	walletID := uuid.New()
+
+	// Запросы не отменяются, но группа прерывается закрытием
+	ctx := context.Background()
+	a := submit(t, r, ctx, operation(walletID, models.OperationDeposit, 1))
+	b := submit(t, r, ctx, operation(walletID, models.OperationDeposit, 1))
+	select {
+	case <-wallets.running:
+	case <-time.After(time.Second):
+		t.Fatal("group did not start")
+	}
+
+	r.Close()
+	assert.ErrorIs(t, receive(t, a).err, context.Canceled)
+	assert.ErrorIs(t, receive(t, b).err, context.Canceled)
+}
+
+func TestCloseRejectsWaitingAndNewRequests(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	wallets := newMemoryWallets()
+	r := New(wallets, Config{Window: time.Minute}, log)
+	walletID := uuid.New()
+
+	ctx := context.Background()
+	waiting := submit(t, r, ctx, operation(walletID, models.OperationDeposit, 1))
+	r.Close()
+	assert.ErrorIs(t, receive(t, waiting).err, ErrClosed)
+
+	_, err := r.ExecuteTxWithRetry(ctx, operation(walletID, models.OperationDeposit, 1))
+	assert.ErrorIs(t, err, ErrClosed)
+
+	// Операции, которые не группируются, передаются репозиторию как прежде
+	_, err = r.ExecuteTxWithRetry(ctx, operation(walletID, models.OperationTransfer, 1))
+	assert.NoError(t, err)
+
+	assert.Empty(t, wallets.groups)
+	assert.Len(t, wallets.singles, 1)
+}
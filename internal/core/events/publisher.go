@@ -0,0 +1,94 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/Nzyazin/itk/internal/core/models"
+)
+
+// Publisher доставляет события получателям. Доставка не реже одного раза: после сбоя событие
+// может быть отправлено повторно, поэтому получатели отбрасывают дубликаты по ID события.
+type Publisher interface {
+	Publish(ctx context.Context, event models.OutboxEvent) error
+}
+
+// ChannelPublisher передаёт события в канал внутри процесса
+type ChannelPublisher struct {
+	events chan models.OutboxEvent
+}
+
+func NewChannelPublisher(buffer int) *ChannelPublisher {
+	return &ChannelPublisher{events: make(chan models.OutboxEvent, buffer)}
+}
+
+// Events возвращает канал опубликованных событий
+func (p *ChannelPublisher) Events() <-chan models.OutboxEvent {
+	return p.events
+}
+
+// Publish ждёт места в канале, пока не отменён ctx
+func (p *ChannelPublisher) Publish(ctx context.Context, event models.OutboxEvent) error {
+	select {
+	case p.events <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// FilePublisher дописывает события в файл по одному JSON-объекту на строку
+type FilePublisher struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFilePublisher(path string) (*FilePublisher, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open events file: %w", err)
+	}
+	return &FilePublisher{file: file}, nil
+}
+
+// Publish записывает событие и сбрасывает его на диск, прежде чем событие будет отмечено опубликованным
+func (p *FilePublisher) Publish(_ context.Context, event models.OutboxEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, err := p.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write event: %w", err)
+	}
+	return p.file.Sync()
+}
+
+func (p *FilePublisher) Close() error {
+	return p.file.Close()
+}
+
+// FanOut публикует событие всем получателям по очереди. Событие считается опубликованным, только
+// если его приняли все получатели; при повторе оно снова уйдёт и тем, кто уже принял его.
+func FanOut(publishers ...Publisher) Publisher {
+	if len(publishers) == 1 {
+		return publishers[0]
+	}
+	return fanOut(publishers)
+}
+
+type fanOut []Publisher
+
+func (f fanOut) Publish(ctx context.Context, event models.OutboxEvent) error {
+	for _, publisher := range f {
+		if err := publisher.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package events
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/google/uuid"
+)
+
+// DefaultBatchSize - число событий, которое ретранслятор публикует за один проход
+const DefaultBatchSize = 100
+
+// ErrPreviousEventFailed означает, что событие не отправлялось: предыдущее событие
+// того же кошелька не опубликовано
+var ErrPreviousEventFailed = fmt.Errorf("%w: previous event of the wallet is not published", repository.ErrEventDeferred)
+
+// Relay публикует события из outbox в порядке их записи. Если событие кошелька опубликовать
+// не удалось, следующие события этого кошелька ждут, пока оно не будет опубликовано,
+// поэтому получатели видят события каждого кошелька по порядку.
+type Relay struct {
+	store     repository.OutboxRepository
+	publisher Publisher
+	log       logger.Logger
+	batchSize int
+}
+
+func NewRelay(store repository.OutboxRepository, publisher Publisher, log logger.Logger) *Relay {
+	return &Relay{store: store, publisher: publisher, log: log, batchSize: DefaultBatchSize}
+}
+
+// RunOnce публикует очередную пачку событий и возвращает число опубликованных
+func (r *Relay) RunOnce(ctx context.Context) (int, error) {
+	published, err := r.store.ProcessOutbox(ctx, r.batchSize, func(events []models.OutboxEvent) []error {
+		return r.publishInOrder(ctx, events)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("relay outbox: %w", err)
+	}
+	return published, nil
+}
+
+func (r *Relay) publishInOrder(ctx context.Context, events []models.OutboxEvent) []error {
+	results := make([]error, len(events))
+	failed := make(map[uuid.UUID]struct{})
+	for i, event := range events {
+		if _, ok := failed[event.WalletID]; ok {
+			results[i] = ErrPreviousEventFailed
+			continue
+		}
+
+		if err := r.publisher.Publish(ctx, event); err != nil {
+			results[i] = err
+			failed[event.WalletID] = struct{}{}
+			r.log.Warn("Failed to publish event",
+				logger.StringField("event_id", event.ID.String()),
+				logger.StringField("wallet_id", event.WalletID.String()),
+				logger.Int64Field("attempts", int64(event.Attempts+1)),
+				logger.ErrorField("error", err))
+		}
+	}
+	return results
+}
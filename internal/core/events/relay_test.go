@@ -0,0 +1,132 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Nzyazin/itk/internal/core/events"
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryOutbox хранит события в памяти и, как и хранилище в базе, выдаёт только неопубликованные
+type memoryOutbox struct {
+	events    []models.OutboxEvent
+	published map[int64]bool
+}
+
+func (o *memoryOutbox) ProcessOutbox(_ context.Context, limit int, publish func([]models.OutboxEvent) []error) (int, error) {
+	var pending []models.OutboxEvent
+	for _, event := range o.events {
+		if !o.published[event.Sequence] && len(pending) < limit {
+			pending = append(pending, event)
+		}
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	count := 0
+	for i, err := range publish(pending) {
+		if err == nil {
+			o.published[pending[i].Sequence] = true
+			count++
+		}
+	}
+	return count, nil
+}
+
+// flakyPublisher отклоняет первые failures попыток публикации выбранных событий
+type flakyPublisher struct {
+	failures  map[uuid.UUID]int
+	delivered []models.OutboxEvent
+}
+
+func (p *flakyPublisher) Publish(_ context.Context, event models.OutboxEvent) error {
+	if p.failures[event.ID] > 0 {
+		p.failures[event.ID]--
+		return errors.New("broker unavailable")
+	}
+	p.delivered = append(p.delivered, event)
+	return nil
+}
+
+func newOutbox(walletIDs ...uuid.UUID) *memoryOutbox {
+	outbox := &memoryOutbox{published: make(map[int64]bool)}
+	for i, walletID := range walletIDs {
+		outbox.events = append(outbox.events, models.OutboxEvent{
+			Sequence: int64(i + 1),
+			ID:       uuid.New(),
+			WalletID: walletID,
+			Type:     models.EventWalletCredited,
+		})
+	}
+	return outbox
+}
+
+func sequences(events []models.OutboxEvent, walletID uuid.UUID) []int64 {
+	var result []int64
+	for _, event := range events {
+		if event.WalletID == walletID {
+			result = append(result, event.Sequence)
+		}
+	}
+	return result
+}
+
+func TestRelayPublishesAllEventsInOrder(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	a, b := uuid.New(), uuid.New()
+	outbox := newOutbox(a, b, a, b, a)
+	publisher := &flakyPublisher{}
+	relay := events.NewRelay(outbox, publisher, log)
+
+	published, err := relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 5, published)
+	assert.Equal(t, []int64{1, 3, 5}, sequences(publisher.delivered, a))
+	assert.Equal(t, []int64{2, 4}, sequences(publisher.delivered, b))
+
+	published, err = relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, published, "published events must not be delivered again")
+}
+
+func TestRelayHoldsBackWalletAfterFailure(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	a, b := uuid.New(), uuid.New()
+	outbox := newOutbox(a, b, a, b)
+	publisher := &flakyPublisher{failures: map[uuid.UUID]int{outbox.events[0].ID: 1}}
+	relay := events.NewRelay(outbox, publisher, log)
+
+	published, err := relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, published)
+	assert.Empty(t, sequences(publisher.delivered, a), "later events of a wallet must wait for the failed one")
+	assert.Equal(t, []int64{2, 4}, sequences(publisher.delivered, b))
+
+	published, err = relay.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, published)
+	assert.Equal(t, []int64{1, 3}, sequences(publisher.delivered, a))
+}
+
+func TestChannelPublisherRespectsContext(t *testing.T) {
+	publisher := events.NewChannelPublisher(1)
+	event := models.OutboxEvent{ID: uuid.New()}
+	require.NoError(t, publisher.Publish(context.Background(), event))
+	assert.Equal(t, event.ID, (<-publisher.Events()).ID)
+
+	require.NoError(t, publisher.Publish(context.Background(), event))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, publisher.Publish(ctx, event), context.Canceled)
+}
@@ -0,0 +1,183 @@
+package export
+
+import (
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/google/uuid"
+)
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.08"
+
+// Коды ISO 20022, используемые в выписке
+const (
+	balanceOpening  = "OPBD"
+	balanceClosing  = "CLBD"
+	indicatorCredit = "CRDT"
+	indicatorDebit  = "DBIT"
+	entryBooked     = "BOOK"
+)
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camtGroupHeader struct {
+	XMLName   xml.Name `xml:"GrpHdr"`
+	MessageID string   `xml:"MsgId"`
+	CreatedAt string   `xml:"CreDtTm"`
+}
+
+type camtPeriod struct {
+	XMLName xml.Name `xml:"FrToDt"`
+	From    string   `xml:"FrDtTm"`
+	To      string   `xml:"ToDtTm"`
+}
+
+type camtAccount struct {
+	XMLName  xml.Name `xml:"Acct"`
+	ID       string   `xml:"Id>Othr>Id"`
+	Currency string   `xml:"Ccy"`
+}
+
+type camtBalance struct {
+	XMLName   xml.Name   `xml:"Bal"`
+	Type      string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount    camtAmount `xml:"Amt"`
+	Indicator string     `xml:"CdtDbtInd"`
+	Date      string     `xml:"Dt>DtTm"`
+}
+
+type camtEntry struct {
+	XMLName        xml.Name   `xml:"Ntry"`
+	Reference      string     `xml:"NtryRef"`
+	Amount         camtAmount `xml:"Amt"`
+	Indicator      string     `xml:"CdtDbtInd"`
+	Reversal       bool       `xml:"RvslInd,omitempty"`
+	Status         string     `xml:"Sts>Cd"`
+	BookingDate    string     `xml:"BookgDt>DtTm"`
+	ValueDate      string     `xml:"ValDt>DtTm"`
+	ServicerRef    string     `xml:"AcctSvcrRef"`
+	Code           string     `xml:"BkTxCd>Prtry>Cd"`
+	AdditionalInfo string     `xml:"AddtlNtryInf,omitempty"`
+}
+
+// camt053Writer записывает выписку в формате camt.053: обёртка документа открывается в Begin
+// и закрывается в End, а каждая операция записывается отдельным элементом Ntry
+type camt053Writer struct {
+	w        io.Writer
+	enc      *xml.Encoder
+	currency *models.Currency
+}
+
+func newCAMT053Writer(w io.Writer) *camt053Writer {
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return &camt053Writer{w: w, enc: enc}
+}
+
+func (c *camt053Writer) Begin(statement Statement) error {
+	c.currency = statement.Currency
+
+	if _, err := io.WriteString(c.w, xml.Header); err != nil {
+		return err
+	}
+	document := xml.StartElement{
+		Name: xml.Name{Local: "Document"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: camt053Namespace}},
+	}
+	for _, start := range []xml.StartElement{document, {Name: xml.Name{Local: "BkToCstmrStmt"}}} {
+		if err := c.enc.EncodeToken(start); err != nil {
+			return err
+		}
+	}
+
+	createdAt := formatTime(statement.CreatedAt)
+	if err := c.enc.Encode(camtGroupHeader{MessageID: reference(statement.ID), CreatedAt: createdAt}); err != nil {
+		return err
+	}
+
+	if err := c.enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: "Stmt"}}); err != nil {
+		return err
+	}
+	elements := []any{
+		struct {
+			XMLName xml.Name `xml:"Id"`
+			Value   string   `xml:",chardata"`
+		}{Value: reference(statement.ID)},
+		struct {
+			XMLName xml.Name `xml:"CreDtTm"`
+			Value   string   `xml:",chardata"`
+		}{Value: createdAt},
+		camtPeriod{From: formatTime(statement.From), To: formatTime(statement.To)},
+		camtAccount{ID: reference(statement.WalletID), Currency: statement.Currency.Code},
+		c.balance(balanceOpening, statement.Balances.Opening, statement.From),
+		c.balance(balanceClosing, statement.Balances.Closing, statement.To),
+	}
+	for _, element := range elements {
+		if err := c.enc.Encode(element); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *camt053Writer) Write(transaction models.Transaction) error {
+	entry := camtEntry{
+		Reference:   reference(transaction.ID),
+		Amount:      camtAmount{Currency: c.currency.Code, Value: formatAmount(transaction.Amount, c.currency)},
+		Indicator:   indicatorCredit,
+		Status:      entryBooked,
+		BookingDate: formatTime(postedAt(transaction)),
+		ValueDate:   formatTime(transaction.CreatedAt),
+		ServicerRef: reference(transaction.ID),
+		Code:        string(transaction.OperationType),
+	}
+	if transaction.OperationType.Sign() < 0 {
+		entry.Indicator = indicatorDebit
+	}
+	switch transaction.OperationType {
+	case models.OperationReversalDebit, models.OperationReversalCredit:
+		entry.Reversal = true
+	}
+	if transaction.CounterpartyWalletID != nil {
+		entry.AdditionalInfo = "counterparty wallet " + transaction.CounterpartyWalletID.String()
+	}
+	return c.enc.Encode(entry)
+}
+
+func (c *camt053Writer) End() error {
+	for _, name := range []string{"Stmt", "BkToCstmrStmt", "Document"} {
+		if err := c.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: name}}); err != nil {
+			return err
+		}
+	}
+	if err := c.enc.Flush(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(c.w, "\n")
+	return err
+}
+
+// balance возвращает остаток: сумма в camt.053 неотрицательна, знак задаёт CdtDbtInd
+func (c *camt053Writer) balance(kind string, amount int64, at time.Time) camtBalance {
+	indicator := indicatorCredit
+	if amount < 0 {
+		indicator, amount = indicatorDebit, -amount
+	}
+	return camtBalance{
+		Type:      kind,
+		Amount:    camtAmount{Currency: c.currency.Code, Value: formatAmount(amount, c.currency)},
+		Indicator: indicator,
+		Date:      formatTime(at),
+	}
+}
+
+// reference возвращает идентификатор без дефисов: ссылки camt.053 ограничены 35 символами
+func reference(id uuid.UUID) string {
+	return hex.EncodeToString(id[:])
+}
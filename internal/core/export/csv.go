@@ -0,0 +1,61 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/google/uuid"
+)
+
+var csvHeader = []string{
+	"transaction_id", "posted_at", "created_at", "operation_type", "amount", "currency",
+	"balance_after", "counterparty_wallet_id", "related_transaction_id",
+}
+
+// csvWriter записывает операции по одной в строке. Сумма списаний отрицательная, поэтому
+// сумма колонки amount равна разнице исходящего и входящего остатков.
+type csvWriter struct {
+	w        *csv.Writer
+	currency *models.Currency
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) Begin(statement Statement) error {
+	c.currency = statement.Currency
+	return c.w.Write(csvHeader)
+}
+
+func (c *csvWriter) Write(transaction models.Transaction) error {
+	balanceAfter := ""
+	if transaction.BalanceAfter != nil {
+		balanceAfter = formatAmount(*transaction.BalanceAfter, c.currency)
+	}
+
+	return c.w.Write([]string{
+		transaction.ID.String(),
+		formatTime(postedAt(transaction)),
+		formatTime(transaction.CreatedAt),
+		string(transaction.OperationType),
+		formatAmount(transaction.OperationType.Sign()*transaction.Amount, c.currency),
+		c.currency.Code,
+		balanceAfter,
+		optionalID(transaction.CounterpartyWalletID),
+		optionalID(transaction.RelatedTransactionID),
+	})
+}
+
+func (c *csvWriter) End() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func optionalID(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
@@ -0,0 +1,93 @@
+// Package export записывает выписку по кошельку в форматах для учётных систем. Операции
+// записываются по мере чтения, поэтому выписка любого размера не загружается в память целиком.
+package export
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Format - формат выгрузки
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatCAMT053 Format = "camt053" // ISO 20022 camt.053.001.08
+)
+
+// ParseFormat разбирает формат из параметра запроса
+func ParseFormat(value string) (Format, error) {
+	switch format := Format(value); format {
+	case FormatCSV, FormatCAMT053:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown export format %q", value)
+}
+
+// ContentType возвращает MIME-тип выгрузки
+func (f Format) ContentType() string {
+	if f == FormatCAMT053 {
+		return "application/xml; charset=utf-8"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// FileName возвращает имя файла выгрузки выписки кошелька
+func (f Format) FileName(walletID uuid.UUID, from, to time.Time) string {
+	extension := "csv"
+	if f == FormatCAMT053 {
+		extension = "xml"
+	}
+	return fmt.Sprintf("statement-%s-%s-%s.%s", walletID, from.UTC().Format("20060102"), to.UTC().Format("20060102"), extension)
+}
+
+// Statement описывает выписку за период [From, To); суммы - в минимальных единицах Currency
+type Statement struct {
+	ID        uuid.UUID
+	WalletID  uuid.UUID
+	Currency  *models.Currency
+	From      time.Time
+	To        time.Time
+	CreatedAt time.Time
+	Balances  models.StatementBalances
+}
+
+// Writer записывает выписку: Begin - один раз перед операциями, End - после всех операций
+type Writer interface {
+	Begin(statement Statement) error
+	Write(transaction models.Transaction) error
+	End() error
+}
+
+// NewWriter возвращает запись выписки в формате format
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatCAMT053:
+		return newCAMT053Writer(w), nil
+	}
+	return nil, fmt.Errorf("unknown export format %q", format)
+}
+
+// formatAmount переводит сумму из минимальных единиц в основные с точностью валюты
+func formatAmount(minorUnits int64, currency *models.Currency) string {
+	return decimal.New(minorUnits, -currency.Scale()).StringFixed(currency.Scale())
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// postedAt возвращает момент проведения операции; у операций без него - время создания
+func postedAt(transaction models.Transaction) time.Time {
+	if transaction.PostedAt != nil {
+		return *transaction.PostedAt
+	}
+	return transaction.CreatedAt
+}
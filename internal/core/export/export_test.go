@@ -0,0 +1,128 @@
+package export
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "перезаписать эталонные файлы в testdata")
+
+var (
+	walletID       = uuid.MustParse("7b0c8a1e-4d2f-4c55-9a1b-2f6f0e3d9c11")
+	counterpartyID = uuid.MustParse("e4a5b6c7-1111-4222-8333-944455566677")
+	periodFrom     = time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	periodTo       = time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func transaction(id string, operationType models.OperationType, amount, balanceAfter int64, postedAt time.Time) models.Transaction {
+	return models.Transaction{
+		ID:            uuid.MustParse(id),
+		WalletID:      walletID,
+		OperationType: operationType,
+		Amount:        amount,
+		Status:        models.TransactionStatusCompleted,
+		BalanceAfter:  &balanceAfter,
+		PostedAt:      &postedAt,
+		CreatedAt:     postedAt.Add(-15 * time.Millisecond),
+	}
+}
+
+// statementFixtures - выписки в валютах с разной точностью; входящий остаток USD отрицательный (овердрафт)
+func statementFixtures() map[string]struct {
+	statement    Statement
+	transactions []models.Transaction
+} {
+	transferOut := transaction("0f9e8d7c-0001-4000-8000-000000000002", models.OperationTransferOut, 2550, 6950, periodFrom.Add(26*time.Hour))
+	transferOut.CounterpartyWalletID = &counterpartyID
+	reversal := transaction("0f9e8d7c-0001-4000-8000-000000000003", models.OperationReversalCredit, 2550, 9500, periodFrom.Add(50*time.Hour))
+	reversal.RelatedTransactionID = &transferOut.ID
+
+	return map[string]struct {
+		statement    Statement
+		transactions []models.Transaction
+	}{
+		"usd": {
+			statement: Statement{
+				ID:        uuid.MustParse("5a1d0c3b-9e8f-4a7b-b6c5-d4e3f2a1b0c9"),
+				WalletID:  walletID,
+				Currency:  &models.Currency{Code: "USD", MinorUnits: 2},
+				From:      periodFrom,
+				To:        periodTo,
+				CreatedAt: time.Date(2026, 4, 2, 9, 30, 0, 0, time.UTC),
+				Balances:  models.StatementBalances{Opening: -500, Closing: 9400},
+			},
+			transactions: []models.Transaction{
+				transaction("0f9e8d7c-0001-4000-8000-000000000001", models.OperationDeposit, 10000, 9500, periodFrom.Add(2*time.Hour)),
+				transferOut,
+				reversal,
+				transaction("0f9e8d7c-0001-4000-8000-000000000004", models.OperationWithdraw, 100, 9400, periodFrom.Add(74*time.Hour+123456789)),
+			},
+		},
+		"jpy": {
+			statement: Statement{
+				ID:        uuid.MustParse("6b2e1d4c-af90-4b8c-87d6-e5f4a3b2c1d0"),
+				WalletID:  walletID,
+				Currency:  &models.Currency{Code: "JPY", MinorUnits: 0},
+				From:      periodFrom,
+				To:        periodTo,
+				CreatedAt: time.Date(2026, 4, 2, 9, 30, 0, 0, time.UTC),
+				Balances:  models.StatementBalances{Opening: 1200, Closing: 700},
+			},
+			transactions: []models.Transaction{
+				transaction("0f9e8d7c-0002-4000-8000-000000000001", models.OperationWithdraw, 500, 700, periodFrom.Add(3*time.Hour)),
+			},
+		},
+	}
+}
+
+func TestWritersGolden(t *testing.T) {
+	for name, fixture := range statementFixtures() {
+		for _, format := range []Format{FormatCSV, FormatCAMT053} {
+			t.Run(name+"_"+string(format), func(t *testing.T) {
+				var buf bytes.Buffer
+				writer, err := NewWriter(format, &buf)
+				require.NoError(t, err)
+
+				require.NoError(t, writer.Begin(fixture.statement))
+				for _, transaction := range fixture.transactions {
+					require.NoError(t, writer.Write(transaction))
+				}
+				require.NoError(t, writer.End())
+
+				golden := filepath.Join("testdata", name+"."+string(format))
+				if *update {
+					require.NoError(t, os.WriteFile(golden, buf.Bytes(), 0o644))
+				}
+				want, err := os.ReadFile(golden)
+				require.NoError(t, err)
+				assert.Equal(t, string(want), buf.String())
+			})
+		}
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	assert.Equal(t, "-5.00", formatAmount(-500, &models.Currency{MinorUnits: 2}))
+	assert.Equal(t, "0.05", formatAmount(5, &models.Currency{MinorUnits: 2}))
+	assert.Equal(t, "1200", formatAmount(1200, &models.Currency{MinorUnits: 0}))
+	assert.Equal(t, "1.005", formatAmount(1005, &models.Currency{MinorUnits: 3}))
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("camt053")
+	require.NoError(t, err)
+	assert.Equal(t, FormatCAMT053, format)
+	assert.Equal(t, "statement-"+walletID.String()+"-20260301-20260401.xml", format.FileName(walletID, periodFrom, periodTo))
+
+	_, err = ParseFormat("pdf")
+	assert.Error(t, err)
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/Nzyazin/itk/internal/core/auth"
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/usecase"
+	"github.com/gorilla/mux"
+)
+
+// APIKeyHandler обслуживает административный API ключей доступа
+type APIKeyHandler struct {
+	usecase usecase.APIKeyUsecase
+	log     logger.Logger
+}
+
+func NewAPIKeyHandler(usecase usecase.APIKeyUsecase, log logger.Logger) *APIKeyHandler {
+	return &APIKeyHandler{usecase: usecase, log: log}
+}
+
+func (h *APIKeyHandler) RegisterRoutes(router *mux.Router) {
+	admin := models.ScopeAdmin
+	router.Handle("/api/v1/api-keys", auth.Require(admin, h.ListAPIKeys)).Methods("GET")
+	router.Handle("/api/v1/api-keys", auth.Require(admin, h.CreateAPIKey)).Methods("POST")
+	router.Handle("/api/v1/api-keys/{key_id}/rotate", auth.Require(admin, h.RotateAPIKey)).Methods("POST")
+	router.Handle("/api/v1/api-keys/{key_id}", auth.Require(admin, h.RevokeAPIKey)).Methods("DELETE")
+}
+
+func (h *APIKeyHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.usecase.ListAPIKeys(r.Context())
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, keys)
+}
+
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req models.APIKeyRequest
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Warn("Failed to decode request body", logger.ErrorField("error", err))
+		respondWithError(w, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	issued, err := h.usecase.CreateAPIKey(r.Context(), req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, issued)
+}
+
+// RotateAPIKey выпускает замену ключа; тело запроса необязательно
+func (h *APIKeyHandler) RotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(w, r, "key_id", "Invalid key ID")
+	if !ok {
+		return
+	}
+
+	var req models.RotateAPIKeyRequest
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.log.Warn("Failed to decode request body", logger.ErrorField("error", err))
+		respondWithError(w, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	issued, err := h.usecase.RotateAPIKey(r.Context(), id, req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, issued)
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(w, r, "key_id", "Invalid key ID")
+	if !ok {
+		return
+	}
+
+	key, err := h.usecase.RevokeAPIKey(r.Context(), id)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, key)
+}
+
+func (h *APIKeyHandler) handleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidAPIKey):
+		respondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, usecase.ErrAPIKeyNotFound):
+		respondWithError(w, http.StatusNotFound, "API key not found")
+	case errors.Is(err, usecase.ErrAPIKeyRevoked):
+		respondWithError(w, http.StatusConflict, "API key is revoked")
+	default:
+		h.log.Error("Failed to process api key request", logger.ErrorField("error", err))
+		respondWithError(w, http.StatusInternalServerError, "Failed to process api key request")
+	}
+}
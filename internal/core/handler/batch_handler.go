@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Nzyazin/itk/internal/core/auth"
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/usecase"
+	"github.com/google/uuid"
+)
+
+// maxBatchBodyBytes ограничивает размер тела пакетного запроса
+const maxBatchBodyBytes = 8 << 20
+
+// batchInternalError - код непредвиденной ошибки; её текст клиенту не передаётся
+const batchInternalError = "INTERNAL_ERROR"
+
+// BatchItemResponse представляет результат одной операции пакета
+type BatchItemResponse struct {
+	Index     int       `json:"index"`
+	WalletID  uuid.UUID `json:"wallet_id"`
+	Status    string    `json:"status"`
+	Balance   string    `json:"balance,omitempty"`
+	ErrorCode string    `json:"error_code,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// BatchResponse представляет результаты пакета в порядке операций запроса
+type BatchResponse struct {
+	Mode      models.BatchMode    `json:"mode"`
+	Committed bool                `json:"committed"`
+	Results   []BatchItemResponse `json:"results"`
+}
+
+// ProcessBatch выполняет пакет операций. Атомарный пакет с ошибкой возвращает 422
+// и результаты всех операций, чтобы клиент видел, какая из них помешала выполнению.
+func (h *WalletHandler) ProcessBatch(w http.ResponseWriter, r *http.Request) {
+	var req models.BatchRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxBatchBodyBytes)
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Warn("Failed to decode request body", logger.ErrorField("error", err))
+		respondWithError(w, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	actor := auth.Actor(r.Context())
+	for i := range req.Operations {
+		req.Operations[i].Actor = actor
+	}
+
+	result, err := h.usecase.ProcessBatch(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidBatch) {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.handleOperationError(w, &models.WalletOperation{}, err)
+		return
+	}
+
+	response := BatchResponse{
+		Mode:      result.Mode,
+		Committed: result.Committed,
+		Results:   make([]BatchItemResponse, 0, len(result.Items)),
+	}
+	failed := false
+	for _, item := range result.Items {
+		itemResponse := BatchItemResponse{
+			Index:    item.Index,
+			WalletID: item.WalletID,
+			Status:   item.Status,
+		}
+		switch item.Status {
+		case models.BatchItemApplied:
+			itemResponse.Balance = item.Balance.StringFixed(item.Currency.Scale())
+		case models.BatchItemFailed:
+			failed = true
+			itemResponse.ErrorCode = batchErrorCode(item.Err)
+			itemResponse.Error = item.Err.Error()
+			if itemResponse.ErrorCode == batchInternalError {
+				h.log.Error("Failed to process batch operation",
+					logger.Int64Field("index", int64(item.Index)),
+					logger.StringField("wallet_id", item.WalletID.String()),
+					logger.ErrorField("error", item.Err))
+				itemResponse.Error = "internal error"
+			}
+		}
+		response.Results = append(response.Results, itemResponse)
+	}
+
+	status := http.StatusOK
+	if failed && result.Mode == models.BatchModeAtomic {
+		status = http.StatusUnprocessableEntity
+	}
+	respondWithJSON(w, status, response)
+}
+
+// batchErrorCode возвращает машиночитаемый код ошибки операции пакета
+func batchErrorCode(err error) string {
+	switch {
+	case errors.Is(err, usecase.ErrInsufficientFunds):
+		return "INSUFFICIENT_FUNDS"
+	case errors.Is(err, usecase.ErrWalletNotFound):
+		return "WALLET_NOT_FOUND"
+	case errors.Is(err, usecase.ErrInvalidAmount), errors.Is(err, usecase.ErrAmountPrecision):
+		return "INVALID_AMOUNT"
+	case errors.Is(err, usecase.ErrInvalidOperationType):
+		return "INVALID_OPERATION_TYPE"
+	case errors.Is(err, usecase.ErrSameWallet):
+		return "SAME_WALLET"
+	case errors.Is(err, usecase.ErrCurrencyMismatch):
+		return "CURRENCY_MISMATCH"
+	case errors.Is(err, usecase.ErrWalletNotActive):
+		return "WALLET_NOT_ACTIVE"
+	case errors.Is(err, usecase.ErrForbidden):
+		return "FORBIDDEN"
+	case errors.Is(err, usecase.ErrLimitExceeded):
+		return "LIMIT_EXCEEDED"
+	case errors.Is(err, usecase.ErrIdempotencyConflict):
+		return "IDEMPOTENCY_CONFLICT"
+	}
+	return batchInternalError
+}
@@ -0,0 +1,62 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Nzyazin/itk/internal/core/handler"
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/usecase"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchUsecase возвращает заданный результат пакета
+type batchUsecase struct {
+	usecase.WalletUsecase
+	result *models.BatchResult
+}
+
+func (u *batchUsecase) ProcessBatch(_ context.Context, _ models.BatchRequest) (*models.BatchResult, error) {
+	return u.result, nil
+}
+
+func TestProcessBatchHidesInternalErrors(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	uc := &batchUsecase{result: &models.BatchResult{
+		Mode:      models.BatchModeBestEffort,
+		Committed: false,
+		Items: []models.BatchItemResult{
+			{Index: 0, WalletID: uuid.New(), Status: models.BatchItemFailed,
+				Err: fmt.Errorf("%w: balance 0, requested 100", usecase.ErrInsufficientFunds)},
+			{Index: 1, WalletID: uuid.New(), Status: models.BatchItemFailed,
+				Err: errors.New(`pq: duplicate key value violates unique constraint "postings_pkey"`)},
+		},
+	}}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/wallets/batch", strings.NewReader(`{"operations": []}`))
+	handler.NewWalletHandler(uc, log).ProcessBatch(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var response handler.BatchResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.Len(t, response.Results, 2)
+
+	assert.Equal(t, "INSUFFICIENT_FUNDS", response.Results[0].ErrorCode)
+	assert.Equal(t, "insufficient funds: balance 0, requested 100", response.Results[0].Error)
+
+	assert.Equal(t, "INTERNAL_ERROR", response.Results[1].ErrorCode)
+	assert.Equal(t, "internal error", response.Results[1].Error)
+	assert.NotContains(t, rec.Body.String(), "postings_pkey")
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Nzyazin/itk/internal/core/auth"
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/usecase"
+	"github.com/gorilla/mux"
+)
+
+// CurrencyHandler обслуживает административный API справочника валют
+type CurrencyHandler struct {
+	usecase usecase.CurrencyUsecase
+	log     logger.Logger
+}
+
+func NewCurrencyHandler(usecase usecase.CurrencyUsecase, log logger.Logger) *CurrencyHandler {
+	return &CurrencyHandler{usecase: usecase, log: log}
+}
+
+func (h *CurrencyHandler) RegisterRoutes(router *mux.Router) {
+	read, admin := models.ScopeWalletRead, models.ScopeAdmin
+	router.Handle("/api/v1/currencies", auth.Require(read, h.ListCurrencies)).Methods("GET")
+	router.Handle("/api/v1/currencies", auth.Require(admin, h.CreateCurrency)).Methods("POST")
+	router.Handle("/api/v1/currencies/{code}", auth.Require(read, h.GetCurrency)).Methods("GET")
+	router.Handle("/api/v1/currencies/{code}", auth.Require(admin, h.UpdateCurrency)).Methods("PUT")
+	router.Handle("/api/v1/currencies/{code}", auth.Require(admin, h.DeleteCurrency)).Methods("DELETE")
+	router.Handle("/api/v1/currencies/{code}/activate", auth.Require(admin, h.setActive(true))).Methods("POST")
+	router.Handle("/api/v1/currencies/{code}/deactivate", auth.Require(admin, h.setActive(false))).Methods("POST")
+}
+
+func (h *CurrencyHandler) ListCurrencies(w http.ResponseWriter, r *http.Request) {
+	currencies, err := h.usecase.ListCurrencies(r.Context())
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, currencies)
+}
+
+func (h *CurrencyHandler) GetCurrency(w http.ResponseWriter, r *http.Request) {
+	currency, err := h.usecase.GetCurrency(r.Context(), mux.Vars(r)["code"])
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, currency)
+}
+
+func (h *CurrencyHandler) CreateCurrency(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.decodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	currency, err := h.usecase.CreateCurrency(r.Context(), *req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, currency)
+}
+
+// UpdateCurrency изменяет валюту; код берётся из пути
+func (h *CurrencyHandler) UpdateCurrency(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.decodeRequest(w, r)
+	if !ok {
+		return
+	}
+	req.Code = mux.Vars(r)["code"]
+
+	currency, err := h.usecase.UpdateCurrency(r.Context(), *req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, currency)
+}
+
+func (h *CurrencyHandler) DeleteCurrency(w http.ResponseWriter, r *http.Request) {
+	if err := h.usecase.DeleteCurrency(r.Context(), mux.Vars(r)["code"]); err != nil {
+		h.handleError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CurrencyHandler) setActive(active bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		currency, err := h.usecase.SetCurrencyActive(r.Context(), mux.Vars(r)["code"], active)
+		if err != nil {
+			h.handleError(w, err)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, currency)
+	}
+}
+
+func (h *CurrencyHandler) decodeRequest(w http.ResponseWriter, r *http.Request) (*models.CurrencyRequest, bool) {
+	var req models.CurrencyRequest
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Warn("Failed to decode request body", logger.ErrorField("error", err))
+		respondWithError(w, http.StatusBadRequest, "invalid request payload")
+		return nil, false
+	}
+	return &req, true
+}
+
+func (h *CurrencyHandler) handleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidCurrency):
+		respondWithError(w, http.StatusBadRequest, "Invalid currency code")
+	case errors.Is(err, usecase.ErrInvalidCurrencyData):
+		respondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, usecase.ErrCurrencyNotFound):
+		respondWithError(w, http.StatusNotFound, "Currency not found")
+	case errors.Is(err, usecase.ErrCurrencyAlreadyExists):
+		respondWithError(w, http.StatusConflict, "Currency already exists")
+	case errors.Is(err, usecase.ErrCurrencyInUse):
+		h.log.Warn("Currency is in use", logger.ErrorField("error", err))
+		respondWithError(w, http.StatusConflict, "Currency is in use")
+	case errors.Is(err, usecase.ErrRetriesExhausted):
+		h.log.Warn("Currency update retries exhausted", logger.ErrorField("error", err))
+		respondWithError(w, http.StatusServiceUnavailable, "Currency is being changed concurrently, retry later")
+	default:
+		h.log.Error("Failed to process currency request", logger.ErrorField("error", err))
+		respondWithError(w, http.StatusInternalServerError, "Failed to process currency request")
+	}
+}
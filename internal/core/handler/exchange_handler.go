@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// QuoteResponse представляет котировку обмена с суммами в основных единицах валют
+type QuoteResponse struct {
+	QuoteID        uuid.UUID `json:"quote_id"`
+	WalletID       uuid.UUID `json:"wallet_id"`
+	TargetWalletID uuid.UUID `json:"target_wallet_id"`
+	SourceAmount   string    `json:"source_amount"`
+	SourceCurrency string    `json:"source_currency"`
+	TargetAmount   string    `json:"target_amount"`
+	TargetCurrency string    `json:"target_currency"`
+	Rate           string    `json:"rate"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+// ConversionResponse представляет результат обмена по котировке
+type ConversionResponse struct {
+	Quote   QuoteResponse       `json:"quote"`
+	Debit   TransactionResponse `json:"debit"`
+	Credit  TransactionResponse `json:"credit"`
+	Balance string              `json:"balance"`
+}
+
+// CreateQuote фиксирует курс и суммы обмена на время действия котировки
+func (h *WalletHandler) CreateQuote(w http.ResponseWriter, r *http.Request) {
+	var req models.QuoteRequest
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Warn("Failed to decode request body", logger.ErrorField("error", err))
+		respondWithError(w, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+	if req.WalletID == uuid.Nil || req.TargetWalletID == uuid.Nil {
+		respondWithError(w, http.StatusBadRequest, "Wallet ID and target wallet ID are required")
+		return
+	}
+
+	details, err := h.usecase.CreateQuote(r.Context(), req)
+	if err != nil {
+		h.handleOperationError(w, &models.WalletOperation{
+			WalletID:       req.WalletID,
+			TargetWalletID: req.TargetWalletID,
+			Amount:         req.Amount,
+		}, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, newQuoteResponse(details))
+}
+
+// Convert выполняет обмен по котировке
+func (h *WalletHandler) Convert(w http.ResponseWriter, r *http.Request) {
+	quoteID, err := uuid.Parse(mux.Vars(r)["quote_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid quote ID")
+		return
+	}
+
+	result, err := h.usecase.Convert(r.Context(), quoteID)
+	if err != nil {
+		h.handleOperationError(w, &models.WalletOperation{}, err)
+		return
+	}
+
+	quote := newQuoteResponse(result.Quote)
+	respondWithJSON(w, http.StatusCreated, ConversionResponse{
+		Quote:   quote,
+		Debit:   conversionTransactionResponse(result.Debit, quote.SourceAmount, quote.SourceCurrency),
+		Credit:  conversionTransactionResponse(result.Credit, quote.TargetAmount, quote.TargetCurrency),
+		Balance: result.Balance.StringFixed(result.Quote.SourceCurrency.Scale()),
+	})
+}
+
+// AddExchangeRate добавляет курс обмена с периодом действия
+func (h *WalletHandler) AddExchangeRate(w http.ResponseWriter, r *http.Request) {
+	var req models.ExchangeRateRequest
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Warn("Failed to decode request body", logger.ErrorField("error", err))
+		respondWithError(w, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	rate, err := h.usecase.AddExchangeRate(r.Context(), req)
+	if err != nil {
+		h.handleOperationError(w, &models.WalletOperation{}, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, rate)
+}
+
+// GetExchangeRate возвращает курс пары, действующий сейчас или в момент, заданный параметром at
+func (h *WalletHandler) GetExchangeRate(w http.ResponseWriter, r *http.Request) {
+	at := time.Now()
+	if v := r.URL.Query().Get("at"); v != "" {
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, errInvalidParam("at").Error())
+			return
+		}
+		at = parsed
+	}
+
+	vars := mux.Vars(r)
+	rate, err := h.usecase.GetExchangeRate(r.Context(), vars["base"], vars["quote"], at)
+	if err != nil {
+		h.handleOperationError(w, &models.WalletOperation{}, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, rate)
+}
+
+func newQuoteResponse(details *models.QuoteDetails) QuoteResponse {
+	return QuoteResponse{
+		QuoteID:        details.Quote.ID,
+		WalletID:       details.Quote.WalletID,
+		TargetWalletID: details.Quote.TargetWalletID,
+		SourceAmount:   details.SourceAmount.StringFixed(details.SourceCurrency.Scale()),
+		SourceCurrency: details.SourceCurrency.Code,
+		TargetAmount:   details.TargetAmount.StringFixed(details.TargetCurrency.Scale()),
+		TargetCurrency: details.TargetCurrency.Code,
+		Rate:           details.Quote.Rate.String(),
+		ExpiresAt:      details.Quote.ExpiresAt,
+	}
+}
+
+func conversionTransactionResponse(t *models.Transaction, amount, currency string) TransactionResponse {
+	return TransactionResponse{
+		ID:                   t.ID,
+		OperationType:        string(t.OperationType),
+		Amount:               amount,
+		Currency:             currency,
+		Status:               t.Status,
+		CounterpartyWalletID: t.CounterpartyWalletID,
+		RelatedTransactionID: t.RelatedTransactionID,
+		ExchangeRate:         t.ExchangeRate,
+		QuoteID:              t.QuoteID,
+		CreatedAt:            t.CreatedAt,
+	}
+}
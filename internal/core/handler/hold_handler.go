@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// HoldResponse представляет резерв с суммами в основных единицах валюты
+type HoldResponse struct {
+	HoldID         uuid.UUID `json:"hold_id"`
+	WalletID       uuid.UUID `json:"wallet_id"`
+	Amount         string    `json:"amount"`
+	CapturedAmount string    `json:"captured_amount"`
+	Currency       string    `json:"currency"`
+	Status         string    `json:"status"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// AuthorizeHold резервирует средства на кошельке
+func (h *WalletHandler) AuthorizeHold(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(mux.Vars(r)["wallet_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid wallet ID")
+		return
+	}
+
+	var req models.HoldRequest
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Warn("Failed to decode request body", logger.ErrorField("error", err))
+		respondWithError(w, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+	req.WalletID = walletID
+
+	details, err := h.usecase.AuthorizeHold(r.Context(), req)
+	if err != nil {
+		h.handleOperationError(w, &models.WalletOperation{WalletID: walletID, Amount: req.Amount}, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, newHoldResponse(details))
+}
+
+// GetHold возвращает состояние резерва
+func (h *WalletHandler) GetHold(w http.ResponseWriter, r *http.Request) {
+	holdID, ok := parseHoldID(w, r)
+	if !ok {
+		return
+	}
+
+	details, err := h.usecase.GetHold(r.Context(), holdID)
+	if err != nil {
+		h.handleOperationError(w, &models.WalletOperation{}, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newHoldResponse(details))
+}
+
+// CaptureHold списывает резерв полностью или частично
+func (h *WalletHandler) CaptureHold(w http.ResponseWriter, r *http.Request) {
+	holdID, ok := parseHoldID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CaptureRequest
+	if r.ContentLength != 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+		defer r.Body.Close()
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.log.Warn("Failed to decode request body", logger.ErrorField("error", err))
+			respondWithError(w, http.StatusBadRequest, "invalid request payload")
+			return
+		}
+	}
+
+	details, err := h.usecase.CaptureHold(r.Context(), holdID, req.Amount)
+	if err != nil {
+		h.handleOperationError(w, &models.WalletOperation{Amount: req.Amount}, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newHoldResponse(details))
+}
+
+// VoidHold отменяет резерв
+func (h *WalletHandler) VoidHold(w http.ResponseWriter, r *http.Request) {
+	holdID, ok := parseHoldID(w, r)
+	if !ok {
+		return
+	}
+
+	details, err := h.usecase.VoidHold(r.Context(), holdID)
+	if err != nil {
+		h.handleOperationError(w, &models.WalletOperation{}, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newHoldResponse(details))
+}
+
+func parseHoldID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	holdID, err := uuid.Parse(mux.Vars(r)["hold_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid hold ID")
+		return uuid.Nil, false
+	}
+	return holdID, true
+}
+
+func newHoldResponse(details *models.HoldDetails) HoldResponse {
+	scale := details.Currency.Scale()
+	return HoldResponse{
+		HoldID:         details.Hold.ID,
+		WalletID:       details.Hold.WalletID,
+		Amount:         details.Amount.StringFixed(scale),
+		CapturedAmount: details.CapturedAmount.StringFixed(scale),
+		Currency:       details.Currency.Code,
+		Status:         string(details.Hold.Status),
+		ExpiresAt:      details.Hold.ExpiresAt,
+		CreatedAt:      details.Hold.CreatedAt,
+		UpdatedAt:      details.Hold.UpdatedAt,
+	}
+}
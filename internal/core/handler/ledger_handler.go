@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// LedgerBalanceResponse представляет сверку баланса кошелька с журналом в минимальных единицах валюты
+type LedgerBalanceResponse struct {
+	models.WalletLedgerBalance
+	Consistent bool `json:"consistent"`
+}
+
+// ReconciliationResponse представляет результат сверки всего журнала
+type ReconciliationResponse struct {
+	*models.LedgerReconciliation
+	Balanced bool `json:"balanced"`
+}
+
+// GetLedgerBalance возвращает баланс кошелька, рассчитанный по проводкам журнала
+func (h *WalletHandler) GetLedgerBalance(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(mux.Vars(r)["wallet_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid wallet ID")
+		return
+	}
+
+	balance, err := h.usecase.GetLedgerBalance(r.Context(), walletID)
+	if err != nil {
+		h.handleOperationError(w, &models.WalletOperation{WalletID: walletID}, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, LedgerBalanceResponse{
+		WalletLedgerBalance: *balance,
+		Consistent:          balance.IsConsistent(),
+	})
+}
+
+// ReconcileLedger сверяет журнал с балансами всех кошельков
+func (h *WalletHandler) ReconcileLedger(w http.ResponseWriter, r *http.Request) {
+	reconciliation, err := h.usecase.ReconcileLedger(r.Context())
+	if err != nil {
+		h.log.Error("Failed to reconcile ledger", logger.ErrorField("error", err))
+		respondWithError(w, http.StatusInternalServerError, "Failed to reconcile ledger")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ReconciliationResponse{
+		LedgerReconciliation: reconciliation,
+		Balanced:             reconciliation.IsBalanced(),
+	})
+}
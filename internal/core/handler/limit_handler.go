@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/shopspring/decimal"
+)
+
+// SpendingLimitsResponse представляет лимиты в основных единицах валюты; null - лимит не задан
+type SpendingLimitsResponse struct {
+	WalletID          *uuid.UUID `json:"wallet_id,omitempty"`
+	Currency          string     `json:"currency"`
+	MaxWithdrawal     *string    `json:"max_withdrawal"`
+	DailyWithdrawal   *string    `json:"daily_withdrawal"`
+	MonthlyWithdrawal *string    `json:"monthly_withdrawal"`
+	HourlyDeposits    *int64     `json:"hourly_deposits"`
+	UpdatedAt         *time.Time `json:"updated_at,omitempty"`
+}
+
+func (h *WalletHandler) GetSpendingLimits(w http.ResponseWriter, r *http.Request) {
+	scope, ok := limitScope(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.usecase.GetSpendingLimits(r.Context(), scope)
+	if err != nil {
+		h.handleLimitError(w, scope, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, newSpendingLimitsResponse(result))
+}
+
+// SetSpendingLimits заменяет лимиты целиком: поле, не переданное в запросе, снимает лимит
+func (h *WalletHandler) SetSpendingLimits(w http.ResponseWriter, r *http.Request) {
+	scope, ok := limitScope(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.SpendingLimitsRequest
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Warn("Failed to decode request body", logger.ErrorField("error", err))
+		respondWithError(w, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	result, err := h.usecase.SetSpendingLimits(r.Context(), scope, req)
+	if err != nil {
+		h.handleLimitError(w, scope, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, newSpendingLimitsResponse(result))
+}
+
+func (h *WalletHandler) DeleteSpendingLimits(w http.ResponseWriter, r *http.Request) {
+	scope, ok := limitScope(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.usecase.DeleteSpendingLimits(r.Context(), scope); err != nil {
+		h.handleLimitError(w, scope, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// limitScope определяет по пути, задаются лимиты кошелька или валюты
+func limitScope(w http.ResponseWriter, r *http.Request) (models.LimitScope, bool) {
+	vars := mux.Vars(r)
+	if code, ok := vars["code"]; ok {
+		return models.LimitScope{CurrencyCode: &code}, true
+	}
+
+	walletID, err := uuid.Parse(vars["wallet_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid wallet ID")
+		return models.LimitScope{}, false
+	}
+	return models.LimitScope{WalletID: &walletID}, true
+}
+
+func (h *WalletHandler) handleLimitError(w http.ResponseWriter, scope models.LimitScope, err error) {
+	op := &models.WalletOperation{}
+	if scope.WalletID != nil {
+		op.WalletID = *scope.WalletID
+	}
+	h.handleOperationError(w, op, err)
+}
+
+func newSpendingLimitsResponse(result *models.SpendingLimitsResult) SpendingLimitsResponse {
+	limits, currency := result.Limits, result.Currency
+	response := SpendingLimitsResponse{
+		WalletID:          limits.WalletID,
+		Currency:          currency.Code,
+		MaxWithdrawal:     formatLimit(limits.MaxWithdrawal, currency),
+		DailyWithdrawal:   formatLimit(limits.DailyWithdrawal, currency),
+		MonthlyWithdrawal: formatLimit(limits.MonthlyWithdrawal, currency),
+		HourlyDeposits:    limits.HourlyDeposits,
+	}
+	if !limits.UpdatedAt.IsZero() {
+		response.UpdatedAt = &limits.UpdatedAt
+	}
+	return response
+}
+
+// formatLimit переводит лимит из минимальных единиц в основные с точностью валюты
+func formatLimit(minor *int64, currency *models.Currency) *string {
+	if minor == nil {
+		return nil
+	}
+	formatted := decimal.New(*minor, -currency.Scale()).StringFixed(currency.Scale())
+	return &formatted
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// ReversalResponse представляет результат возврата операции
+type ReversalResponse struct {
+	Reversal       TransactionResponse `json:"reversal"`
+	OriginalID     uuid.UUID           `json:"original_transaction_id"`
+	OriginalStatus string              `json:"original_status"`
+	WalletID       uuid.UUID           `json:"wallet_id"`
+	Balance        string              `json:"balance"`
+}
+
+// ReverseTransaction выполняет полный или частичный возврат операции
+func (h *WalletHandler) ReverseTransaction(w http.ResponseWriter, r *http.Request) {
+	transactionID, err := uuid.Parse(mux.Vars(r)["transaction_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	var req models.ReversalRequest
+	if r.ContentLength != 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+		defer r.Body.Close()
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.log.Warn("Failed to decode request body", logger.ErrorField("error", err))
+			respondWithError(w, http.StatusBadRequest, "invalid request payload")
+			return
+		}
+	}
+	req.TransactionID = transactionID
+
+	result, err := h.usecase.ReverseTransaction(r.Context(), req)
+	if err != nil {
+		h.handleOperationError(w, &models.WalletOperation{Amount: req.Amount}, err)
+		return
+	}
+
+	scale := result.Currency.Scale()
+	respondWithJSON(w, http.StatusCreated, ReversalResponse{
+		Reversal: TransactionResponse{
+			ID:                   result.Reversal.ID,
+			OperationType:        string(result.Reversal.OperationType),
+			Amount:               result.Amount.StringFixed(scale),
+			Currency:             result.Currency.Code,
+			Status:               result.Reversal.Status,
+			RelatedTransactionID: result.Reversal.RelatedTransactionID,
+			CreatedAt:            result.Reversal.CreatedAt,
+		},
+		OriginalID:     result.Original.ID,
+		OriginalStatus: result.Original.Status,
+		WalletID:       result.Original.WalletID,
+		Balance:        result.Balance.StringFixed(scale),
+	})
+}
@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/export"
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// BalanceAsOfResponse представляет баланс кошелька на момент времени
+type BalanceAsOfResponse struct {
+	WalletID uuid.UUID `json:"wallet_id"`
+	At       time.Time `json:"at"`
+	Balance  string    `json:"balance"`
+	Currency string    `json:"currency"`
+}
+
+// StatementResponse представляет выписку по кошельку за период
+type StatementResponse struct {
+	WalletID       uuid.UUID             `json:"wallet_id"`
+	Currency       string                `json:"currency"`
+	From           time.Time             `json:"from"`
+	To             time.Time             `json:"to"`
+	OpeningBalance string                `json:"opening_balance"`
+	ClosingBalance string                `json:"closing_balance"`
+	Transactions   []TransactionResponse `json:"transactions"`
+}
+
+// GetBalanceAsOf возвращает баланс кошелька после всех операций, проведённых не позже момента at
+func (h *WalletHandler) GetBalanceAsOf(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(mux.Vars(r)["wallet_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid wallet ID")
+		return
+	}
+
+	at, err := parseTimeParam(r.URL.Query().Get("at"), "at")
+	if err != nil || at == nil {
+		respondWithError(w, http.StatusBadRequest, errInvalidParam("at").Error())
+		return
+	}
+
+	result, err := h.usecase.GetBalanceAsOf(r.Context(), walletID, *at)
+	if err != nil {
+		h.handleOperationError(w, &models.WalletOperation{WalletID: walletID}, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, BalanceAsOfResponse{
+		WalletID: walletID,
+		At:       result.At,
+		Balance:  result.Balance.StringFixed(result.Currency.Scale()),
+		Currency: result.Currency.Code,
+	})
+}
+
+// GetStatement возвращает выписку по кошельку за период [from, to): остатки на начало и конец
+// периода и изменившие баланс операции в порядке проведения
+func (h *WalletHandler) GetStatement(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(mux.Vars(r)["wallet_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid wallet ID")
+		return
+	}
+
+	from, to, ok := parsePeriod(w, r)
+	if !ok {
+		return
+	}
+
+	statement, err := h.usecase.GetStatement(r.Context(), walletID, from, to)
+	if err != nil {
+		h.handleOperationError(w, &models.WalletOperation{WalletID: walletID}, err)
+		return
+	}
+
+	scale := statement.Currency.Scale()
+	respondWithJSON(w, http.StatusOK, StatementResponse{
+		WalletID:       walletID,
+		Currency:       statement.Currency.Code,
+		From:           statement.From,
+		To:             statement.To,
+		OpeningBalance: statement.OpeningBalance.StringFixed(scale),
+		ClosingBalance: statement.ClosingBalance.StringFixed(scale),
+		Transactions:   transactionResponses(statement.Entries, statement.Currency),
+	})
+}
+
+// ExportStatement выгружает выписку по кошельку за период [from, to) файлом в формате format
+// (csv или camt053). Выписка записывается в ответ по мере чтения операций.
+func (h *WalletHandler) ExportStatement(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(mux.Vars(r)["wallet_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid wallet ID")
+		return
+	}
+
+	format, err := export.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, errInvalidParam("format").Error())
+		return
+	}
+
+	from, to, ok := parsePeriod(w, r)
+	if !ok {
+		return
+	}
+
+	out := &exportResponseWriter{
+		ResponseWriter: w,
+		controller:     http.NewResponseController(w),
+		contentType:    format.ContentType(),
+		fileName:       format.FileName(walletID, from, to),
+	}
+	// Общий WriteTimeout сервера оборвал бы выгрузку большой выписки: срок записи продлевается
+	// при каждом сбросе ответа клиенту
+	if err := out.extendDeadline(); err != nil {
+		h.log.Warn("Failed to extend export write deadline", logger.ErrorField("error", err))
+	}
+	err = h.usecase.ExportStatement(r.Context(), walletID, from, to, format, out)
+	if err == nil {
+		return
+	}
+	if !out.started {
+		h.handleOperationError(w, &models.WalletOperation{WalletID: walletID}, err)
+		return
+	}
+
+	// Статус 200 уже отправлен: обрываем соединение, чтобы клиент не принял неполный файл за выписку
+	h.log.Error("Statement export interrupted",
+		logger.ErrorField("error", err),
+		logger.StringField("wallet_id", walletID.String()))
+	panic(http.ErrAbortHandler)
+}
+
+const (
+	// exportFlushInterval - как часто записанная часть выписки отправляется клиенту
+	exportFlushInterval = time.Second
+	// exportWriteTimeout - срок записи ответа после очередного сброса: выгрузка обрывается,
+	// только если клиент перестал принимать данные
+	exportWriteTimeout = 30 * time.Second
+)
+
+// exportResponseWriter отправляет заголовки файла при первой записи, чтобы до неё
+// ошибку можно было вернуть обычным ответом, и периодически сбрасывает ответ клиенту
+type exportResponseWriter struct {
+	http.ResponseWriter
+	controller  *http.ResponseController
+	contentType string
+	fileName    string
+	started     bool
+	flushedAt   time.Time
+}
+
+func (e *exportResponseWriter) Write(p []byte) (int, error) {
+	if !e.started {
+		e.started = true
+		e.flushedAt = time.Now()
+		e.Header().Set("Content-Type", e.contentType)
+		e.Header().Set("Content-Disposition", `attachment; filename="`+e.fileName+`"`)
+		e.WriteHeader(http.StatusOK)
+	}
+
+	n, err := e.ResponseWriter.Write(p)
+	if err != nil || time.Since(e.flushedAt) < exportFlushInterval {
+		return n, err
+	}
+	return n, e.flush()
+}
+
+func (e *exportResponseWriter) flush() error {
+	if err := e.controller.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	e.flushedAt = time.Now()
+	return e.extendDeadline()
+}
+
+func (e *exportResponseWriter) extendDeadline() error {
+	err := e.controller.SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+	if errors.Is(err, http.ErrNotSupported) {
+		return nil
+	}
+	return err
+}
+
+// parsePeriod разбирает обязательные параметры from и to; при ошибке отвечает 400
+func parsePeriod(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, bool) {
+	query := r.URL.Query()
+	from, err := parseTimeParam(query.Get("from"), "from")
+	if err != nil || from == nil {
+		respondWithError(w, http.StatusBadRequest, errInvalidParam("from").Error())
+		return time.Time{}, time.Time{}, false
+	}
+	to, err := parseTimeParam(query.Get("to"), "to")
+	if err != nil || to == nil {
+		respondWithError(w, http.StatusBadRequest, errInvalidParam("to").Error())
+		return time.Time{}, time.Time{}, false
+	}
+	return *from, *to, true
+}
@@ -0,0 +1,102 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/export"
+	"github.com/Nzyazin/itk/internal/core/handler"
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/usecase"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exportUsecase выполняет выгрузку функцией export; остальные методы не используются
+type exportUsecase struct {
+	usecase.WalletUsecase
+	export func(w io.Writer) error
+}
+
+func (u *exportUsecase) ExportStatement(_ context.Context, _ uuid.UUID, _, _ time.Time, _ export.Format, w io.Writer) error {
+	return u.export(w)
+}
+
+func exportRequest(walletID uuid.UUID) *http.Request {
+	req := httptest.NewRequest(http.MethodGet,
+		"/api/v1/wallets/"+walletID.String()+"/export?format=csv&from=2026-03-01T00:00:00Z&to=2026-04-01T00:00:00Z", nil)
+	return mux.SetURLVars(req, map[string]string{"wallet_id": walletID.String()})
+}
+
+func TestExportStatementSendsHeadersOnFirstWrite(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	walletID := uuid.New()
+	rec := httptest.NewRecorder()
+	h := handler.NewWalletHandler(&exportUsecase{export: func(w io.Writer) error {
+		// До первой записи ответ ещё можно заменить ошибкой
+		assert.Empty(t, rec.Header().Get("Content-Type"))
+		assert.Empty(t, rec.Header().Get("Content-Disposition"))
+
+		_, err := io.WriteString(w, "transaction_id\n")
+		require.NoError(t, err)
+		assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+		return nil
+	}}, log)
+
+	h.ExportStatement(rec, exportRequest(walletID))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "transaction_id\n", rec.Body.String())
+	assert.Equal(t,
+		`attachment; filename="statement-`+walletID.String()+`-20260301-20260401.csv"`,
+		rec.Header().Get("Content-Disposition"))
+}
+
+func TestExportStatementErrorBeforeStreaming(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	rec := httptest.NewRecorder()
+	h := handler.NewWalletHandler(&exportUsecase{export: func(io.Writer) error {
+		return fmt.Errorf("%w: %s", usecase.ErrWalletNotFound, "missing")
+	}}, log)
+
+	h.ExportStatement(rec, exportRequest(uuid.New()))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Empty(t, rec.Header().Get("Content-Disposition"))
+
+	var body struct {
+		Error string `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "Wallet not found", body.Error)
+}
+
+func TestExportStatementErrorWhileStreamingAbortsResponse(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	rec := httptest.NewRecorder()
+	h := handler.NewWalletHandler(&exportUsecase{export: func(w io.Writer) error {
+		if _, err := io.WriteString(w, "transaction_id\n"); err != nil {
+			return err
+		}
+		return fmt.Errorf("connection reset")
+	}}, log)
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h.ExportStatement(rec, exportRequest(uuid.New()))
+	})
+}
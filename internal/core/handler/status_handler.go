@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Nzyazin/itk/internal/core/auth"
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// ChangeWalletStatus меняет статус кошелька и возвращает кошелёк в новом статусе
+func (h *WalletHandler) ChangeWalletStatus(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(mux.Vars(r)["wallet_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid wallet ID")
+		return
+	}
+
+	var req models.WalletStatusRequest
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Warn("Failed to decode request body", logger.ErrorField("error", err))
+		respondWithError(w, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	req.Actor = auth.Actor(r.Context())
+
+	details, err := h.usecase.ChangeWalletStatus(r.Context(), walletID, req)
+	if err != nil {
+		h.handleOperationError(w, &models.WalletOperation{WalletID: walletID}, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, newWalletResponse(details))
+}
+
+// ListWalletStatusHistory возвращает историю смены статусов кошелька
+func (h *WalletHandler) ListWalletStatusHistory(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(mux.Vars(r)["wallet_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid wallet ID")
+		return
+	}
+
+	history, err := h.usecase.ListWalletStatusHistory(r.Context(), walletID)
+	if err != nil {
+		h.handleOperationError(w, &models.WalletOperation{WalletID: walletID}, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, history)
+}
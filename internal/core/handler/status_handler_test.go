@@ -0,0 +1,141 @@
+package handler_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Nzyazin/itk/internal/core/auth"
+	"github.com/Nzyazin/itk/internal/core/handler"
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/Nzyazin/itk/internal/core/usecase"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// adminRepo хранит кошельки в памяти и запоминает изменения, дошедшие до хранилища
+type adminRepo struct {
+	repository.WalletRepository
+	wallets map[uuid.UUID]*models.Wallet
+	changes []string
+	actor   string
+}
+
+func (r *adminRepo) GetByID(_ context.Context, id uuid.UUID) (*models.Wallet, error) {
+	wallet, ok := r.wallets[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", repository.ErrNotFound, id)
+	}
+	return wallet, nil
+}
+
+func (r *adminRepo) GetCurrencyByCode(_ context.Context, code string) (*models.Currency, error) {
+	return &models.Currency{Code: code, MinorUnits: 2, IsFractional: true, IsActive: true}, nil
+}
+
+func (r *adminRepo) ChangeStatusWithRetry(_ context.Context, change *models.WalletStatusChange) (*models.Wallet, error) {
+	r.changes = append(r.changes, "status")
+	r.actor = change.Actor
+	wallet := r.wallets[change.WalletID]
+	wallet.Status = change.ToStatus
+	return wallet, nil
+}
+
+func (r *adminRepo) SetOverdraftLimit(_ context.Context, id uuid.UUID, limit int64) (*models.Wallet, error) {
+	r.changes = append(r.changes, "overdraft")
+	wallet := r.wallets[id]
+	wallet.OverdraftLimit = limit
+	return wallet, nil
+}
+
+func (r *adminRepo) SetSpendingLimits(_ context.Context, _ *models.SpendingLimits) error {
+	r.changes = append(r.changes, "limits")
+	return nil
+}
+
+func TestWalletAdminRequiresWalletAccess(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	allowed := &models.Wallet{ID: uuid.New(), CurrencyCode: "USD", Status: models.WalletActive}
+	other := &models.Wallet{ID: uuid.New(), CurrencyCode: "USD", Status: models.WalletActive}
+	principal := &models.Principal{
+		Actor:     "api_key:restricted",
+		Scopes:    []models.Scope{models.ScopeAdmin},
+		WalletIDs: []uuid.UUID{allowed.ID},
+	}
+
+	endpoints := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		handle func(h *handler.WalletHandler) http.HandlerFunc
+	}{
+		{"status", http.MethodPost, "/status", `{"status": "FROZEN", "reason": "AML review", "actor": "forged"}`,
+			func(h *handler.WalletHandler) http.HandlerFunc { return h.ChangeWalletStatus }},
+		{"overdraft", http.MethodPut, "/overdraft", `{"limit": "100"}`,
+			func(h *handler.WalletHandler) http.HandlerFunc { return h.SetOverdraftLimit }},
+		{"limits", http.MethodPut, "/limits", `{"daily_withdrawal": "100"}`,
+			func(h *handler.WalletHandler) http.HandlerFunc { return h.SetSpendingLimits }},
+	}
+	for _, endpoint := range endpoints {
+		t.Run(endpoint.name, func(t *testing.T) {
+			repo := &adminRepo{wallets: map[uuid.UUID]*models.Wallet{allowed.ID: allowed, other.ID: other}}
+			h := handler.NewWalletHandler(usecase.NewWalletUsecase(repo, nil, log), log)
+
+			call := func(walletID uuid.UUID) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(endpoint.method, "/api/v1/wallets/"+walletID.String()+endpoint.path,
+					strings.NewReader(endpoint.body))
+				req = mux.SetURLVars(req, map[string]string{"wallet_id": walletID.String()})
+				req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+				rec := httptest.NewRecorder()
+				endpoint.handle(h)(rec, req)
+				return rec
+			}
+
+			rec := call(other.ID)
+			assert.Equal(t, http.StatusForbidden, rec.Code, rec.Body.String())
+			assert.Empty(t, repo.changes, "change of an inaccessible wallet must not reach the repository")
+
+			rec = call(allowed.ID)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.Equal(t, []string{endpoint.name}, repo.changes)
+		})
+	}
+}
+
+func TestChangeWalletStatusRecordsAuthenticatedActor(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	wallet := &models.Wallet{ID: uuid.New(), CurrencyCode: "USD", Status: models.WalletActive}
+	repo := &adminRepo{wallets: map[uuid.UUID]*models.Wallet{wallet.ID: wallet}}
+	h := handler.NewWalletHandler(usecase.NewWalletUsecase(repo, nil, log), log)
+
+	body := `{"status": "FROZEN", "reason": "AML review", "actor": "compliance@example.com"}`
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/wallets/"+wallet.ID.String()+"/status", strings.NewReader(body))
+		return mux.SetURLVars(req, map[string]string{"wallet_id": wallet.ID.String()})
+	}
+
+	// Инициатор из тела запроса не принимается
+	rec := httptest.NewRecorder()
+	h.ChangeWalletStatus(rec, newRequest())
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+	assert.Empty(t, repo.changes)
+
+	req := newRequest()
+	req = req.WithContext(auth.WithPrincipal(req.Context(), &models.Principal{Actor: "api_key:admin"}))
+	rec = httptest.NewRecorder()
+	h.ChangeWalletStatus(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "api_key:admin", repo.actor)
+}
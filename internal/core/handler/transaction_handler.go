@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/shopspring/decimal"
+)
+
+// TransactionResponse представляет операцию в истории кошелька
+type TransactionResponse struct {
+	ID            uuid.UUID `json:"id"`
+	OperationType string    `json:"operation_type"`
+	Amount        string    `json:"amount"`
+	Currency      string    `json:"currency"`
+	Status        string    `json:"status"`
+	CounterpartyWalletID *uuid.UUID `json:"counterparty_wallet_id,omitempty"`
+	RelatedTransactionID *uuid.UUID `json:"related_transaction_id,omitempty"`
+	FailureReason *models.FailureReason `json:"failure_reason,omitempty"`
+	ExchangeRate  *decimal.Decimal `json:"exchange_rate,omitempty"`
+	QuoteID       *uuid.UUID `json:"quote_id,omitempty"`
+	BalanceAfter  string    `json:"balance_after,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TransactionListResponse представляет страницу истории операций кошелька
+type TransactionListResponse struct {
+	WalletID     uuid.UUID             `json:"wallet_id"`
+	Transactions []TransactionResponse `json:"transactions"`
+	NextCursor   string                `json:"next_cursor,omitempty"`
+}
+
+// ListTransactions возвращает историю операций кошелька с пагинацией по курсору
+func (h *WalletHandler) ListTransactions(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(mux.Vars(r)["wallet_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid wallet ID")
+		return
+	}
+
+	filter, err := parseTransactionFilter(r)
+	if err != nil {
+		h.log.Warn("Invalid transactions filter", logger.ErrorField("error", err))
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.WalletID = walletID
+
+	page, err := h.usecase.ListTransactions(r.Context(), *filter)
+	if err != nil {
+		h.handleOperationError(w, &models.WalletOperation{WalletID: walletID}, err)
+		return
+	}
+
+	response := TransactionListResponse{
+		WalletID:     walletID,
+		Transactions: transactionResponses(page.Entries, page.Currency),
+	}
+	if page.Next != nil {
+		response.NextCursor = page.Next.Encode()
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func transactionResponses(entries []models.TransactionEntry, currency *models.Currency) []TransactionResponse {
+	scale := currency.Scale()
+	responses := make([]TransactionResponse, 0, len(entries))
+	for _, entry := range entries {
+		response := TransactionResponse{
+			ID:            entry.Transaction.ID,
+			OperationType: string(entry.Transaction.OperationType),
+			Amount:        entry.Amount.StringFixed(scale),
+			Currency:      currency.Code,
+			Status:        entry.Transaction.Status,
+			CounterpartyWalletID: entry.Transaction.CounterpartyWalletID,
+			RelatedTransactionID: entry.Transaction.RelatedTransactionID,
+			FailureReason: entry.Transaction.FailureReason,
+			ExchangeRate:  entry.Transaction.ExchangeRate,
+			QuoteID:       entry.Transaction.QuoteID,
+			CreatedAt:     entry.Transaction.CreatedAt,
+		}
+		if entry.BalanceAfter != nil {
+			response.BalanceAfter = entry.BalanceAfter.StringFixed(scale)
+		}
+		responses = append(responses, response)
+	}
+	return responses
+}
+
+func parseTransactionFilter(r *http.Request) (*models.TransactionFilter, error) {
+	query := r.URL.Query()
+	filter := &models.TransactionFilter{}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return nil, errInvalidParam("limit")
+		}
+		filter.Limit = limit
+	}
+
+	if v := query.Get("cursor"); v != "" {
+		cursor, err := models.ParseTransactionCursor(v)
+		if err != nil {
+			return nil, errInvalidParam("cursor")
+		}
+		filter.After = cursor
+	}
+
+	if v := query.Get("operation_type"); v != "" {
+		opType := models.OperationType(strings.ToUpper(v))
+		if !opType.IsTransactionType() {
+			return nil, errInvalidParam("operation_type")
+		}
+		filter.OperationType = opType
+	}
+
+	if v := query.Get("status"); v != "" {
+		status := strings.ToUpper(v)
+		if !models.IsTransactionStatus(status) {
+			return nil, errInvalidParam("status")
+		}
+		filter.Status = status
+	}
+
+	from, err := parseTimeParam(query.Get("from"), "from")
+	if err != nil {
+		return nil, err
+	}
+	filter.From = from
+
+	to, err := parseTimeParam(query.Get("to"), "to")
+	if err != nil {
+		return nil, err
+	}
+	filter.To = to
+
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, errInvalidParam("from")
+	}
+
+	return filter, nil
+}
+
+func parseTimeParam(value, name string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, errInvalidParam(name)
+	}
+	return &t, nil
+}
+
+func errInvalidParam(name string) error {
+	return fmt.Errorf("invalid query parameter: %s", name)
+}
@@ -0,0 +1,88 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/handler"
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/usecase"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// historyUsecase запоминает фильтр истории и возвращает пустую страницу
+type historyUsecase struct {
+	usecase.WalletUsecase
+	filter *models.TransactionFilter
+}
+
+func (u *historyUsecase) ListTransactions(_ context.Context, filter models.TransactionFilter) (*models.TransactionPage, error) {
+	u.filter = &filter
+	return &models.TransactionPage{Currency: &models.Currency{Code: "USD", MinorUnits: 2}}, nil
+}
+
+func listTransactions(t *testing.T, query string) (*httptest.ResponseRecorder, *historyUsecase) {
+	t.Helper()
+	log, cleanup := logger.NewLogger()
+	t.Cleanup(cleanup)
+
+	walletID := uuid.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/"+walletID.String()+"/transactions?"+query, nil)
+	req = mux.SetURLVars(req, map[string]string{"wallet_id": walletID.String()})
+
+	uc := &historyUsecase{}
+	rec := httptest.NewRecorder()
+	handler.NewWalletHandler(uc, log).ListTransactions(rec, req)
+	return rec, uc
+}
+
+func TestListTransactionsFilter(t *testing.T) {
+	cursor := models.TransactionCursor{ID: uuid.New()}
+	rec, uc := listTransactions(t, "operation_type=withdraw&status=failed&from=2026-03-01T00:00:00Z&to=2026-04-01T00:00:00%2B03:00&limit=10&cursor="+cursor.Encode())
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	require.NotNil(t, uc.filter)
+	assert.Equal(t, models.OperationWithdraw, uc.filter.OperationType)
+	assert.Equal(t, "FAILED", uc.filter.Status)
+	assert.Equal(t, "2026-03-01T00:00:00Z", uc.filter.From.UTC().Format(time.RFC3339))
+	assert.Equal(t, "2026-03-31T21:00:00Z", uc.filter.To.UTC().Format(time.RFC3339))
+	assert.Equal(t, 10, uc.filter.Limit)
+	require.NotNil(t, uc.filter.After)
+	assert.Equal(t, cursor.ID, uc.filter.After.ID)
+}
+
+func TestListTransactionsRejectsInvalidFilter(t *testing.T) {
+	tests := map[string]struct {
+		query string
+		param string
+	}{
+		"malformed cursor":      {"cursor=not-a-cursor", "cursor"},
+		"cursor without id":     {"cursor=MTcwMDAwMDAwMDAwMDAwMDAwMA", "cursor"},
+		"unknown type":          {"operation_type=GIFT", "operation_type"},
+		"unknown status":        {"status=DONE", "status"},
+		"date without timezone": {"from=2026-03-01", "from"},
+		"empty period":          {"from=2026-03-01T00:00:00Z&to=2026-03-01T00:00:00Z", "from"},
+		"zero limit":            {"limit=0", "limit"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec, uc := listTransactions(t, tt.query)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Nil(t, uc.filter, "usecase must not be called")
+
+			var body struct {
+				Error string `json:"error"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, "invalid query parameter: "+tt.param, body.Error)
+		})
+	}
+}
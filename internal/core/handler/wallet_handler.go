@@ -5,16 +5,17 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"regexp"
+	"strconv"
 	"strings"
 	"context"
+	"time"
 
+	"github.com/Nzyazin/itk/internal/core/auth"
 	"github.com/Nzyazin/itk/internal/core/models"
 	"github.com/Nzyazin/itk/internal/core/usecase"
 	"github.com/Nzyazin/itk/internal/core/logger"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
-	"github.com/shopspring/decimal"
 )
 
 type WalletHandler struct {
@@ -28,15 +29,60 @@ type OperationResponse struct {
 	WalletID uuid.UUID `json:"wallet_id"`
 }
 
-var amountRegexp = regexp.MustCompile(`^\s*\d{1,9}([.,]\d{1,2})?\s*$`)
+// WalletResponse представляет кошелёк с балансом в основных единицах валюты
+type WalletResponse struct {
+	WalletID  uuid.UUID `json:"wallet_id"`
+	Balance   string    `json:"balance"`
+	HeldBalance      string `json:"held_balance"`
+	AvailableBalance string `json:"available_balance"`
+	OverdraftLimit   string `json:"overdraft_limit"`
+	AvailableCredit  string `json:"available_credit"`
+	Currency  string    `json:"currency"`
+	Status    models.WalletStatus `json:"status"`
+	OwnerRef  *string   `json:"owner_ref,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+const (
+    idempotencyKeyHeader = "Idempotency-Key"
+    maxIdempotencyKeyLen = 255
+)
 
 func NewWalletHandler(usecase usecase.WalletUsecase, log logger.Logger) *WalletHandler {
 	return &WalletHandler{usecase: usecase, log: log}
 }
 
 func (h *WalletHandler) RegisterRoutes(router *mux.Router) {
-	router.HandleFunc("/api/v1/wallet", h.ProcessWalletOperation).Methods("POST")
-	// router.HandleFunc("/api/v1/wallets/{wallet_id}", h.GetWallet).Methods("GET")
+	read, write, admin := models.ScopeWalletRead, models.ScopeWalletWrite, models.ScopeAdmin
+	router.Handle("/api/v1/wallet", auth.Require(write, h.ProcessWalletOperation)).Methods("POST")
+	router.Handle("/api/v1/wallet/batch", auth.Require(write, h.ProcessBatch)).Methods("POST")
+	router.Handle("/api/v1/wallets", auth.Require(write, h.CreateWallet)).Methods("POST")
+	router.Handle("/api/v1/wallets/{wallet_id}", auth.Require(read, h.GetWallet)).Methods("GET")
+	router.Handle("/api/v1/wallets/{wallet_id}/overdraft", auth.Require(admin, h.SetOverdraftLimit)).Methods("PUT")
+	router.Handle("/api/v1/wallets/{wallet_id}/status", auth.Require(admin, h.ChangeWalletStatus)).Methods("POST")
+	router.Handle("/api/v1/wallets/{wallet_id}/status-history", auth.Require(read, h.ListWalletStatusHistory)).Methods("GET")
+	router.Handle("/api/v1/wallets/{wallet_id}/transactions", auth.Require(read, h.ListTransactions)).Methods("GET")
+	router.Handle("/api/v1/wallets/{wallet_id}/balance", auth.Require(read, h.GetBalanceAsOf)).Methods("GET")
+	router.Handle("/api/v1/wallets/{wallet_id}/statement", auth.Require(read, h.GetStatement)).Methods("GET")
+	router.Handle("/api/v1/wallets/{wallet_id}/export", auth.Require(read, h.ExportStatement)).Methods("GET")
+	router.Handle("/api/v1/wallets/{wallet_id}/holds", auth.Require(write, h.AuthorizeHold)).Methods("POST")
+	router.Handle("/api/v1/holds/{hold_id}", auth.Require(read, h.GetHold)).Methods("GET")
+	router.Handle("/api/v1/holds/{hold_id}/capture", auth.Require(write, h.CaptureHold)).Methods("POST")
+	router.Handle("/api/v1/holds/{hold_id}/void", auth.Require(write, h.VoidHold)).Methods("POST")
+	router.Handle("/api/v1/transactions/{transaction_id}/reverse", auth.Require(admin, h.ReverseTransaction)).Methods("POST")
+	router.Handle("/api/v1/fx/quotes", auth.Require(write, h.CreateQuote)).Methods("POST")
+	router.Handle("/api/v1/fx/quotes/{quote_id}/convert", auth.Require(write, h.Convert)).Methods("POST")
+	router.Handle("/api/v1/exchange-rates", auth.Require(admin, h.AddExchangeRate)).Methods("POST")
+	router.Handle("/api/v1/exchange-rates/{base}/{quote}", auth.Require(read, h.GetExchangeRate)).Methods("GET")
+	router.Handle("/api/v1/wallets/{wallet_id}/limits", auth.Require(read, h.GetSpendingLimits)).Methods("GET")
+	router.Handle("/api/v1/wallets/{wallet_id}/limits", auth.Require(admin, h.SetSpendingLimits)).Methods("PUT")
+	router.Handle("/api/v1/wallets/{wallet_id}/limits", auth.Require(admin, h.DeleteSpendingLimits)).Methods("DELETE")
+	router.Handle("/api/v1/currencies/{code}/limits", auth.Require(read, h.GetSpendingLimits)).Methods("GET")
+	router.Handle("/api/v1/currencies/{code}/limits", auth.Require(admin, h.SetSpendingLimits)).Methods("PUT")
+	router.Handle("/api/v1/currencies/{code}/limits", auth.Require(admin, h.DeleteSpendingLimits)).Methods("DELETE")
+	router.Handle("/api/v1/wallets/{wallet_id}/ledger", auth.Require(read, h.GetLedgerBalance)).Methods("GET")
+	router.Handle("/api/v1/ledger/reconciliation", auth.Require(admin, h.ReconcileLedger)).Methods("GET")
 }
 
 func (h *WalletHandler) ProcessWalletOperation(w http.ResponseWriter, r *http.Request) {
@@ -46,28 +92,35 @@ func (h *WalletHandler) ProcessWalletOperation(w http.ResponseWriter, r *http.Re
         return
     }
 
+    if err := applyIdempotencyKey(r, operation); err != nil {
+        h.log.Warn("Invalid idempotency key", logger.ErrorField("error", err))
+        respondWithError(w, http.StatusBadRequest, err.Error())
+        return
+    }
+
     if validationErr := h.validateOperation(operation); validationErr != nil {
         h.log.Warn(validationErr.Message, validationErr.Fields...)
         respondWithError(w, http.StatusBadRequest, validationErr.Message)
         return
     }
+    operation.Actor = auth.Actor(r.Context())
 
-    amountDec, err := h.parseAmount(operation.Amount)
-    if err != nil {
-        h.log.Warn("Invalid amount", logger.StringField("amount", operation.Amount), logger.ErrorField("error", err))
-        respondWithError(w, http.StatusBadRequest, err.Error())
-        return
+    // Операция с чужим кошельком отклоняется до выполнения: пользователю доступны только его кошельки
+    if principal, ok := auth.FromContext(r.Context()); ok && principal.IsRestricted() {
+        if err := h.usecase.AuthorizeWallet(r.Context(), operation.WalletID); err != nil {
+            h.handleOperationError(w, operation, err)
+            return
+        }
     }
-    operation.DecimalAmount = amountDec
 
-    newBalance, err := h.executeWalletOperation(r.Context(), operation)
+    result, err := h.executeWalletOperation(r.Context(), operation)
     if err != nil {
         h.handleOperationError(w, operation, err)
         return
     }
 
-    h.logSuccess(operation, newBalance)
-    h.sendSuccessResponse(w, operation, newBalance)
+    h.logSuccess(operation, result)
+    h.sendSuccessResponse(w, operation, result)
 }
 
 type ValidationError struct {
@@ -86,6 +139,25 @@ func (h *WalletHandler) decodeRequest(w http.ResponseWriter, r *http.Request) (*
     return &operation, nil
 }
 
+// applyIdempotencyKey переносит ключ из заголовка Idempotency-Key в операцию.
+// Ключ может быть передан и в поле operationId, но тогда значения должны совпадать.
+func applyIdempotencyKey(r *http.Request, operation *models.WalletOperation) error {
+    headerKey := strings.TrimSpace(r.Header.Get(idempotencyKeyHeader))
+    operation.OperationID = strings.TrimSpace(operation.OperationID)
+
+    switch {
+    case headerKey != "" && operation.OperationID != "" && headerKey != operation.OperationID:
+        return fmt.Errorf("idempotency key header does not match operationId")
+    case headerKey != "":
+        operation.OperationID = headerKey
+    }
+
+    if len(operation.OperationID) > maxIdempotencyKeyLen {
+        return fmt.Errorf("idempotency key must not exceed %d characters", maxIdempotencyKeyLen)
+    }
+    return nil
+}
+
 // validateOperation выполняет базовую валидацию полей операции
 func (h *WalletHandler) validateOperation(operation *models.WalletOperation) *ValidationError {
     if operation.WalletID == uuid.Nil {
@@ -102,6 +174,20 @@ func (h *WalletHandler) validateOperation(operation *models.WalletOperation) *Va
     switch operation.OperationType {
     case models.OperationDeposit, models.OperationWithdraw:
         return nil
+    case models.OperationTransfer:
+        if operation.TargetWalletID == uuid.Nil {
+            return &ValidationError{
+                Message: "Target wallet ID is required for transfer",
+                Fields:  []logger.Field{logger.StringField("target_wallet_id", "")},
+            }
+        }
+        if operation.TargetWalletID == operation.WalletID {
+            return &ValidationError{
+                Message: "Source and target wallets must differ",
+                Fields:  []logger.Field{logger.StringField("wallet_id", operation.WalletID.String())},
+            }
+        }
+        return nil
     default:
         return &ValidationError{
             Message: "Invalid operation type",
@@ -112,27 +198,10 @@ func (h *WalletHandler) validateOperation(operation *models.WalletOperation) *Va
     }
 }
 
-// parseAmount обрабатывает и валидирует сумму операции
-func (h *WalletHandler) parseAmount(amountStr string) (decimal.Decimal, error) {
-    cleaned := strings.ReplaceAll(strings.ReplaceAll(amountStr, " ", ""), ",", ".")
-    
-    if !amountRegexp.MatchString(cleaned) {
-        return decimal.Zero, fmt.Errorf("invalid amount format: %s", cleaned)
-    }
-
-    amount, err := decimal.NewFromString(cleaned)
-    if err != nil {
-        return decimal.Zero, fmt.Errorf("could not parse amount: %v", err)
-    }
-
-    if amount.LessThanOrEqual(decimal.Zero) {
-        return decimal.Zero, fmt.Errorf("amount must be positive")
+func (h *WalletHandler) executeWalletOperation(ctx context.Context, op *models.WalletOperation) (*models.OperationResult, error) {
+    if op.OperationType == models.OperationTransfer {
+        return h.usecase.Transfer(ctx, *op)
     }
-
-    return amount, nil
-}
-
-func (h *WalletHandler) executeWalletOperation(ctx context.Context, op *models.WalletOperation) (decimal.Decimal, error) {
     return h.usecase.OperateWallet(ctx, *op)
 }
 
@@ -142,12 +211,100 @@ func (h *WalletHandler) handleOperationError(w http.ResponseWriter, op *models.W
         h.log.Warn("Wallet not found", logger.StringField("wallet_id", op.WalletID.String()))
         respondWithError(w, http.StatusNotFound, "Wallet not found")
     case errors.Is(err, usecase.ErrInvalidAmount):
-        h.log.Warn("Invalid amount", logger.StringField("amount", op.DecimalAmount.String()))
+        h.log.Warn("Invalid amount", logger.StringField("amount", op.Amount), logger.ErrorField("error", err))
         respondWithError(w, http.StatusBadRequest, "Invalid amount")
+    case errors.Is(err, usecase.ErrAmountPrecision):
+        h.log.Warn("Amount precision exceeds currency scale", logger.StringField("amount", op.Amount))
+        respondWithError(w, http.StatusBadRequest, err.Error())
+    case errors.Is(err, usecase.ErrIdempotencyConflict):
+        h.log.Warn("Idempotency key reused with different payload",
+            logger.StringField("wallet_id", op.WalletID.String()),
+            logger.StringField("operation_id", op.OperationID),
+        )
+        respondWithError(w, http.StatusConflict, "Idempotency key already used with a different request")
+    case errors.Is(err, usecase.ErrHoldNotFound):
+        respondWithError(w, http.StatusNotFound, "Hold not found")
+    case errors.Is(err, usecase.ErrHoldNotActive):
+        respondWithError(w, http.StatusConflict, "Hold is not active")
+    case errors.Is(err, usecase.ErrHoldExpired):
+        respondWithError(w, http.StatusConflict, "Hold expired")
+    case errors.Is(err, usecase.ErrCaptureExceedsHold):
+        respondWithError(w, http.StatusUnprocessableEntity, "Capture amount exceeds held amount")
+    case errors.Is(err, usecase.ErrInvalidHoldExpiry):
+        respondWithError(w, http.StatusBadRequest, "Invalid hold expiry")
+    case errors.Is(err, usecase.ErrTransactionNotFound):
+        respondWithError(w, http.StatusNotFound, "Transaction not found")
+    case errors.Is(err, usecase.ErrNotReversible):
+        respondWithError(w, http.StatusUnprocessableEntity,
+            "Transaction cannot be reversed: only completed DEPOSIT, WITHDRAW and CAPTURE operations are reversible")
+    case errors.Is(err, usecase.ErrAlreadyReversed):
+        respondWithError(w, http.StatusConflict, "Transaction already reversed")
+    case errors.Is(err, usecase.ErrReversalExceedsOriginal):
+        respondWithError(w, http.StatusUnprocessableEntity, "Reversal amount exceeds remaining original amount")
+    case errors.Is(err, usecase.ErrSameCurrency):
+        respondWithError(w, http.StatusBadRequest, "Currencies must differ; use TRANSFER for same-currency wallets")
+    case errors.Is(err, usecase.ErrInvalidCurrency):
+        respondWithError(w, http.StatusBadRequest, "Invalid currency code")
+    case errors.Is(err, usecase.ErrCurrencyNotFound):
+        respondWithError(w, http.StatusUnprocessableEntity, "Unsupported currency")
+    case errors.Is(err, usecase.ErrInvalidExchangeRate):
+        respondWithError(w, http.StatusBadRequest, err.Error())
+    case errors.Is(err, usecase.ErrRateNotFound):
+        h.log.Warn("Exchange rate not available", logger.ErrorField("error", err))
+        respondWithError(w, http.StatusUnprocessableEntity, "Exchange rate not available")
+    case errors.Is(err, usecase.ErrRatesReadOnly):
+        respondWithError(w, http.StatusNotImplemented, "Exchange rates are managed by an external provider")
+    case errors.Is(err, usecase.ErrQuoteNotFound):
+        respondWithError(w, http.StatusNotFound, "Quote not found")
+    case errors.Is(err, usecase.ErrQuoteExpired):
+        respondWithError(w, http.StatusConflict, "Quote expired")
+    case errors.Is(err, usecase.ErrQuoteUsed):
+        respondWithError(w, http.StatusConflict, "Quote already used")
+    case errors.Is(err, usecase.ErrForbidden):
+        respondWithError(w, http.StatusForbidden, "Access to wallet denied")
+    case errors.Is(err, usecase.ErrWalletNotActive):
+        h.log.Warn("Operation not allowed for wallet status",
+            logger.StringField("wallet_id", op.WalletID.String()),
+            logger.ErrorField("error", err),
+        )
+        respondWithError(w, http.StatusForbidden, "Operation not allowed for wallet status")
+    case errors.Is(err, usecase.ErrInvalidWalletStatus):
+        respondWithError(w, http.StatusBadRequest, err.Error())
+    case errors.Is(err, usecase.ErrStatusTransition):
+        respondWithError(w, http.StatusConflict, "Wallet status transition not allowed")
+    case errors.Is(err, usecase.ErrWalletNotEmpty):
+        respondWithError(w, http.StatusConflict, "Wallet has funds or holds and cannot be closed")
+    case errors.Is(err, usecase.ErrLimitExceeded):
+        h.log.Warn("Spending limit exceeded",
+            logger.StringField("wallet_id", op.WalletID.String()),
+            logger.StringField("amount", op.Amount),
+            logger.ErrorField("error", err),
+        )
+        respondWithError(w, http.StatusUnprocessableEntity, "Spending limit exceeded")
+    case errors.Is(err, usecase.ErrInvalidLimit):
+        respondWithError(w, http.StatusBadRequest, err.Error())
+    case errors.Is(err, usecase.ErrInvalidPeriod):
+        respondWithError(w, http.StatusBadRequest, err.Error())
+    case errors.Is(err, usecase.ErrStatementTooLarge):
+        respondWithError(w, http.StatusUnprocessableEntity, err.Error())
+    case errors.Is(err, usecase.ErrRetriesExhausted):
+        h.log.Error("Operation retries exhausted",
+            logger.StringField("wallet_id", op.WalletID.String()),
+            logger.ErrorField("error", err),
+        )
+        respondWithError(w, http.StatusServiceUnavailable, "Wallet is busy, please retry later")
+    case errors.Is(err, usecase.ErrSameWallet):
+        respondWithError(w, http.StatusBadRequest, "Source and target wallets must differ")
+    case errors.Is(err, usecase.ErrCurrencyMismatch):
+        h.log.Warn("Currency mismatch",
+            logger.StringField("wallet_id", op.WalletID.String()),
+            logger.StringField("target_wallet_id", op.TargetWalletID.String()),
+        )
+        respondWithError(w, http.StatusUnprocessableEntity, "Wallet currencies do not match")
     case errors.Is(err, usecase.ErrInsufficientFunds):
         h.log.Warn("Insufficient funds", 
             logger.StringField("wallet_id", op.WalletID.String()),
-            logger.StringField("amount", op.DecimalAmount.String()),
+            logger.StringField("amount", op.Amount),
         )
         respondWithJSON(w, http.StatusBadRequest, OperationResponse{
             Error:    "insufficient funds",
@@ -157,26 +314,136 @@ func (h *WalletHandler) handleOperationError(w http.ResponseWriter, op *models.W
     default:
         h.log.Error("Failed to process operation", 
             logger.StringField("wallet_id", op.WalletID.String()),
-            logger.StringField("amount", op.DecimalAmount.String()),
+            logger.StringField("amount", op.Amount),
             logger.ErrorField("error", err),
         )
         respondWithError(w, http.StatusInternalServerError, "Failed to process operation")
     }
 }
 
-func (h *WalletHandler) logSuccess(op *models.WalletOperation, newBalance decimal.Decimal) {
+// CreateWallet открывает новый кошелёк в указанной валюте
+func (h *WalletHandler) CreateWallet(w http.ResponseWriter, r *http.Request) {
+    var req models.CreateWalletRequest
+    r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+    defer r.Body.Close()
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        h.log.Warn("Failed to decode request body", logger.ErrorField("error", err))
+        respondWithError(w, http.StatusBadRequest, "invalid request payload")
+        return
+    }
+
+    wallet, err := h.usecase.CreateWallet(r.Context(), req)
+    if err != nil {
+        switch {
+        case errors.Is(err, usecase.ErrInvalidCurrency):
+            respondWithError(w, http.StatusBadRequest, "Invalid currency code")
+        case errors.Is(err, usecase.ErrCurrencyNotFound):
+            h.log.Warn("Unsupported currency", logger.StringField("currency", req.CurrencyCode))
+            respondWithError(w, http.StatusUnprocessableEntity, "Unsupported currency")
+        case errors.Is(err, usecase.ErrCurrencyInactive):
+            h.log.Warn("Inactive currency", logger.StringField("currency", req.CurrencyCode))
+            respondWithError(w, http.StatusUnprocessableEntity, "Currency is not available for new wallets")
+        case errors.Is(err, usecase.ErrWalletAlreadyExists):
+            respondWithError(w, http.StatusConflict, "Wallet already exists")
+        case errors.Is(err, usecase.ErrForbidden):
+            respondWithError(w, http.StatusForbidden, err.Error())
+        default:
+            h.log.Error("Failed to create wallet", logger.ErrorField("error", err))
+            respondWithError(w, http.StatusInternalServerError, "Failed to create wallet")
+        }
+        return
+    }
+
+    respondWithJSON(w, http.StatusCreated, wallet)
+}
+
+// GetWallet возвращает баланс кошелька; ETag строится по времени последнего изменения
+func (h *WalletHandler) GetWallet(w http.ResponseWriter, r *http.Request) {
+    walletID, err := uuid.Parse(mux.Vars(r)["wallet_id"])
+    if err != nil {
+        respondWithError(w, http.StatusBadRequest, "Invalid wallet ID")
+        return
+    }
+
+    details, err := h.usecase.GetWallet(r.Context(), walletID)
+    if err != nil {
+        h.handleOperationError(w, &models.WalletOperation{WalletID: walletID}, err)
+        return
+    }
+
+    etag := walletETag(details.Wallet)
+    w.Header().Set("ETag", etag)
+    w.Header().Set("Cache-Control", "no-cache")
+    if match := r.Header.Get("If-None-Match"); match != "" && match == etag {
+        w.WriteHeader(http.StatusNotModified)
+        return
+    }
+
+    respondWithJSON(w, http.StatusOK, newWalletResponse(details))
+}
+
+// SetOverdraftLimit задаёт кредитную линию кошелька
+func (h *WalletHandler) SetOverdraftLimit(w http.ResponseWriter, r *http.Request) {
+    walletID, err := uuid.Parse(mux.Vars(r)["wallet_id"])
+    if err != nil {
+        respondWithError(w, http.StatusBadRequest, "Invalid wallet ID")
+        return
+    }
+
+    var req struct {
+        Limit string `json:"limit"`
+    }
+    r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+    defer r.Body.Close()
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        h.log.Warn("Failed to decode request body", logger.ErrorField("error", err))
+        respondWithError(w, http.StatusBadRequest, "invalid request payload")
+        return
+    }
+
+    details, err := h.usecase.SetOverdraftLimit(r.Context(), walletID, req.Limit)
+    if err != nil {
+        h.handleOperationError(w, &models.WalletOperation{WalletID: walletID, Amount: req.Limit}, err)
+        return
+    }
+
+    respondWithJSON(w, http.StatusOK, newWalletResponse(details))
+}
+
+func newWalletResponse(details *models.WalletDetails) WalletResponse {
+    scale := details.Currency.Scale()
+    return WalletResponse{
+        WalletID:  details.Wallet.ID,
+        Balance:   details.Balance.StringFixed(scale),
+        HeldBalance:      details.HeldBalance.StringFixed(scale),
+        AvailableBalance: details.AvailableBalance.StringFixed(scale),
+        OverdraftLimit:   details.OverdraftLimit.StringFixed(scale),
+        AvailableCredit:  details.AvailableCredit.StringFixed(scale),
+        Currency:  details.Wallet.CurrencyCode,
+        Status:    details.Wallet.Status,
+        OwnerRef:  details.Wallet.OwnerRef,
+        CreatedAt: details.Wallet.CreatedAt,
+        UpdatedAt: details.Wallet.UpdatedAt,
+    }
+}
+
+func walletETag(wallet *models.Wallet) string {
+    return `"` + strconv.FormatInt(wallet.UpdatedAt.UnixNano(), 36) + `"`
+}
+
+func (h *WalletHandler) logSuccess(op *models.WalletOperation, result *models.OperationResult) {
     h.log.Info("Wallet operation successful",
         logger.StringField("wallet_id", op.WalletID.String()),
         logger.StringField("operation_type", string(op.OperationType)),
-        logger.StringField("amount", op.DecimalAmount.String()),
-        logger.StringField("new_balance", newBalance.StringFixedBank(2)),
+        logger.StringField("amount", op.Amount),
+        logger.StringField("new_balance", result.Balance.StringFixed(result.Currency.Scale())),
     )
 }
 
-func (h *WalletHandler) sendSuccessResponse(w http.ResponseWriter, op *models.WalletOperation, balance decimal.Decimal) {
+func (h *WalletHandler) sendSuccessResponse(w http.ResponseWriter, op *models.WalletOperation, result *models.OperationResult) {
     respondWithJSON(w, http.StatusOK, OperationResponse{
         Error:    "",
-        Balance:  balance.StringFixedBank(2),
+        Balance:  result.Balance.StringFixed(result.Currency.Scale()),
         WalletID: op.WalletID,
     })
 }
@@ -185,8 +452,8 @@ func respondWithError(w http.ResponseWriter, code int, message string) {
 	respondWithJSON(w, code, OperationResponse{Error: message})
 }
 
-func respondWithJSON(w http.ResponseWriter, code int, os OperationResponse) {
-	response, err := json.Marshal(os)
+func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response, err := json.Marshal(payload)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(`{"error":"Internal Server Error"}`)) // Fallback response
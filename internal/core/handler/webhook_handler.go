@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Nzyazin/itk/internal/core/auth"
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/usecase"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// WebhookHandler обслуживает API подписок на события кошельков
+type WebhookHandler struct {
+	usecase usecase.WebhookUsecase
+	log     logger.Logger
+}
+
+func NewWebhookHandler(usecase usecase.WebhookUsecase, log logger.Logger) *WebhookHandler {
+	return &WebhookHandler{usecase: usecase, log: log}
+}
+
+func (h *WebhookHandler) RegisterRoutes(router *mux.Router) {
+	admin := models.ScopeAdmin
+	router.Handle("/api/v1/webhooks", auth.Require(admin, h.ListSubscriptions)).Methods("GET")
+	router.Handle("/api/v1/webhooks", auth.Require(admin, h.CreateSubscription)).Methods("POST")
+	router.Handle("/api/v1/webhooks/{subscription_id}", auth.Require(admin, h.GetSubscription)).Methods("GET")
+	router.Handle("/api/v1/webhooks/{subscription_id}", auth.Require(admin, h.DeleteSubscription)).Methods("DELETE")
+	router.Handle("/api/v1/webhooks/{subscription_id}/deliveries", auth.Require(admin, h.ListDeliveries)).Methods("GET")
+	router.Handle("/api/v1/webhook-deliveries/{delivery_id}/redeliver", auth.Require(admin, h.Redeliver)).Methods("POST")
+}
+
+// CreatedSubscriptionResponse возвращает секрет подписки; позже его получить нельзя
+type CreatedSubscriptionResponse struct {
+	*models.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+func (h *WebhookHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	var req models.WebhookSubscriptionRequest
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Warn("Failed to decode request body", logger.ErrorField("error", err))
+		respondWithError(w, http.StatusBadRequest, "invalid request payload")
+		return
+	}
+
+	subscription, err := h.usecase.CreateSubscription(r.Context(), req)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, CreatedSubscriptionResponse{
+		WebhookSubscription: subscription,
+		Secret:              subscription.Secret,
+	})
+}
+
+// ListSubscriptions принимает необязательный параметр wallet_id
+func (h *WebhookHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	var walletID *uuid.UUID
+	if value := r.URL.Query().Get("wallet_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid wallet ID")
+			return
+		}
+		walletID = &id
+	}
+
+	subscriptions, err := h.usecase.ListSubscriptions(r.Context(), walletID)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, subscriptions)
+}
+
+func (h *WebhookHandler) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(w, r, "subscription_id", "Invalid subscription ID")
+	if !ok {
+		return
+	}
+
+	subscription, err := h.usecase.GetSubscription(r.Context(), id)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, subscription)
+}
+
+func (h *WebhookHandler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(w, r, "subscription_id", "Invalid subscription ID")
+	if !ok {
+		return
+	}
+
+	if err := h.usecase.DeleteSubscription(r.Context(), id); err != nil {
+		h.handleError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliveries принимает необязательный параметр status: PENDING, DELIVERED или DEAD
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(w, r, "subscription_id", "Invalid subscription ID")
+	if !ok {
+		return
+	}
+
+	status := models.DeliveryStatus(r.URL.Query().Get("status"))
+	deliveries, err := h.usecase.ListDeliveries(r.Context(), id, status)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, deliveries)
+}
+
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUUID(w, r, "delivery_id", "Invalid delivery ID")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.usecase.Redeliver(r.Context(), id)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	respondWithJSON(w, http.StatusAccepted, delivery)
+}
+
+func pathUUID(w http.ResponseWriter, r *http.Request, name, message string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(mux.Vars(r)[name])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, message)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *WebhookHandler) handleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidWebhook):
+		respondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, usecase.ErrWalletNotFound):
+		respondWithError(w, http.StatusNotFound, "Wallet not found")
+	case errors.Is(err, usecase.ErrSubscriptionNotFound):
+		respondWithError(w, http.StatusNotFound, "Webhook subscription not found")
+	case errors.Is(err, usecase.ErrDeliveryNotFound):
+		respondWithError(w, http.StatusNotFound, "Webhook delivery not found")
+	case errors.Is(err, usecase.ErrDeliveryPending):
+		respondWithError(w, http.StatusConflict, "Webhook delivery is still pending")
+	default:
+		h.log.Error("Failed to process webhook request", logger.ErrorField("error", err))
+		respondWithError(w, http.StatusInternalServerError, "Failed to process webhook request")
+	}
+}
@@ -20,6 +20,9 @@ func WithErrorHandler(log logger.Logger) func(http.Handler) http.Handler {
 func (eh *ErrorHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
     defer func() {
         if err := recover(); err != nil {
+            if err == http.ErrAbortHandler {
+                panic(err)
+            }
             eh.log.Error("request processing failed",
                 logger.StringField("method", r.Method),
                 logger.StringField("path", r.URL.Path),
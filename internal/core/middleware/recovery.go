@@ -12,6 +12,10 @@ func Recovery(log logger.Logger) func(http.Handler) http.Handler {
         return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
             defer func() {
                 if rec := recover(); rec != nil {
+                    // Обработчик прерывает уже начатый ответ; соединение закрывает http.Server
+                    if rec == http.ErrAbortHandler {
+                        panic(rec)
+                    }
                     log.Error("panic recovered",
                         logger.StringField("path", r.URL.Path),
                         logger.AnyField("error", rec),
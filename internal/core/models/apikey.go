@@ -0,0 +1,98 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Scope - право доступа клиента API
+type Scope string
+
+const (
+	// ScopeWalletRead - чтение кошельков, операций и справочников
+	ScopeWalletRead Scope = "wallet:read"
+	// ScopeWalletWrite - создание кошельков и операции с ними; включает wallet:read
+	ScopeWalletWrite Scope = "wallet:write"
+	// ScopeAdmin - управление кошельками, справочниками, ключами и служебные эндпоинты; включает все права
+	ScopeAdmin Scope = "admin"
+)
+
+// IsValid проверяет, что право известно
+func (s Scope) IsValid() bool {
+	switch s {
+	case ScopeWalletRead, ScopeWalletWrite, ScopeAdmin:
+		return true
+	}
+	return false
+}
+
+// APIKey - выпущенный API-ключ. Сам ключ не хранится, только его хэш.
+type APIKey struct {
+	ID        uuid.UUID   `json:"id" db:"id"`
+	Name      string      `json:"name" db:"name"`
+	Prefix    string      `json:"prefix" db:"prefix"` // начало ключа, чтобы его можно было узнать
+	Hash      string      `json:"-" db:"key_hash"`
+	Scopes    []Scope     `json:"scopes" db:"-"`
+	WalletIDs []uuid.UUID `json:"wallet_ids" db:"-"` // пустой список - все кошельки
+	CreatedAt time.Time   `json:"created_at" db:"created_at"`
+	ExpiresAt *time.Time  `json:"expires_at,omitempty" db:"expires_at"`
+	RevokedAt *time.Time  `json:"revoked_at,omitempty" db:"revoked_at"`
+	RotatedTo *uuid.UUID  `json:"rotated_to,omitempty" db:"rotated_to"`
+}
+
+// IsActive сообщает, можно ли аутентифицироваться ключом в момент now
+func (k *APIKey) IsActive(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// APIKeyRequest представляет запрос на выпуск ключа
+type APIKeyRequest struct {
+	Name      string      `json:"name"`
+	Scopes    []Scope     `json:"scopes"`
+	WalletIDs []uuid.UUID `json:"wallet_ids,omitempty"`
+	ExpiresAt *time.Time  `json:"expires_at,omitempty"`
+}
+
+// RotateAPIKeyRequest задаёт, сколько секунд старый ключ продолжает действовать после ротации
+type RotateAPIKeyRequest struct {
+	GracePeriodSeconds int64 `json:"grace_period_seconds"`
+}
+
+// IssuedAPIKey возвращает выпущенный ключ; значение Key больше получить нельзя
+type IssuedAPIKey struct {
+	*APIKey
+	Key string `json:"key"`
+}
+
+// Principal - аутентифицированный клиент API: сервис с API-ключом или пользователь с JWT
+type Principal struct {
+	Actor     string      // идентификатор клиента для истории операций, например "api_key:<id>"
+	Subject   string      // пользователь; ему доступны только кошельки с таким owner_ref
+	Scopes    []Scope
+	WalletIDs []uuid.UUID // пустой список - все кошельки
+}
+
+// HasScope проверяет право клиента; admin включает все права, wallet:write - чтение
+func (p *Principal) HasScope(scope Scope) bool {
+	for _, s := range p.Scopes {
+		if s == scope || s == ScopeAdmin || (s == ScopeWalletWrite && scope == ScopeWalletRead) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccessWallet проверяет, что клиенту доступен кошелёк
+func (p *Principal) CanAccessWallet(wallet *Wallet) bool {
+	if p.Subject != "" && (wallet.OwnerRef == nil || *wallet.OwnerRef != p.Subject) {
+		return false
+	}
+	return len(p.WalletIDs) == 0 || slices.Contains(p.WalletIDs, wallet.ID)
+}
+
+// IsRestricted сообщает, ограничен ли клиент частью кошельков
+func (p *Principal) IsRestricted() bool {
+	return p.Subject != "" || len(p.WalletIDs) > 0
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// BatchMode определяет поведение пакета при ошибке одной из операций
+type BatchMode string
+
+const (
+	// BatchModeAtomic - все операции выполняются в одной транзакции: ошибка любой откатывает весь пакет
+	BatchModeAtomic BatchMode = "ATOMIC"
+	// BatchModeBestEffort - ошибка операции откатывает только её, остальные выполняются
+	BatchModeBestEffort BatchMode = "BEST_EFFORT"
+)
+
+// Статусы операций пакета
+const (
+	BatchItemApplied    = "APPLIED"
+	BatchItemFailed     = "FAILED"
+	BatchItemRolledBack = "ROLLED_BACK" // операция атомарного пакета откачена из-за ошибки другой операции
+)
+
+// BatchRequest представляет пакет операций, выполняемых по порядку
+type BatchRequest struct {
+	Mode       BatchMode         `json:"mode"`
+	Operations []WalletOperation `json:"operations"`
+}
+
+// BatchItemResult представляет результат одной операции пакета
+type BatchItemResult struct {
+	Index    int
+	WalletID uuid.UUID
+	Status   string
+	Currency *Currency
+	Balance  decimal.Decimal
+	Err      error
+}
+
+// BatchResult представляет результаты операций пакета в порядке запроса
+type BatchResult struct {
+	Mode      BatchMode
+	Committed bool // выполнена хотя бы одна операция
+	Items     []BatchItemResult
+}
@@ -1,9 +1,31 @@
 package models
 
+import "time"
+
 type Currency struct {
 	Code            string `json:"code" db:"code"`                         // ISO 4217, например "RUB"
 	Name            string `json:"name" db:"name"`                         // Полное название валюты
 	MinorUnitName   string `json:"minor_unit_name" db:"minor_unit_name"`   // "копейка", "цент", "филс"
-	MinorUnits      int64    `json:"minor_units" db:"minor_units"`           // Сколько минимальных единиц в основной валюте: 100 или 1000
+	MinorUnits      int64    `json:"minor_units" db:"minor_units"`           // Количество знаков после запятой: 2 для RUB, 0 для JPY, 3 для KWD
 	IsFractional    bool   `json:"is_fractional" db:"is_fractional"`       // Есть ли дробная часть у валюты (например, у японской иены — нет)
-}
\ No newline at end of file
+	IsActive        bool      `json:"is_active" db:"is_active"`          // Неактивная валюта недоступна для новых кошельков
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Scale возвращает количество знаков после запятой, с которым отображаются суммы в валюте
+func (c *Currency) Scale() int32 {
+	return int32(c.MinorUnits)
+}
+
+// MaxMinorUnits ограничивает точность валюты: суммы хранятся в BIGINT
+const MaxMinorUnits = 8
+
+// CurrencyRequest представляет запрос на создание или изменение валюты.
+// При изменении пустые поля сохраняют текущие значения.
+type CurrencyRequest struct {
+	Code          string  `json:"code"`
+	Name          string  `json:"name"`
+	MinorUnits    *int64  `json:"minor_units,omitempty"`
+	MinorUnitName *string `json:"minor_unit_name,omitempty"`
+}
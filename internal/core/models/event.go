@@ -0,0 +1,58 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventType определяет тип доменного события кошелька
+type EventType string
+
+const (
+	// EventWalletCredited - учётный баланс кошелька увеличился
+	EventWalletCredited EventType = "WalletCredited"
+	// EventWalletDebited - учётный баланс кошелька уменьшился
+	EventWalletDebited EventType = "WalletDebited"
+	// EventWalletFundsHeld - средства кошелька зарезервированы
+	EventWalletFundsHeld EventType = "WalletFundsHeld"
+	// EventWalletFundsReleased - резерв отменён или истёк, средства вернулись в доступный баланс
+	EventWalletFundsReleased EventType = "WalletFundsReleased"
+	// EventWalletStatusChanged - изменился статус кошелька
+	EventWalletStatusChanged EventType = "WalletStatusChanged"
+)
+
+// IsValid проверяет, что тип события известен
+func (t EventType) IsValid() bool {
+	switch t {
+	case EventWalletCredited, EventWalletDebited, EventWalletFundsHeld, EventWalletFundsReleased,
+		EventWalletStatusChanged:
+		return true
+	}
+	return false
+}
+
+// TransactionEventType возвращает тип события для операции в истории кошелька
+func TransactionEventType(t OperationType) EventType {
+	switch {
+	case t.Sign() > 0:
+		return EventWalletCredited
+	case t.Sign() < 0:
+		return EventWalletDebited
+	}
+	return EventWalletFundsHeld
+}
+
+// OutboxEvent - событие, записанное в одной транзакции с изменением кошелька.
+// Sequence возрастает в порядке изменений каждого кошелька; ID позволяет получателю
+// отбросить повторную доставку.
+type OutboxEvent struct {
+	Sequence  int64           `json:"sequence" db:"id"`
+	ID        uuid.UUID       `json:"id" db:"event_id"`
+	WalletID  uuid.UUID       `json:"wallet_id" db:"wallet_id"`
+	Type      EventType       `json:"type" db:"event_type"`
+	Payload   json.RawMessage `json:"payload" db:"payload"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+	Attempts  int             `json:"-" db:"attempts"`
+}
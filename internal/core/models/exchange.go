@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ExchangeRate задаёт курс обмена: сколько единиц QuoteCurrency стоит одна единица BaseCurrency.
+// Курс действует с ValidFrom включительно до ValidTo (не включительно); пустой ValidTo - бессрочно.
+type ExchangeRate struct {
+	BaseCurrency  string          `json:"base" db:"base_currency"`
+	QuoteCurrency string          `json:"quote" db:"quote_currency"`
+	Rate          decimal.Decimal `json:"rate" db:"rate"`
+	ValidFrom     time.Time       `json:"valid_from" db:"valid_from"`
+	ValidTo       *time.Time      `json:"valid_to,omitempty" db:"valid_to"`
+}
+
+// ExchangeRateRequest представляет запрос на добавление курса
+type ExchangeRateRequest struct {
+	BaseCurrency  string     `json:"base"`
+	QuoteCurrency string     `json:"quote"`
+	Rate          string     `json:"rate"`
+	ValidFrom     *time.Time `json:"validFrom,omitempty"`
+	ValidTo       *time.Time `json:"validTo,omitempty"`
+}
+
+// FXQuote фиксирует курс и суммы обмена на время действия котировки.
+// Котировка используется не более одного раза.
+type FXQuote struct {
+	ID             uuid.UUID       `db:"id"`
+	WalletID       uuid.UUID       `db:"wallet_id"`
+	TargetWalletID uuid.UUID       `db:"target_wallet_id"`
+	SourceCurrency string          `db:"source_currency"`
+	TargetCurrency string          `db:"target_currency"`
+	SourceAmount   int64           `db:"source_amount"`
+	TargetAmount   int64           `db:"target_amount"`
+	Rate           decimal.Decimal `db:"rate"`
+	ExpiresAt      time.Time       `db:"expires_at"`
+	UsedAt         *time.Time      `db:"used_at"`
+	CreatedAt      time.Time       `db:"created_at"`
+}
+
+// QuoteRequest представляет запрос котировки на обмен суммы Amount в валюте кошелька-источника
+type QuoteRequest struct {
+	WalletID       uuid.UUID `json:"walletId"`
+	TargetWalletID uuid.UUID `json:"targetWalletId"`
+	Amount         string    `json:"amount"`
+}
+
+// QuoteDetails представляет котировку с суммами в основных единицах валют
+type QuoteDetails struct {
+	Quote          *FXQuote
+	SourceCurrency *Currency
+	TargetCurrency *Currency
+	SourceAmount   decimal.Decimal
+	TargetAmount   decimal.Decimal
+}
+
+// ConversionResult представляет результат обмена по котировке
+type ConversionResult struct {
+	Quote   *QuoteDetails
+	Debit   *Transaction
+	Credit  *Transaction
+	Balance decimal.Decimal // баланс кошелька-источника после обмена
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// HoldStatus определяет состояние резервирования средств
+type HoldStatus string
+
+const (
+	// HoldActive - средства зарезервированы и уменьшают доступный баланс
+	HoldActive HoldStatus = "ACTIVE"
+	// HoldCaptured - резерв списан полностью или частично, остаток освобождён
+	HoldCaptured HoldStatus = "CAPTURED"
+	// HoldVoided - резерв отменён без списания
+	HoldVoided HoldStatus = "VOIDED"
+	// HoldExpired - резерв истёк и освобождён автоматически
+	HoldExpired HoldStatus = "EXPIRED"
+)
+
+// Hold представляет резервирование средств на кошельке (авторизацию)
+type Hold struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	WalletID       uuid.UUID  `json:"wallet_id" db:"wallet_id"`
+	Amount         int64      `json:"amount" db:"amount"`
+	CapturedAmount int64      `json:"captured_amount" db:"captured_amount"`
+	Status         HoldStatus `json:"status" db:"status"`
+	ExpiresAt      time.Time  `json:"expires_at" db:"expires_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// HoldRequest представляет запрос на резервирование средств
+type HoldRequest struct {
+	WalletID  uuid.UUID `json:"-"`
+	Amount    string    `json:"amount"`
+	ExpiresIn int64     `json:"expiresIn,omitempty"` // срок действия резерва в секундах
+}
+
+// CaptureRequest представляет запрос на списание зарезервированных средств.
+// Пустая сумма означает списание резерва целиком.
+type CaptureRequest struct {
+	Amount string `json:"amount,omitempty"`
+}
+
+// HoldDetails представляет резерв с суммами в основных единицах валюты
+type HoldDetails struct {
+	Hold           *Hold
+	Currency       *Currency
+	Amount         decimal.Decimal
+	CapturedAmount decimal.Decimal
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LedgerAccountType определяет вид счёта в журнале двойной записи
+type LedgerAccountType string
+
+const (
+	// LedgerAccountWallet - счёт кошелька, его идентификатор совпадает с идентификатором кошелька
+	LedgerAccountWallet LedgerAccountType = "WALLET"
+	// LedgerAccountSystem - служебный счёт сервиса в конкретной валюте
+	LedgerAccountSystem LedgerAccountType = "SYSTEM"
+)
+
+// Служебные счета. Код счёта в журнале: "system:<вид>:<валюта>", например "system:clearing:RUB"
+const (
+	// SystemAccountClearing - внешние поступления и выплаты (пополнения и снятия)
+	SystemAccountClearing = "clearing"
+	// SystemAccountFees - комиссии сервиса
+	SystemAccountFees = "fees"
+	// SystemAccountOpening - входящие остатки, перенесённые при переходе на журнал
+	SystemAccountOpening = "opening"
+	// SystemAccountFX - позиция сервиса в валюте по операциям обмена
+	SystemAccountFX = "fx"
+)
+
+// SystemAccountCode возвращает код служебного счёта для валюты
+func SystemAccountCode(kind, currencyCode string) string {
+	return "system:" + kind + ":" + currencyCode
+}
+
+// LedgerAccount представляет счёт в журнале двойной записи
+type LedgerAccount struct {
+	ID           uuid.UUID         `json:"id" db:"id"`
+	Code         string            `json:"code" db:"code"`
+	AccountType  LedgerAccountType `json:"account_type" db:"account_type"`
+	WalletID     *uuid.UUID        `json:"wallet_id,omitempty" db:"wallet_id"`
+	CurrencyCode string            `json:"currency" db:"currency_code"`
+	CreatedAt    time.Time         `json:"created_at" db:"created_at"`
+}
+
+// Posting представляет проводку по счёту. Положительная сумма кредитует счёт
+// (увеличивает его баланс), отрицательная - дебетует. Сумма проводок одной записи
+// журнала в каждой валюте равна нулю.
+type Posting struct {
+	AccountID uuid.UUID `json:"account_id" db:"account_id"`
+	Amount    int64     `json:"amount" db:"amount"`
+}
+
+// WalletLedgerBalance сопоставляет баланс кошелька с балансом, рассчитанным по проводкам
+type WalletLedgerBalance struct {
+	WalletID      uuid.UUID `json:"wallet_id" db:"wallet_id"`
+	CurrencyCode  string    `json:"currency" db:"currency_code"`
+	Balance       int64     `json:"balance" db:"balance"`
+	LedgerBalance int64     `json:"ledger_balance" db:"ledger_balance"`
+}
+
+// IsConsistent сообщает, совпадает ли сохранённый баланс с рассчитанным по проводкам
+func (b WalletLedgerBalance) IsConsistent() bool {
+	return b.Balance == b.LedgerBalance
+}
+
+// TrialBalance представляет сумму всех проводок в валюте; для корректного журнала она равна нулю
+type TrialBalance struct {
+	CurrencyCode string `json:"currency" db:"currency_code"`
+	Total        int64  `json:"total" db:"total"`
+}
+
+// LedgerReconciliation представляет результат сверки журнала с балансами кошельков
+type LedgerReconciliation struct {
+	Mismatches    []WalletLedgerBalance `json:"mismatches"`
+	TrialBalances []TrialBalance        `json:"trial_balances"`
+}
+
+// IsBalanced сообщает, что деньги не были ни созданы, ни уничтожены
+func (r *LedgerReconciliation) IsBalanced() bool {
+	if len(r.Mismatches) > 0 {
+		return false
+	}
+	for _, tb := range r.TrialBalances {
+		if tb.Total != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LimitScope определяет, к чему относятся лимиты: к кошельку или ко всем кошелькам валюты.
+// Задано ровно одно поле.
+type LimitScope struct {
+	WalletID     *uuid.UUID `db:"wallet_id"`
+	CurrencyCode *string    `db:"currency_code"`
+}
+
+// SpendingLimits - лимиты операций в минимальных единицах валюты; nil означает, что лимит не задан.
+// Незаданный лимит кошелька наследуется от валюты отдельно для каждого вида лимита.
+// Лимиты списаний относятся ко всем операциям, уменьшающим баланс, кроме возврата пополнения;
+// лимит пополнений - к DEPOSIT.
+type SpendingLimits struct {
+	LimitScope
+	MaxWithdrawal     *int64    `db:"max_withdrawal"`     // наибольшая сумма одного списания
+	DailyWithdrawal   *int64    `db:"daily_withdrawal"`   // сумма списаний за календарный день (UTC)
+	MonthlyWithdrawal *int64    `db:"monthly_withdrawal"` // сумма списаний за календарный месяц (UTC)
+	HourlyDeposits    *int64    `db:"hourly_deposits"`    // число пополнений за последний час
+	UpdatedAt         time.Time `db:"updated_at"`
+}
+
+// IsEmpty сообщает, что ни один лимит не задан
+func (l *SpendingLimits) IsEmpty() bool {
+	return l.MaxWithdrawal == nil && l.DailyWithdrawal == nil && l.MonthlyWithdrawal == nil && l.HourlyDeposits == nil
+}
+
+// SpendingLimitsRequest задаёт лимиты целиком: незаданное поле снимает лимит.
+// Суммы передаются в основных единицах валюты.
+type SpendingLimitsRequest struct {
+	MaxWithdrawal     *string `json:"max_withdrawal"`
+	DailyWithdrawal   *string `json:"daily_withdrawal"`
+	MonthlyWithdrawal *string `json:"monthly_withdrawal"`
+	HourlyDeposits    *int64  `json:"hourly_deposits"`
+}
+
+// SpendingLimitsResult представляет лимиты вместе с валютой, в которой они заданы
+type SpendingLimitsResult struct {
+	Limits   *SpendingLimits
+	Currency *Currency
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// StatementBalances - остатки кошелька в минимальных единицах на начало и конец периода выписки
+type StatementBalances struct {
+	Opening int64
+	Closing int64
+}
+
+// BalanceAsOf представляет баланс кошелька на момент времени
+type BalanceAsOf struct {
+	WalletID uuid.UUID
+	At       time.Time
+	Currency *Currency
+	Balance  decimal.Decimal
+}
+
+// Statement представляет выписку по кошельку за период [From, To): остатки на начало и конец
+// периода и операции, изменившие баланс, в порядке проведения
+type Statement struct {
+	WalletID       uuid.UUID
+	From           time.Time
+	To             time.Time
+	Currency       *Currency
+	OpeningBalance decimal.Decimal
+	ClosingBalance decimal.Decimal
+	Entries        []TransactionEntry
+}
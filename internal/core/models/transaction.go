@@ -1,9 +1,40 @@
 package models
 
 import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Статусы операций в истории кошелька
+const (
+	TransactionStatusCompleted = "COMPLETED"
+	TransactionStatusFailed    = "FAILED"
+	// Статусы записи о резервировании средств
+	TransactionStatusPending  = "PENDING"
+	TransactionStatusCaptured = "CAPTURED"
+	TransactionStatusVoided   = "VOIDED"
+	TransactionStatusExpired  = "EXPIRED"
+	// Статусы операции, по которой выполнен возврат
+	TransactionStatusReversed          = "REVERSED"
+	TransactionStatusPartiallyReversed = "PARTIALLY_REVERSED"
+)
+
+// FailureReason - код причины отказа в операции со статусом FAILED
+type FailureReason string
+
+const (
+	FailureInsufficientFunds FailureReason = "INSUFFICIENT_FUNDS"
+	FailureWalletNotFound    FailureReason = "WALLET_NOT_FOUND"
+	FailureCurrencyMismatch  FailureReason = "CURRENCY_MISMATCH"
+	FailureRetriesExhausted  FailureReason = "RETRIES_EXHAUSTED"
+	FailureLimitExceeded     FailureReason = "LIMIT_EXCEEDED"
+	FailureWalletNotActive   FailureReason = "WALLET_NOT_ACTIVE"
 )
 
 type Transaction struct {
@@ -11,6 +42,111 @@ type Transaction struct {
 	WalletID      uuid.UUID     `json:"wallet_id" db:"wallet_id"`
 	OperationType OperationType `json:"operation_type" db:"operation_type"`
 	Amount        int64         `json:"amount" db:"amount"`
+	RefundedAmount int64        `json:"refunded_amount" db:"refunded_amount"`
 	Status        string        `json:"status" db:"status"`
+	CounterpartyWalletID *uuid.UUID `json:"counterparty_wallet_id,omitempty" db:"counterparty_wallet_id"`
+	RelatedTransactionID *uuid.UUID `json:"related_transaction_id,omitempty" db:"related_transaction_id"`
+	HoldID        *uuid.UUID    `json:"hold_id,omitempty" db:"hold_id"`
+	FailureReason *FailureReason `json:"failure_reason,omitempty" db:"failure_reason"`
+	ExchangeRate  *decimal.Decimal `json:"exchange_rate,omitempty" db:"exchange_rate"` // курс обмена для CONVERSION_OUT и CONVERSION_IN
+	QuoteID       *uuid.UUID    `json:"quote_id,omitempty" db:"quote_id"`
+	Actor         *string       `json:"actor,omitempty" db:"actor"` // клиент API, выполнивший операцию
+	// BalanceAfter - баланс кошелька после операции; только для операций, изменивших баланс
+	BalanceAfter  *int64        `json:"balance_after,omitempty" db:"balance_after"`
+	PostedAt      *time.Time    `json:"posted_at,omitempty" db:"posted_at"` // момент изменения баланса
 	CreatedAt     time.Time     `json:"created_at" db:"created_at"`
-}
\ No newline at end of file
+}
+
+// IsTransactionStatus проверяет, что статус относится к известным статусам операций
+func IsTransactionStatus(status string) bool {
+	switch status {
+	case TransactionStatusCompleted, TransactionStatusFailed, TransactionStatusPending,
+		TransactionStatusCaptured, TransactionStatusVoided, TransactionStatusExpired,
+		TransactionStatusReversed, TransactionStatusPartiallyReversed:
+		return true
+	}
+	return false
+}
+
+// RemainingReversible возвращает сумму, которую ещё можно вернуть по операции
+func (t *Transaction) RemainingReversible() int64 {
+	return t.Amount - t.RefundedAmount
+}
+
+// ReversalRequest представляет запрос на возврат операции.
+// Пустая сумма означает возврат всего невозвращённого остатка.
+type ReversalRequest struct {
+	TransactionID uuid.UUID `json:"-"`
+	Amount        string    `json:"amount,omitempty"`
+}
+
+// ReversalResult представляет результат возврата операции
+type ReversalResult struct {
+	Original *Transaction
+	Reversal *Transaction
+	Currency *Currency
+	Amount   decimal.Decimal
+	Balance  decimal.Decimal
+}
+
+// TransactionCursor указывает на последнюю выданную запись истории для keyset-пагинации
+type TransactionCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// TransactionFilter задаёт условия выборки истории операций кошелька
+type TransactionFilter struct {
+	WalletID      uuid.UUID
+	OperationType OperationType
+	Status        string
+	From          *time.Time // включительно
+	To            *time.Time // не включительно
+	After         *TransactionCursor
+	Limit         int
+}
+
+// TransactionEntry представляет операцию с суммой в основных единицах валюты кошелька
+type TransactionEntry struct {
+	Transaction  Transaction
+	Amount       decimal.Decimal
+	BalanceAfter *decimal.Decimal
+}
+
+// TransactionPage представляет страницу истории операций
+type TransactionPage struct {
+	Entries  []TransactionEntry
+	Currency *Currency
+	Next     *TransactionCursor
+}
+
+// Encode кодирует курсор в непрозрачную строку для передачи клиенту
+func (c TransactionCursor) Encode() string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixNano(), 10) + "_" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseTransactionCursor разбирает курсор, полученный от клиента
+func ParseTransactionCursor(s string) (*TransactionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("decode cursor: %w", err)
+	}
+
+	parts := strings.SplitN(string(raw), "_", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor timestamp: %w", err)
+	}
+
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor id: %w", err)
+	}
+
+	return &TransactionCursor{CreatedAt: time.Unix(0, nanos).UTC(), ID: id}, nil
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionCursorRoundTrip(t *testing.T) {
+	cursor := TransactionCursor{CreatedAt: time.Date(2026, 3, 1, 12, 0, 0, 123456000, time.UTC), ID: uuid.New()}
+
+	parsed, err := ParseTransactionCursor(cursor.Encode())
+	require.NoError(t, err)
+	assert.True(t, cursor.CreatedAt.Equal(parsed.CreatedAt))
+	assert.Equal(t, cursor.ID, parsed.ID)
+}
+
+func TestParseTransactionCursorRejectsMalformed(t *testing.T) {
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+	tests := map[string]string{
+		"not base64":        "***",
+		"padded base64":     base64.URLEncoding.EncodeToString([]byte("1_" + uuid.NewString())),
+		"missing separator": encode("1700000000000000000"),
+		"invalid timestamp": encode("yesterday_" + uuid.NewString()),
+		"invalid id":        encode("1700000000000000000_42"),
+		"empty":             "",
+	}
+	for name, cursor := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseTransactionCursor(cursor)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -10,12 +10,89 @@ import (
 // Wallet представляет модель кошелька
 type Wallet struct {
 	ID        uuid.UUID `json:"id" db:"id"`
-	Balance   int64   `json:"balance" db:"balance"` // в копейках
+	Balance   int64   `json:"balance" db:"balance"` // учётный баланс в копейках
+	HeldBalance int64 `json:"held_balance" db:"held_balance"` // сумма активных резервов
+	OverdraftLimit int64 `json:"overdraft_limit" db:"overdraft_limit"` // кредитная линия: насколько доступный баланс может уйти в минус
 	CurrencyCode  string    `json:"currency" db:"currency_code"` // ISO 4217: "USD", "RUB"
+	Status    WalletStatus `json:"status" db:"status"`
+	OwnerRef  *string   `json:"owner_ref,omitempty" db:"owner_ref"` // внешний идентификатор владельца
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// AvailableBalance возвращает баланс, доступный для списания с учётом активных резервов
+func (w *Wallet) AvailableBalance() int64 {
+	return w.Balance - w.HeldBalance
+}
+
+// AvailableCredit возвращает неиспользованную часть кредитной линии
+func (w *Wallet) AvailableCredit() int64 {
+	used := max(-w.AvailableBalance(), 0)
+	return max(w.OverdraftLimit-used, 0)
+}
+
+// CanDebit сообщает, достаточно ли собственных средств и кредитной линии для списания amount.
+// По этому правилу проверяется и запрос до транзакции, и баланс в транзакции операции.
+func (w *Wallet) CanDebit(amount int64) bool {
+	return w.AvailableBalance()+w.OverdraftLimit >= amount
+}
+
+// WalletStatus определяет, какие операции разрешены кошельку
+type WalletStatus string
+
+const (
+	// WalletActive - разрешены все операции
+	WalletActive WalletStatus = "ACTIVE"
+	// WalletFrozen - запрещены списания, зачисления разрешены
+	WalletFrozen WalletStatus = "FROZEN"
+	// WalletBlocked - запрещены любые операции
+	WalletBlocked WalletStatus = "BLOCKED"
+	// WalletClosed - конечный статус кошелька без средств и резервов
+	WalletClosed WalletStatus = "CLOSED"
+)
+
+// IsValid проверяет, что статус известен
+func (s WalletStatus) IsValid() bool {
+	switch s {
+	case WalletActive, WalletFrozen, WalletBlocked, WalletClosed:
+		return true
+	}
+	return false
+}
+
+// AllowsDebit сообщает, разрешены ли списания и резервирование средств
+func (s WalletStatus) AllowsDebit() bool {
+	return s == WalletActive
+}
+
+// AllowsCredit сообщает, разрешены ли зачисления
+func (s WalletStatus) AllowsCredit() bool {
+	return s == WalletActive || s == WalletFrozen
+}
+
+// CanTransitionTo проверяет, что статус можно сменить на next. Закрытый кошелёк не переоткрывается.
+func (s WalletStatus) CanTransitionTo(next WalletStatus) bool {
+	return s != WalletClosed && next != s && next.IsValid()
+}
+
+// WalletStatusChange представляет смену статуса кошелька
+type WalletStatusChange struct {
+	ID         int64        `json:"id" db:"id"`
+	WalletID   uuid.UUID    `json:"wallet_id" db:"wallet_id"`
+	FromStatus WalletStatus `json:"from_status" db:"from_status"`
+	ToStatus   WalletStatus `json:"to_status" db:"to_status"`
+	Reason     string       `json:"reason" db:"reason"`
+	Actor      string       `json:"actor" db:"actor"`
+	CreatedAt  time.Time    `json:"created_at" db:"created_at"`
+}
+
+// WalletStatusRequest представляет запрос на смену статуса кошелька
+type WalletStatusRequest struct {
+	Status WalletStatus `json:"status"`
+	Reason string       `json:"reason"`
+	Actor  string       `json:"-"` // клиент API, заполняется по аутентификации
+}
+
 // OperationType определяет тип операции с кошельком
 type OperationType string
 
@@ -24,12 +101,91 @@ const (
 	OperationDeposit OperationType = "DEPOSIT"
 	// OperationWithdraw - снятие средств с кошелька
 	OperationWithdraw OperationType = "WITHDRAW"
+	// OperationTransfer - перевод средств на другой кошелёк
+	OperationTransfer OperationType = "TRANSFER"
+	// OperationTransferOut - списание по переводу, запись в истории кошелька-отправителя
+	OperationTransferOut OperationType = "TRANSFER_OUT"
+	// OperationTransferIn - зачисление по переводу, запись в истории кошелька-получателя
+	OperationTransferIn OperationType = "TRANSFER_IN"
+	// OperationHold - резервирование средств, не меняет учётный баланс
+	OperationHold OperationType = "HOLD"
+	// OperationCapture - списание ранее зарезервированных средств
+	OperationCapture OperationType = "CAPTURE"
+	// OperationReversalDebit - возврат зачисления: средства списываются с кошелька
+	OperationReversalDebit OperationType = "REVERSAL_DEBIT"
+	// OperationReversalCredit - возврат списания: средства возвращаются на кошелёк
+	OperationReversalCredit OperationType = "REVERSAL_CREDIT"
+	// OperationConversionOut - списание при обмене валюты, запись в истории кошелька-источника
+	OperationConversionOut OperationType = "CONVERSION_OUT"
+	// OperationConversionIn - зачисление при обмене валюты, запись в истории кошелька-получателя
+	OperationConversionIn OperationType = "CONVERSION_IN"
 )
 
+// IsTransactionType проверяет, что тип операции может встречаться в истории кошелька
+func (t OperationType) IsTransactionType() bool {
+	switch t {
+	case OperationDeposit, OperationWithdraw, OperationTransferOut, OperationTransferIn,
+		OperationHold, OperationCapture, OperationReversalDebit, OperationReversalCredit,
+		OperationConversionOut, OperationConversionIn:
+		return true
+	}
+	return false
+}
+
+// Sign возвращает направление изменения учётного баланса кошелька: 1 - зачисление,
+// -1 - списание, 0 - операция не меняет учётный баланс
+func (t OperationType) Sign() int64 {
+	switch t {
+	case OperationDeposit, OperationTransferIn, OperationReversalCredit, OperationConversionIn:
+		return 1
+	case OperationWithdraw, OperationTransferOut, OperationCapture, OperationReversalDebit, OperationConversionOut:
+		return -1
+	}
+	return 0
+}
+
+// ReversalType возвращает тип компенсирующей операции для возврата операции данного типа.
+// Переводы и обмены затрагивают два кошелька и не возвращаются.
+func (t OperationType) ReversalType() (OperationType, bool) {
+	switch t {
+	case OperationDeposit:
+		return OperationReversalDebit, true
+	case OperationWithdraw, OperationCapture:
+		return OperationReversalCredit, true
+	}
+	return "", false
+}
+
 // WalletOperation представляет запрос на операцию с кошельком
 type WalletOperation struct {
 	WalletID      uuid.UUID     `json:"walletId"`
+	TargetWalletID uuid.UUID    `json:"targetWalletId,omitempty"` // только для TRANSFER
 	OperationType OperationType `json:"operationType"`
 	Amount        string       `json:"amount"`
-	DecimalAmount decimal.Decimal `json:"-"`
+	OperationID   string       `json:"operationId,omitempty"` // ключ идемпотентности, альтернатива заголовку Idempotency-Key
+	Actor         string       `json:"-"`                     // клиент API, заполняется по аутентификации
+}
+
+// OperationResult представляет баланс кошелька после операции в основных единицах валюты
+type OperationResult struct {
+	Currency *Currency
+	Balance  decimal.Decimal
+}
+
+// CreateWalletRequest представляет запрос на создание кошелька
+type CreateWalletRequest struct {
+	WalletID     *uuid.UUID `json:"walletId,omitempty"`
+	CurrencyCode string     `json:"currency"`
+	OwnerRef     *string    `json:"ownerRef,omitempty"`
+}
+
+// WalletDetails представляет кошелёк вместе с балансом в основных единицах валюты
+type WalletDetails struct {
+	Wallet           *Wallet
+	Currency         *Currency
+	Balance          decimal.Decimal
+	HeldBalance      decimal.Decimal
+	AvailableBalance decimal.Decimal
+	OverdraftLimit   decimal.Decimal
+	AvailableCredit  decimal.Decimal
 }
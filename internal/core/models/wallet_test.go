@@ -0,0 +1,36 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalletDebitCapacity(t *testing.T) {
+	tests := []struct {
+		name            string
+		wallet          Wallet
+		amount          int64
+		canDebit        bool
+		availableCredit int64
+	}{
+		{"funds cover amount", Wallet{Balance: 100}, 100, true, 0},
+		{"funds short of amount", Wallet{Balance: 100}, 101, false, 0},
+		{"held funds are not available", Wallet{Balance: 100, HeldBalance: 40}, 61, false, 0},
+		{"credit line covers shortfall", Wallet{Balance: 100, OverdraftLimit: 50}, 150, true, 50},
+		{"credit line exceeded", Wallet{Balance: 100, OverdraftLimit: 50}, 151, false, 50},
+		{"held funds use credit line", Wallet{Balance: 100, HeldBalance: 130, OverdraftLimit: 50}, 20, true, 20},
+		{"negative balance within credit line", Wallet{Balance: -30, OverdraftLimit: 50}, 20, true, 20},
+		{"negative balance at credit line", Wallet{Balance: -50, OverdraftLimit: 50}, 1, false, 0},
+		// Кредитную линию уменьшили после того, как она была использована
+		{"negative balance beyond reduced credit line", Wallet{Balance: -80, OverdraftLimit: 50}, 1, false, 0},
+		{"negative balance without credit line", Wallet{Balance: -10}, 1, false, 0},
+		{"zero amount on exhausted credit line", Wallet{Balance: -50, HeldBalance: 10, OverdraftLimit: 50}, 0, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.canDebit, tt.wallet.CanDebit(tt.amount))
+			assert.Equal(t, tt.availableCredit, tt.wallet.AvailableCredit())
+		})
+	}
+}
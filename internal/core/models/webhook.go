@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookSubscription - подписка партнёра на события кошелька или всех кошельков
+type WebhookSubscription struct {
+	ID         uuid.UUID   `json:"id" db:"id"`
+	WalletID   *uuid.UUID  `json:"wallet_id,omitempty" db:"wallet_id"` // nil - все кошельки
+	URL        string      `json:"url" db:"url"`
+	Secret     string      `json:"-" db:"secret"`
+	EventTypes []EventType `json:"event_types" db:"-"` // пустой список - все типы событий
+	CreatedAt  time.Time   `json:"created_at" db:"created_at"`
+}
+
+// WebhookSubscriptionRequest представляет запрос на создание подписки. Если секрет не передан,
+// он генерируется и возвращается только в ответе на создание.
+type WebhookSubscriptionRequest struct {
+	WalletID   *uuid.UUID  `json:"wallet_id,omitempty"`
+	URL        string      `json:"url"`
+	Secret     string      `json:"secret,omitempty"`
+	EventTypes []EventType `json:"event_types,omitempty"`
+}
+
+// DeliveryStatus определяет состояние доставки события подписчику
+type DeliveryStatus string
+
+const (
+	// DeliveryPending - доставка ожидает очередной попытки
+	DeliveryPending DeliveryStatus = "PENDING"
+	// DeliveryDelivered - подписчик ответил кодом 2xx
+	DeliveryDelivered DeliveryStatus = "DELIVERED"
+	// DeliveryDead - попытки исчерпаны; доставку можно повторить вручную
+	DeliveryDead DeliveryStatus = "DEAD"
+)
+
+// WebhookDelivery - доставка одного события по одной подписке
+type WebhookDelivery struct {
+	ID             uuid.UUID      `json:"id" db:"id"`
+	SubscriptionID uuid.UUID      `json:"subscription_id" db:"subscription_id"`
+	EventID        uuid.UUID      `json:"event_id" db:"event_id"`
+	EventType      EventType      `json:"event_type" db:"event_type"`
+	Payload        []byte         `json:"-" db:"payload"` // тело запроса: событие в формате OutboxEvent
+	Status         DeliveryStatus `json:"status" db:"status"`
+	Attempts       int            `json:"attempts" db:"attempts"`
+	NextAttemptAt  time.Time      `json:"next_attempt_at" db:"next_attempt_at"`
+	LastError      *string        `json:"last_error,omitempty" db:"last_error"`
+	LastStatusCode *int           `json:"last_status_code,omitempty" db:"last_status_code"`
+	DeliveredAt    *time.Time     `json:"delivered_at,omitempty" db:"delivered_at"`
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+
+	// Адрес и секрет подписки заполняются для доставок, выданных на отправку
+	URL    string `json:"-" db:"url"`
+	Secret string `json:"-" db:"secret"`
+}
+
+// DeliveryAttempt описывает результат неудачной попытки доставки
+type DeliveryAttempt struct {
+	Error      string
+	StatusCode *int
+	RetryAt    *time.Time // nil - попытки исчерпаны, доставка переходит в DEAD
+}
@@ -0,0 +1,37 @@
+package repository
+
+import "errors"
+
+// Ошибки уровня хранилища, не зависящие от конкретной реализации
+var (
+	ErrNotFound          = errors.New("not found")
+	ErrAlreadyExists     = errors.New("already exists")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrCurrencyMismatch  = errors.New("currency mismatch")
+	ErrIdempotencyConflict = errors.New("idempotency key reused with different request")
+	ErrHoldNotFound      = errors.New("hold not found")
+	ErrHoldNotActive     = errors.New("hold is not active")
+	ErrHoldExpired       = errors.New("hold expired")
+	ErrCaptureExceedsHold = errors.New("capture amount exceeds hold")
+	ErrTransactionNotFound = errors.New("transaction not found")
+	ErrNotReversible     = errors.New("transaction cannot be reversed")
+	ErrAlreadyReversed   = errors.New("transaction already reversed")
+	ErrReversalExceedsOriginal = errors.New("reversal amount exceeds original")
+	ErrRetriesExhausted  = errors.New("retries exhausted")
+	ErrCurrencyInUse     = errors.New("currency is in use")
+	ErrRateNotFound      = errors.New("exchange rate not found")
+	ErrQuoteNotFound     = errors.New("quote not found")
+	ErrQuoteExpired      = errors.New("quote expired")
+	ErrQuoteUsed         = errors.New("quote already used")
+	ErrLimitExceeded     = errors.New("spending limit exceeded")
+	ErrWalletNotActive   = errors.New("operation not allowed for wallet status")
+	ErrStatusTransition  = errors.New("wallet status transition not allowed")
+	ErrWalletNotEmpty    = errors.New("wallet has funds or holds")
+	ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+	ErrDeliveryNotFound  = errors.New("webhook delivery not found")
+	ErrDeliveryPending   = errors.New("webhook delivery is still pending")
+	ErrAPIKeyNotFound    = errors.New("api key not found")
+	ErrAPIKeyRevoked     = errors.New("api key revoked")
+	// ErrEventDeferred - событие outbox не публиковалось и не расходует попытку
+	ErrEventDeferred     = errors.New("event deferred")
+)
@@ -0,0 +1,164 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type postgresAPIKeyRepo struct {
+	db  *sqlx.DB
+	log logger.Logger
+}
+
+func NewPostgresAPIKeyRepo(db *sqlx.DB, log logger.Logger) repository.APIKeyRepository {
+	return &postgresAPIKeyRepo{db: db, log: log}
+}
+
+const apiKeyColumns = `id, name, prefix, key_hash, scopes, wallet_ids, created_at, expires_at, revoked_at, rotated_to`
+
+// apiKeyRow читает массивы прав и кошельков PostgreSQL
+type apiKeyRow struct {
+	models.APIKey
+	Scopes    pq.StringArray `db:"scopes"`
+	WalletIDs pq.StringArray `db:"wallet_ids"`
+}
+
+func (row *apiKeyRow) apiKey() (*models.APIKey, error) {
+	key := row.APIKey
+	key.Scopes = make([]models.Scope, len(row.Scopes))
+	for i, scope := range row.Scopes {
+		key.Scopes[i] = models.Scope(scope)
+	}
+	key.WalletIDs = make([]uuid.UUID, len(row.WalletIDs))
+	for i, id := range row.WalletIDs {
+		walletID, err := uuid.Parse(id)
+		if err != nil {
+			return nil, fmt.Errorf("parse wallet id of api key %s: %w", key.ID, err)
+		}
+		key.WalletIDs[i] = walletID
+	}
+	return &key, nil
+}
+
+func (r *postgresAPIKeyRepo) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	return createAPIKey(ctx, r.db, key)
+}
+
+func createAPIKey(ctx context.Context, db sqlx.QueryerContext, key *models.APIKey) error {
+	scopes := make(pq.StringArray, len(key.Scopes))
+	for i, scope := range key.Scopes {
+		scopes[i] = string(scope)
+	}
+	walletIDs := make(pq.StringArray, len(key.WalletIDs))
+	for i, id := range key.WalletIDs {
+		walletIDs[i] = id.String()
+	}
+
+	query := `INSERT INTO api_keys (id, name, prefix, key_hash, scopes, wallet_ids, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6::uuid[], $7)
+		RETURNING created_at`
+	err := db.QueryRowxContext(ctx, query,
+		key.ID, key.Name, key.Prefix, key.Hash, scopes, walletIDs, key.ExpiresAt,
+	).Scan(&key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("create api key: %w", err)
+	}
+	return nil
+}
+
+func (r *postgresAPIKeyRepo) GetAPIKey(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
+	return r.getAPIKey(ctx, `id = $1`, id)
+}
+
+func (r *postgresAPIKeyRepo) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	return r.getAPIKey(ctx, `key_hash = $1`, hash)
+}
+
+func (r *postgresAPIKeyRepo) getAPIKey(ctx context.Context, condition string, value any) (*models.APIKey, error) {
+	var row apiKeyRow
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE ` + condition
+	if err := r.db.GetContext(ctx, &row, query, value); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("get api key: %w", err)
+	}
+	return row.apiKey()
+}
+
+func (r *postgresAPIKeyRepo) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	var rows []apiKeyRow
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY created_at, id`
+	if err := r.db.SelectContext(ctx, &rows, query); err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+
+	keys := make([]models.APIKey, len(rows))
+	for i := range rows {
+		key, err := rows[i].apiKey()
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = *key
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey отзывает ключ; повторный отзыв не меняет время отзыва
+func (r *postgresAPIKeyRepo) RevokeAPIKey(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
+	var row apiKeyRow
+	query := `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, CURRENT_TIMESTAMP)
+		WHERE id = $1
+		RETURNING ` + apiKeyColumns
+	if err := r.db.GetContext(ctx, &row, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", repository.ErrAPIKeyNotFound, id)
+		}
+		return nil, fmt.Errorf("revoke api key: %w", err)
+	}
+	return row.apiKey()
+}
+
+func (r *postgresAPIKeyRepo) RotateAPIKey(ctx context.Context, id uuid.UUID, next *models.APIKey, expiresAt time.Time) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin rotation: %w", err)
+	}
+	defer tx.Rollback()
+
+	var revoked bool
+	if err := tx.GetContext(ctx, &revoked, `SELECT revoked_at IS NOT NULL FROM api_keys WHERE id = $1 FOR UPDATE`, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %s", repository.ErrAPIKeyNotFound, id)
+		}
+		return fmt.Errorf("lock api key: %w", err)
+	}
+	if revoked {
+		return fmt.Errorf("%w: %s", repository.ErrAPIKeyRevoked, id)
+	}
+
+	if err := createAPIKey(ctx, tx, next); err != nil {
+		return err
+	}
+
+	// Срок старого ключа только сокращается: ротация не продлевает ключ
+	query := `UPDATE api_keys SET rotated_to = $1, expires_at = LEAST(COALESCE(expires_at, $2), $2) WHERE id = $3`
+	if _, err := tx.ExecContext(ctx, query, next.ID, expiresAt, id); err != nil {
+		return fmt.Errorf("expire rotated api key: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit rotation: %w", err)
+	}
+	return nil
+}
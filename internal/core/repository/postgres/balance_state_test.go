@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// canDebit проверяет состояние уже после списания, поэтому результат должен совпадать
+// с models.Wallet.CanDebit для состояния до списания
+func TestBalanceStateCanDebit(t *testing.T) {
+	tests := []struct {
+		name  string
+		state balanceState
+		want  bool
+	}{
+		{"positive balance", balanceState{Balance: 10}, true},
+		{"zero balance", balanceState{}, true},
+		{"negative balance without credit line", balanceState{Balance: -1}, false},
+		{"negative balance within credit line", balanceState{Balance: -50, OverdraftLimit: 50}, true},
+		{"negative balance beyond credit line", balanceState{Balance: -51, OverdraftLimit: 50}, false},
+		{"held funds within balance", balanceState{Balance: 100, HeldBalance: 100}, true},
+		{"held funds beyond balance", balanceState{Balance: 100, HeldBalance: 101}, false},
+		{"held funds within credit line", balanceState{Balance: 100, HeldBalance: 150, OverdraftLimit: 50}, true},
+		{"held funds beyond credit line", balanceState{Balance: -10, HeldBalance: 41, OverdraftLimit: 50}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.state.canDebit())
+		})
+	}
+}
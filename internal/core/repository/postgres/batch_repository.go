@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// errBatchAborted прерывает транзакцию атомарного пакета; причина сохраняется в результате операции
+var errBatchAborted = errors.New("batch aborted")
+
+func (r *postgresWalletRepo) ExecuteBatchWithRetry(ctx context.Context, items []repository.OperationParams, atomic bool) ([]repository.BatchItemOutcome, error) {
+	var outcomes []repository.BatchItemOutcome
+	err := r.withRetry(ctx, "batch", func() error {
+		outcomes = make([]repository.BatchItemOutcome, len(items))
+		err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+			return r.executeBatch(ctx, tx, items, outcomes, atomic)
+		})
+		if errors.Is(err, errBatchAborted) {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return outcomes, nil
+}
+
+// executeBatch выполняет операции пакета. В режиме best-effort каждая операция выполняется
+// внутри точки сохранения, и её ошибка откатывает только эту операцию. Конфликты сериализации
+// прерывают транзакцию целиком: пакет будет повторён с начала.
+func (r *postgresWalletRepo) executeBatch(ctx context.Context, tx *sqlx.Tx, items []repository.OperationParams, outcomes []repository.BatchItemOutcome, atomic bool) error {
+	if r.txStrategy == TxStrategyRowLock {
+		if err := r.lockBatchWallets(ctx, tx, items); err != nil {
+			return err
+		}
+	}
+
+	for i, params := range items {
+		if !atomic {
+			if _, err := tx.ExecContext(ctx, `SAVEPOINT batch_item`); err != nil {
+				return fmt.Errorf("create savepoint: %w", err)
+			}
+		}
+
+		balance, err := r.applyOperation(ctx, tx, params)
+		switch {
+		case err == nil:
+			outcomes[i].Balance = balance
+			if !atomic {
+				if _, err := tx.ExecContext(ctx, `RELEASE SAVEPOINT batch_item`); err != nil {
+					return fmt.Errorf("release savepoint: %w", err)
+				}
+			}
+		case isRetryable(err):
+			return err
+		case atomic:
+			outcomes[i].Err = err
+			return errBatchAborted
+		default:
+			outcomes[i].Err = err
+			if _, err := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT batch_item`); err != nil {
+				return fmt.Errorf("rollback to savepoint: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// lockBatchWallets заранее блокирует все кошельки пакета в порядке возрастания id: без этого
+// пакеты, затрагивающие одни кошельки в разном порядке, взаимно блокировали бы друг друга.
+// Отсутствующие кошельки пропускаются, их ошибка вернётся из соответствующей операции.
+func (r *postgresWalletRepo) lockBatchWallets(ctx context.Context, tx *sqlx.Tx, items []repository.OperationParams) error {
+	ids := make([]uuid.UUID, 0, len(items))
+	for _, params := range items {
+		ids = append(ids, params.WalletID)
+		if params.TargetWalletID != uuid.Nil {
+			ids = append(ids, params.TargetWalletID)
+		}
+	}
+
+	query := `SELECT id FROM wallets WHERE id = ANY($1) ORDER BY id FOR UPDATE`
+	if _, err := tx.ExecContext(ctx, query, pq.Array(ids)); err != nil {
+		return fmt.Errorf("lock batch wallets: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/Nzyazin/itk/internal/core/repository/postgres"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func completedOperations(t *testing.T, db *sqlx.DB, walletID uuid.UUID) int {
+	t.Helper()
+	var count int
+	require.NoError(t, db.Get(&count,
+		`SELECT COUNT(*) FROM transactions WHERE wallet_id = $1 AND status = 'COMPLETED'`, walletID))
+	return count
+}
+
+func TestBatch(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	db, teardown := setupTestDB(t, log)
+	defer teardown()
+
+	ctx := context.Background()
+
+	for _, strategy := range []postgres.TxStrategy{postgres.TxStrategySerializable, postgres.TxStrategyRowLock} {
+		repo := postgres.NewPostgresWalletRepo(db, log, postgres.WithTxStrategy(strategy))
+
+		// Ошибка операции откатывается до точки сохранения: операции до и после неё сохраняются
+		t.Run(string(strategy)+" best effort", func(t *testing.T) {
+			first, second := createWallet(t, db, "USD", 100), createWallet(t, db, "USD", 0)
+			outcomes, err := repo.ExecuteBatchWithRetry(ctx, []repository.OperationParams{
+				{WalletID: first, Amount: 50, OperationType: models.OperationDeposit},
+				{WalletID: first, Amount: 1000, OperationType: models.OperationWithdraw},
+				{WalletID: second, TargetWalletID: first, Amount: 10, OperationType: models.OperationTransfer},
+				{WalletID: first, TargetWalletID: second, Amount: 30, OperationType: models.OperationTransfer},
+			}, false)
+			require.NoError(t, err)
+			require.Len(t, outcomes, 4)
+
+			assert.NoError(t, outcomes[0].Err)
+			assert.Equal(t, int64(150), outcomes[0].Balance)
+			assert.ErrorIs(t, outcomes[1].Err, repository.ErrInsufficientFunds)
+			assert.ErrorIs(t, outcomes[2].Err, repository.ErrInsufficientFunds)
+			assert.NoError(t, outcomes[3].Err)
+			assert.Equal(t, int64(120), outcomes[3].Balance)
+
+			wallets, err := repo.GetByIDs(ctx, []uuid.UUID{first, second})
+			require.NoError(t, err)
+			assert.Equal(t, int64(120), wallets[first].Balance)
+			assert.Equal(t, int64(30), wallets[second].Balance)
+			assert.Equal(t, 2, completedOperations(t, db, first), "deposit and transfer out")
+			assert.Equal(t, 1, completedOperations(t, db, second), "transfer in")
+		})
+
+		// Первая ошибка откатывает весь пакет, следующие операции не выполняются
+		t.Run(string(strategy)+" atomic", func(t *testing.T) {
+			first, second := createWallet(t, db, "USD", 100), createWallet(t, db, "USD", 0)
+			outcomes, err := repo.ExecuteBatchWithRetry(ctx, []repository.OperationParams{
+				{WalletID: first, Amount: 50, OperationType: models.OperationDeposit},
+				{WalletID: first, Amount: 1000, OperationType: models.OperationWithdraw},
+				{WalletID: second, Amount: 10, OperationType: models.OperationDeposit},
+			}, true)
+			require.NoError(t, err, "aborted batch is reported per operation")
+			require.Len(t, outcomes, 3)
+
+			assert.NoError(t, outcomes[0].Err)
+			assert.ErrorIs(t, outcomes[1].Err, repository.ErrInsufficientFunds)
+			assert.NoError(t, outcomes[2].Err)
+			assert.Zero(t, outcomes[2].Balance, "operation after the failure is not executed")
+
+			wallets, err := repo.GetByIDs(ctx, []uuid.UUID{first, second})
+			require.NoError(t, err)
+			assert.Equal(t, int64(100), wallets[first].Balance)
+			assert.Zero(t, wallets[second].Balance)
+			assert.Zero(t, completedOperations(t, db, first))
+			assert.Zero(t, completedOperations(t, db, second))
+		})
+	}
+}
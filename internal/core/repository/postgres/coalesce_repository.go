@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+func (r *postgresWalletRepo) ExecuteWalletOperationsWithRetry(ctx context.Context, walletID uuid.UUID, items []repository.OperationParams) ([]repository.BatchItemOutcome, error) {
+	var outcomes []repository.BatchItemOutcome
+	err := r.withRetry(ctx, "wallet_operations", func() error {
+		outcomes = make([]repository.BatchItemOutcome, len(items))
+		return r.inTx(ctx, func(tx *sqlx.Tx) error {
+			return r.executeWalletOperations(ctx, tx, walletID, items, outcomes)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return outcomes, nil
+}
+
+// executeWalletOperations проверяет операции по порядку относительно баланса после предыдущих,
+// записывает их в историю и журнал, а баланс кошелька обновляет одним запросом в конце.
+// Каждая операция выполняется внутри точки сохранения: её ошибка откатывает только её записи.
+func (r *postgresWalletRepo) executeWalletOperations(ctx context.Context, tx *sqlx.Tx, walletID uuid.UUID, items []repository.OperationParams, outcomes []repository.BatchItemOutcome) error {
+	// Истёкшие резервы не должны уменьшать доступный баланс, даже если их ещё не освободил фоновый процесс
+	if _, err := r.expireHolds(ctx, tx, &walletID, 0); err != nil {
+		return err
+	}
+
+	var state balanceState
+	query := `SELECT balance, held_balance, overdraft_limit, currency_code, status
+		FROM wallets WHERE id = $1 FOR UPDATE`
+	if err := tx.GetContext(ctx, &state, query, walletID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = fmt.Errorf("%w: wallet with id %s", repository.ErrNotFound, walletID)
+			for i := range outcomes {
+				outcomes[i].Err = err
+			}
+			return nil
+		}
+		return fmt.Errorf("lock wallet: %w", err)
+	}
+	initial := state.Balance
+
+	for i, params := range items {
+		if params.WalletID != walletID {
+			outcomes[i].Err = fmt.Errorf("operation for wallet %s grouped with wallet %s", params.WalletID, walletID)
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `SAVEPOINT wallet_operation`); err != nil {
+			return fmt.Errorf("create savepoint: %w", err)
+		}
+
+		balance, err := r.applyWalletOperation(ctx, tx, &state, params)
+		switch {
+		case err == nil:
+			outcomes[i].Balance = balance
+			if _, err := tx.ExecContext(ctx, `RELEASE SAVEPOINT wallet_operation`); err != nil {
+				return fmt.Errorf("release savepoint: %w", err)
+			}
+		case isRetryable(err):
+			return err
+		default:
+			outcomes[i].Err = err
+			if _, err := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT wallet_operation`); err != nil {
+				return fmt.Errorf("rollback to savepoint: %w", err)
+			}
+		}
+	}
+
+	if state.Balance == initial {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE wallets SET balance = $1 WHERE id = $2`, state.Balance, walletID); err != nil {
+		return fmt.Errorf("update balance: %w", err)
+	}
+	return nil
+}
+
+// applyWalletOperation выполняет зачисление или списание относительно state и при успехе
+// переносит в state новый баланс; сам кошелёк не изменяется
+func (r *postgresWalletRepo) applyWalletOperation(ctx context.Context, tx *sqlx.Tx, state *balanceState, params repository.OperationParams) (int64, error) {
+	if params.OperationType != models.OperationDeposit && params.OperationType != models.OperationWithdraw {
+		return 0, ErrInvalidOperationType
+	}
+
+	replayed, err := r.loadIdempotentResult(ctx, tx, params)
+	if err != nil {
+		return 0, err
+	}
+	if replayed != nil {
+		return *replayed, nil
+	}
+
+	delta := params.OperationType.Sign() * params.Amount
+	next := *state
+	next.Balance += delta
+	switch {
+	case delta < 0 && !state.Status.AllowsDebit(), delta > 0 && !state.Status.AllowsCredit():
+		return 0, fmt.Errorf("%w: wallet %s is %s", repository.ErrWalletNotActive, params.WalletID, state.Status)
+	case delta < 0 && !next.canDebit():
+		return 0, ErrInsufficientFunds
+	}
+
+	if err := r.checkLimits(ctx, tx, params.WalletID, state.CurrencyCode, params.OperationType, params.Amount); err != nil {
+		return 0, err
+	}
+
+	transaction := &models.Transaction{
+		ID:            uuid.New(),
+		WalletID:      params.WalletID,
+		OperationType: params.OperationType,
+		Amount:        params.Amount,
+		Status:        transactionStatusCompleted,
+		Actor:         actorValue(params.Actor),
+		// Баланс кошелька изменится одним запросом после всех операций группы
+		BalanceAfter:  &next.Balance,
+	}
+	if err := r.createTransaction(ctx, tx, transaction); err != nil {
+		return 0, err
+	}
+	if err := r.postAgainstClearing(ctx, tx, params.OperationType, transaction.ID, params.WalletID,
+		state.CurrencyCode, delta); err != nil {
+		return 0, err
+	}
+	if err := r.saveIdempotentResult(ctx, tx, params, transaction.ID, next.Balance); err != nil {
+		return 0, err
+	}
+
+	*state = next
+	return next.Balance, nil
+}
@@ -0,0 +1,138 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/Nzyazin/itk/internal/core/repository/postgres"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// benchDBURLEnv задаёт базу с применёнными миграциями для сравнения стратегий под нагрузкой.
+// Бенчмарки создают в ней кошельки и операции, поэтому рабочую базу использовать не следует.
+const benchDBURLEnv = "BENCH_DB_URL"
+
+// BenchmarkHotWallet сравнивает стратегии изоляции при конкурирующих операциях над одним кошельком:
+// зачислениях на него и переводах на него с разных кошельков. Кроме времени операции
+// отчитывается число повторов и доля операций, не выполненных после всех повторов.
+func BenchmarkHotWallet(b *testing.B) {
+	url := os.Getenv(benchDBURLEnv)
+	if url == "" {
+		b.Skipf("%s is not set", benchDBURLEnv)
+	}
+
+	db, err := sqlx.Connect("postgres", url)
+	if err != nil {
+		b.Fatalf("Failed to connect to PostgreSQL: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(64)
+	db.SetMaxIdleConns(64)
+
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	strategies := []postgres.TxStrategy{postgres.TxStrategySerializable, postgres.TxStrategyRowLock}
+	scenarios := []struct {
+		name string
+		run  func(ctx context.Context, repo repository.WalletRepository, hot, source uuid.UUID) error
+	}{
+		{"deposit", func(ctx context.Context, repo repository.WalletRepository, hot, _ uuid.UUID) error {
+			_, err := repo.ExecuteTxWithRetry(ctx, repository.OperationParams{
+				WalletID:      hot,
+				Amount:        1,
+				OperationType: models.OperationDeposit,
+			})
+			return err
+		}},
+		{"transfer_in", func(ctx context.Context, repo repository.WalletRepository, hot, source uuid.UUID) error {
+			_, err := repo.TransferTxWithRetry(ctx, repository.OperationParams{
+				WalletID:       source,
+				TargetWalletID: hot,
+				Amount:         1,
+				OperationType:  models.OperationTransfer,
+			})
+			return err
+		}},
+	}
+
+	for _, strategy := range strategies {
+		repo := postgres.NewPostgresWalletRepo(db, log, postgres.WithTxStrategy(strategy))
+		for _, scenario := range scenarios {
+			for _, parallelism := range []int{1, 4, 16} {
+				name := fmt.Sprintf("%s/%s/parallelism=%d", strategy, scenario.name, parallelism)
+				b.Run(name, func(b *testing.B) {
+					hot := createBenchWallet(b, db, 0)
+					// У каждой горутины свой кошелёк-источник: конкурируют только за горячий кошелёк
+					sources := make([]uuid.UUID, parallelism*runtime.GOMAXPROCS(0))
+					for i := range sources {
+						sources[i] = createBenchWallet(b, db, 1<<40)
+					}
+					retriesBefore := retriesTotal(b)
+
+					var failed atomic.Int64
+					var next atomic.Int64
+					b.SetParallelism(parallelism)
+					b.ResetTimer()
+					b.RunParallel(func(pb *testing.PB) {
+						source := sources[next.Add(1)-1]
+						ctx := context.Background()
+						for pb.Next() {
+							err := scenario.run(ctx, repo, hot, source)
+							switch {
+							case errors.Is(err, repository.ErrRetriesExhausted):
+								failed.Add(1)
+							case err != nil:
+								b.Errorf("operation failed: %v", err)
+								return
+							}
+						}
+					})
+					b.StopTimer()
+
+					b.ReportMetric((retriesTotal(b)-retriesBefore)/float64(b.N), "retries/op")
+					b.ReportMetric(float64(failed.Load())/float64(b.N), "failed/op")
+				})
+			}
+		}
+	}
+}
+
+func createBenchWallet(b *testing.B, db *sqlx.DB, balance int64) uuid.UUID {
+	id := uuid.New()
+	_, err := db.Exec(`INSERT INTO wallets (id, balance, currency_code, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())`, id, balance, "USD")
+	if err != nil {
+		b.Fatalf("Failed to create wallet: %v", err)
+	}
+	return id
+}
+
+// retriesTotal возвращает суммарное число повторов всех операций из метрики wallet_retries_total
+func retriesTotal(b *testing.B) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		b.Fatalf("Failed to gather metrics: %v", err)
+	}
+
+	var total float64
+	for _, family := range families {
+		if family.GetName() != "wallet_retries_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			total += metric.GetCounter().GetValue()
+		}
+	}
+	return total
+}
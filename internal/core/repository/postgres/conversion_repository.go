@@ -0,0 +1,152 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const quoteColumns = `id, wallet_id, target_wallet_id, source_currency, target_currency,
+	source_amount, target_amount, rate, expires_at, used_at, created_at`
+
+func (r *postgresWalletRepo) CreateQuote(ctx context.Context, quote *models.FXQuote) error {
+	query := `INSERT INTO fx_quotes (id, wallet_id, target_wallet_id, source_currency, target_currency,
+			source_amount, target_amount, rate, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING created_at`
+	err := r.db.QueryRowxContext(ctx, query, quote.ID, quote.WalletID, quote.TargetWalletID,
+		quote.SourceCurrency, quote.TargetCurrency, quote.SourceAmount, quote.TargetAmount,
+		quote.Rate, quote.ExpiresAt).Scan(&quote.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("create quote: %w", err)
+	}
+	return nil
+}
+
+func (r *postgresWalletRepo) GetQuote(ctx context.Context, id uuid.UUID) (*models.FXQuote, error) {
+	var quote models.FXQuote
+	query := `SELECT ` + quoteColumns + ` FROM fx_quotes WHERE id = $1`
+	if err := r.db.GetContext(ctx, &quote, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", repository.ErrQuoteNotFound, id)
+		}
+		return nil, fmt.Errorf("error getting quote: %w", err)
+	}
+	return &quote, nil
+}
+
+// ConvertWithRetry списывает сумму котировки с кошелька-источника и зачисляет пересчитанную
+// сумму на кошелёк-получатель по зафиксированному в котировке курсу. Котировка помечается
+// использованной в той же транзакции, поэтому повторный обмен по ней невозможен.
+func (r *postgresWalletRepo) ConvertWithRetry(ctx context.Context, quoteID uuid.UUID, actor string) (*repository.ConversionOutcome, error) {
+	var outcome *repository.ConversionOutcome
+	err := r.withRetry(ctx, "convert", func() error {
+		return r.inTx(ctx, func(tx *sqlx.Tx) error {
+			var err error
+			outcome, err = r.convert(ctx, tx, quoteID, actor)
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return outcome, nil
+}
+
+func (r *postgresWalletRepo) convert(ctx context.Context, tx *sqlx.Tx, quoteID uuid.UUID, actor string) (*repository.ConversionOutcome, error) {
+	var quote struct {
+		models.FXQuote
+		IsExpired bool `db:"is_expired"`
+	}
+	query := `SELECT ` + quoteColumns + `, expires_at <= CURRENT_TIMESTAMP AS is_expired
+		FROM fx_quotes WHERE id = $1 FOR UPDATE`
+	if err := tx.GetContext(ctx, &quote, query, quoteID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", repository.ErrQuoteNotFound, quoteID)
+		}
+		return nil, fmt.Errorf("lock quote: %w", err)
+	}
+	switch {
+	case quote.UsedAt != nil:
+		return nil, fmt.Errorf("%w: %s", repository.ErrQuoteUsed, quoteID)
+	case quote.IsExpired:
+		return nil, fmt.Errorf("%w: %s", repository.ErrQuoteExpired, quoteID)
+	}
+
+	fromID, toID := quote.WalletID, quote.TargetWalletID
+	if _, err := r.lockWallets(ctx, tx, fromID, toID); err != nil {
+		return nil, err
+	}
+
+	source, err := r.updateBalance(ctx, tx, fromID, quote.SourceAmount, models.OperationConversionOut)
+	if err != nil {
+		return nil, err
+	}
+	target, err := r.updateBalance(ctx, tx, toID, quote.TargetAmount, models.OperationConversionIn)
+	if err != nil {
+		return nil, err
+	}
+	if source.CurrencyCode != quote.SourceCurrency || target.CurrencyCode != quote.TargetCurrency {
+		return nil, fmt.Errorf("%w: quote %s/%s, wallets %s/%s", repository.ErrCurrencyMismatch,
+			quote.SourceCurrency, quote.TargetCurrency, source.CurrencyCode, target.CurrencyCode)
+	}
+
+	debitID, creditID := uuid.New(), uuid.New()
+	debit := &models.Transaction{
+		ID:                   debitID,
+		WalletID:             fromID,
+		OperationType:        models.OperationConversionOut,
+		Amount:               quote.SourceAmount,
+		Status:               transactionStatusCompleted,
+		CounterpartyWalletID: &toID,
+		RelatedTransactionID: &creditID,
+		ExchangeRate:         &quote.Rate,
+		QuoteID:              &quote.ID,
+		Actor:                actorValue(actor),
+	}
+	credit := &models.Transaction{
+		ID:                   creditID,
+		WalletID:             toID,
+		OperationType:        models.OperationConversionIn,
+		Amount:               quote.TargetAmount,
+		Status:               transactionStatusCompleted,
+		CounterpartyWalletID: &fromID,
+		RelatedTransactionID: &debitID,
+		ExchangeRate:         &quote.Rate,
+		QuoteID:              &quote.ID,
+		Actor:                actorValue(actor),
+	}
+	for _, t := range []*models.Transaction{debit, credit} {
+		if err := r.createTransaction(ctx, tx, t); err != nil {
+			return nil, err
+		}
+	}
+
+	// Каждая валюта проводится отдельной записью через валютную позицию сервиса,
+	// поэтому сумма проводок в каждой валюте остаётся нулевой
+	if err := r.postAgainstSystem(ctx, tx, models.SystemAccountFX, models.OperationConversionOut,
+		debitID, fromID, source.CurrencyCode, -quote.SourceAmount); err != nil {
+		return nil, err
+	}
+	if err := r.postAgainstSystem(ctx, tx, models.SystemAccountFX, models.OperationConversionIn,
+		creditID, toID, target.CurrencyCode, quote.TargetAmount); err != nil {
+		return nil, err
+	}
+
+	query = `UPDATE fx_quotes SET used_at = CURRENT_TIMESTAMP, transaction_id = $2 WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, query, quote.ID, debitID); err != nil {
+		return nil, fmt.Errorf("mark quote used: %w", err)
+	}
+
+	return &repository.ConversionOutcome{
+		Debit:   debit,
+		Credit:  credit,
+		Balance: source.Balance,
+	}, nil
+}
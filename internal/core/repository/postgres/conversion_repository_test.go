@@ -0,0 +1,70 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/Nzyazin/itk/internal/core/repository/postgres"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createQuote(t *testing.T, repo repository.WalletRepository, source, target uuid.UUID, expiresAt time.Time) *models.FXQuote {
+	t.Helper()
+	quote := &models.FXQuote{
+		ID: uuid.New(), WalletID: source, TargetWalletID: target,
+		SourceCurrency: "USD", TargetCurrency: "EUR",
+		SourceAmount: 100, TargetAmount: 90, Rate: decimal.RequireFromString("0.9"),
+		ExpiresAt: expiresAt,
+	}
+	require.NoError(t, repo.CreateQuote(context.Background(), quote))
+	return quote
+}
+
+func TestConvertQuote(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	db, teardown := setupTestDB(t, log)
+	defer teardown()
+
+	repo := postgres.NewPostgresWalletRepo(db, log)
+	ctx := context.Background()
+
+	source, target := createWallet(t, db, "USD", 1000), createWallet(t, db, "EUR", 0)
+	balances := func() (int64, int64) {
+		wallets, err := repo.GetByIDs(ctx, []uuid.UUID{source, target})
+		require.NoError(t, err)
+		return wallets[source].Balance, wallets[target].Balance
+	}
+
+	expired := createQuote(t, repo, source, target, time.Now().Add(-time.Second))
+	_, err := repo.ConvertWithRetry(ctx, expired.ID, "")
+	assert.ErrorIs(t, err, repository.ErrQuoteExpired)
+	sourceBalance, targetBalance := balances()
+	assert.Equal(t, int64(1000), sourceBalance)
+	assert.Zero(t, targetBalance)
+
+	quote := createQuote(t, repo, source, target, time.Now().Add(time.Minute))
+	outcome, err := repo.ConvertWithRetry(ctx, quote.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(900), outcome.Balance)
+	assert.Equal(t, models.OperationConversionOut, outcome.Debit.OperationType)
+	assert.Equal(t, int64(90), outcome.Credit.Amount)
+
+	// Котировка используется один раз
+	_, err = repo.ConvertWithRetry(ctx, quote.ID, "")
+	assert.ErrorIs(t, err, repository.ErrQuoteUsed)
+	sourceBalance, targetBalance = balances()
+	assert.Equal(t, int64(900), sourceBalance)
+	assert.Equal(t, int64(90), targetBalance)
+
+	_, err = repo.ConvertWithRetry(ctx, uuid.New(), "")
+	assert.ErrorIs(t, err, repository.ErrQuoteNotFound)
+}
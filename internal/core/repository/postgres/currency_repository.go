@@ -0,0 +1,170 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/Nzyazin/itk/internal/core/retry"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const currencyColumns = `code, name, minor_unit_name, minor_units, is_fractional, is_active, created_at, updated_at`
+
+type postgresCurrencyRepo struct {
+	db          *sqlx.DB
+	log         logger.Logger
+	retryPolicy retry.Policy
+}
+
+// NewPostgresCurrencyRepo создаёт репозиторий валют; retryPolicy задаёт повторы транзакций
+// при конфликтах сериализации
+func NewPostgresCurrencyRepo(db *sqlx.DB, log logger.Logger, retryPolicy retry.Policy) repository.CurrencyRepository {
+	return &postgresCurrencyRepo{
+		db:          db,
+		log:         log,
+		retryPolicy: retryPolicy,
+	}
+}
+
+func (r *postgresCurrencyRepo) List(ctx context.Context) ([]models.Currency, error) {
+	currencies := []models.Currency{}
+	query := `SELECT ` + currencyColumns + ` FROM currencies ORDER BY code`
+	if err := r.db.SelectContext(ctx, &currencies, query); err != nil {
+		return nil, fmt.Errorf("error listing currencies: %w", err)
+	}
+	return currencies, nil
+}
+
+func (r *postgresCurrencyRepo) GetByCode(ctx context.Context, code string) (*models.Currency, error) {
+	var currency models.Currency
+	query := `SELECT ` + currencyColumns + ` FROM currencies WHERE code = $1`
+	if err := r.db.GetContext(ctx, &currency, query, code); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: currency with code %s", repository.ErrNotFound, code)
+		}
+		return nil, fmt.Errorf("error getting currency: %w", err)
+	}
+	return &currency, nil
+}
+
+func (r *postgresCurrencyRepo) Create(ctx context.Context, currency *models.Currency) error {
+	query := `INSERT INTO currencies (code, name, minor_unit_name, minor_units, is_active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + currencyColumns
+	err := r.db.GetContext(ctx, currency, query,
+		currency.Code, currency.Name, currency.MinorUnitName, currency.MinorUnits, currency.IsActive)
+	if err != nil {
+		var pgErr *pq.Error
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return fmt.Errorf("%w: currency with code %s", repository.ErrAlreadyExists, currency.Code)
+		}
+		return fmt.Errorf("error creating currency: %w", err)
+	}
+	return nil
+}
+
+// Update изменяет валюту в сериализуемой транзакции и повторяет её при конфликте сериализации.
+// При изменении точности кошельки в этой валюте блокируются, чтобы проверка не разошлась
+// с параллельными операциями.
+func (r *postgresCurrencyRepo) Update(ctx context.Context, currency *models.Currency) error {
+	err := r.retryPolicy.Do(ctx, "update_currency", isRetryable, func() error {
+		return r.update(ctx, currency)
+	})
+	if errors.Is(err, retry.ErrExhausted) {
+		return fmt.Errorf("%w: %w", repository.ErrRetriesExhausted, err)
+	}
+	return err
+}
+
+func (r *postgresCurrencyRepo) update(ctx context.Context, currency *models.Currency) error {
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var current models.Currency
+	query := `SELECT ` + currencyColumns + ` FROM currencies WHERE code = $1 FOR UPDATE`
+	if err := tx.GetContext(ctx, &current, query, currency.Code); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: currency with code %s", repository.ErrNotFound, currency.Code)
+		}
+		return fmt.Errorf("error getting currency: %w", err)
+	}
+
+	if current.MinorUnits != currency.MinorUnits {
+		// Суммы в истории операций и журнале хранятся в минимальных единицах, поэтому точность
+		// меняется, только пока по валюте не было ни одной операции. Строки кошельков блокируются
+		// по мере чтения: если операций нет, заблокированы все кошельки валюты, и параллельная
+		// операция дождётся фиксации новой точности.
+		var used bool
+		query = `SELECT EXISTS (
+				SELECT 1 FROM (
+					SELECT id, balance, held_balance FROM wallets WHERE currency_code = $1 FOR UPDATE
+				) w
+				WHERE w.balance <> 0 OR w.held_balance <> 0
+					OR EXISTS (SELECT 1 FROM transactions t WHERE t.wallet_id = w.id)
+			) OR EXISTS (SELECT 1 FROM postings WHERE currency_code = $1)`
+		if err := tx.GetContext(ctx, &used, query, currency.Code); err != nil {
+			return fmt.Errorf("check currency usage: %w", err)
+		}
+		if used {
+			return fmt.Errorf("%w: %s has balances or operation history, minor units cannot change",
+				repository.ErrCurrencyInUse, currency.Code)
+		}
+	}
+
+	query = `UPDATE currencies
+		SET name = $2, minor_unit_name = $3, minor_units = $4
+		WHERE code = $1
+		RETURNING ` + currencyColumns
+	if err := tx.GetContext(ctx, currency, query,
+		currency.Code, currency.Name, currency.MinorUnitName, currency.MinorUnits); err != nil {
+		return fmt.Errorf("error updating currency: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+	return nil
+}
+
+func (r *postgresCurrencyRepo) SetActive(ctx context.Context, code string, active bool) (*models.Currency, error) {
+	var currency models.Currency
+	query := `UPDATE currencies SET is_active = $2 WHERE code = $1 RETURNING ` + currencyColumns
+	if err := r.db.GetContext(ctx, &currency, query, code, active); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: currency with code %s", repository.ErrNotFound, code)
+		}
+		return nil, fmt.Errorf("error updating currency: %w", err)
+	}
+	return &currency, nil
+}
+
+// Delete удаляет валюту, в которой нет кошельков. Наличие кошельков проверяет внешний ключ
+// wallets.currency_code, поэтому проверка не разойдётся с одновременным созданием кошелька.
+func (r *postgresCurrencyRepo) Delete(ctx context.Context, code string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM currencies WHERE code = $1`, code)
+	if err != nil {
+		var pgErr *pq.Error
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return fmt.Errorf("%w: wallets in %s exist", repository.ErrCurrencyInUse, code)
+		}
+		return fmt.Errorf("error deleting currency: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error deleting currency: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: currency with code %s", repository.ErrNotFound, code)
+	}
+	return nil
+}
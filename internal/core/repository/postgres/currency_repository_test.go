@@ -0,0 +1,155 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/Nzyazin/itk/internal/core/repository/postgres"
+	"github.com/Nzyazin/itk/internal/core/retry"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createCurrency заводит валюту со случайным кодом, чтобы тесты не пересекались в общей базе
+func createCurrency(t *testing.T, repo repository.CurrencyRepository) *models.Currency {
+	t.Helper()
+	for {
+		code := make([]byte, 3)
+		for i := range code {
+			code[i] = byte('A' + rand.IntN(26))
+		}
+		currency := &models.Currency{Code: string(code), Name: "Test " + string(code), MinorUnits: 2, IsActive: true}
+		err := repo.Create(context.Background(), currency)
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			continue
+		}
+		require.NoError(t, err)
+		return currency
+	}
+}
+
+func changeMinorUnits(repo repository.CurrencyRepository, currency *models.Currency, minorUnits int64) error {
+	changed := *currency
+	changed.MinorUnits = minorUnits
+	return repo.Update(context.Background(), &changed)
+}
+
+func newCurrencyRepos(t *testing.T) (*sqlx.DB, repository.CurrencyRepository, repository.WalletRepository) {
+	t.Helper()
+	log, cleanup := logger.NewLogger()
+	t.Cleanup(cleanup)
+
+	db, teardown := setupTestDB(t, log)
+	t.Cleanup(teardown)
+
+	return db, postgres.NewPostgresCurrencyRepo(db, log, retry.DefaultPolicy()), postgres.NewPostgresWalletRepo(db, log)
+}
+
+func TestCurrencyMinorUnitsChange(t *testing.T) {
+	db, currencies, wallets := newCurrencyRepos(t)
+	ctx := context.Background()
+
+	t.Run("unused currency", func(t *testing.T) {
+		currency := createCurrency(t, currencies)
+		require.NoError(t, changeMinorUnits(currencies, currency, 3))
+
+		stored, err := currencies.GetByCode(ctx, currency.Code)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), stored.MinorUnits)
+	})
+
+	t.Run("wallets with zero balance and no history", func(t *testing.T) {
+		currency := createCurrency(t, currencies)
+		createWallet(t, db, currency.Code, 0)
+		assert.NoError(t, changeMinorUnits(currencies, currency, 0))
+	})
+
+	t.Run("wallet with balance", func(t *testing.T) {
+		currency := createCurrency(t, currencies)
+		createWallet(t, db, currency.Code, 100)
+		assert.ErrorIs(t, changeMinorUnits(currencies, currency, 3), repository.ErrCurrencyInUse)
+	})
+
+	// Нулевой баланс не означает отсутствия истории: суммы прошлых операций записаны
+	// в минимальных единицах прежней точности
+	t.Run("wallet with zero balance and history", func(t *testing.T) {
+		currency := createCurrency(t, currencies)
+		walletID := createWallet(t, db, currency.Code, 0)
+		for _, operationType := range []models.OperationType{models.OperationDeposit, models.OperationWithdraw} {
+			_, err := wallets.ExecuteTxWithRetry(ctx, repository.OperationParams{
+				WalletID: walletID, Amount: 500, OperationType: operationType,
+			})
+			require.NoError(t, err)
+		}
+
+		assert.ErrorIs(t, changeMinorUnits(currencies, currency, 3), repository.ErrCurrencyInUse)
+
+		stored, err := currencies.GetByCode(ctx, currency.Code)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), stored.MinorUnits)
+	})
+
+	t.Run("other fields of currency with history", func(t *testing.T) {
+		currency := createCurrency(t, currencies)
+		createWallet(t, db, currency.Code, 100)
+
+		renamed := *currency
+		renamed.Name = "Renamed"
+		require.NoError(t, currencies.Update(ctx, &renamed))
+		assert.Equal(t, "Renamed", renamed.Name)
+	})
+}
+
+func TestCurrencyDelete(t *testing.T) {
+	db, currencies, _ := newCurrencyRepos(t)
+	ctx := context.Background()
+
+	unused := createCurrency(t, currencies)
+	require.NoError(t, currencies.Delete(ctx, unused.Code))
+	_, err := currencies.GetByCode(ctx, unused.Code)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	assert.ErrorIs(t, currencies.Delete(ctx, unused.Code), repository.ErrNotFound)
+
+	used := createCurrency(t, currencies)
+	createWallet(t, db, used.Code, 0)
+	assert.ErrorIs(t, currencies.Delete(ctx, used.Code), repository.ErrCurrencyInUse)
+	_, err = currencies.GetByCode(ctx, used.Code)
+	assert.NoError(t, err)
+}
+
+// Кошелёк, созданный в транзакции, ещё не видной удалению, удерживает валюту внешним ключом
+func TestCurrencyDeleteWaitsForConcurrentWallet(t *testing.T) {
+	db, currencies, _ := newCurrencyRepos(t)
+	ctx := context.Background()
+
+	currency := createCurrency(t, currencies)
+
+	tx, err := db.Beginx()
+	require.NoError(t, err)
+	defer tx.Rollback()
+	_, err = tx.Exec(`INSERT INTO wallets (id, balance, currency_code) VALUES ($1, 0, $2)`, uuid.New(), currency.Code)
+	require.NoError(t, err)
+
+	deleted := make(chan error, 1)
+	go func() { deleted <- currencies.Delete(ctx, currency.Code) }()
+
+	require.NoError(t, tx.Commit())
+	assert.ErrorIs(t, <-deleted, repository.ErrCurrencyInUse)
+}
+
+func TestWalletRequiresKnownCurrency(t *testing.T) {
+	db, _, _ := newCurrencyRepos(t)
+
+	_, err := db.Exec(`INSERT INTO wallets (id, balance, currency_code) VALUES ($1, 0, '000')`, uuid.New())
+	var pgErr *pq.Error
+	require.ErrorAs(t, err, &pgErr)
+	assert.Equal(t, pq.ErrorCode("23503"), pgErr.Code)
+}
@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+)
+
+// inverseRatePrecision - число знаков при обращении курса, заданного для обратной пары
+const inverseRatePrecision = 12
+
+type postgresExchangeRateRepo struct {
+	db  *sqlx.DB
+	log logger.Logger
+}
+
+// NewPostgresExchangeRateRepo возвращает провайдер курсов, хранящихся в таблице exchange_rates
+func NewPostgresExchangeRateRepo(db *sqlx.DB, log logger.Logger) repository.ExchangeRateRepository {
+	return &postgresExchangeRateRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// GetRate возвращает курс пары, действующий в момент at. При нескольких подходящих
+// курсах выбирается начавший действовать последним; прямая пара предпочтительнее обратной.
+func (r *postgresExchangeRateRepo) GetRate(ctx context.Context, base, quote string, at time.Time) (*models.ExchangeRate, error) {
+	var rate models.ExchangeRate
+	query := `SELECT base_currency, quote_currency, rate, valid_from, valid_to
+		FROM exchange_rates
+		WHERE ((base_currency = $1 AND quote_currency = $2) OR (base_currency = $2 AND quote_currency = $1))
+			AND valid_from <= $3 AND (valid_to IS NULL OR valid_to > $3)
+		ORDER BY base_currency = $1 DESC, valid_from DESC
+		LIMIT 1`
+	if err := r.db.GetContext(ctx, &rate, query, base, quote, at); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s/%s at %s", repository.ErrRateNotFound, base, quote, at.Format(time.RFC3339))
+		}
+		return nil, fmt.Errorf("error getting exchange rate: %w", err)
+	}
+
+	if rate.BaseCurrency != base {
+		rate.BaseCurrency, rate.QuoteCurrency = base, quote
+		rate.Rate = decimal.NewFromInt(1).DivRound(rate.Rate, inverseRatePrecision)
+	}
+	return &rate, nil
+}
+
+func (r *postgresExchangeRateRepo) AddRate(ctx context.Context, rate *models.ExchangeRate) error {
+	query := `INSERT INTO exchange_rates (base_currency, quote_currency, rate, valid_from, valid_to)
+		VALUES ($1, $2, $3, $4, $5)`
+	if _, err := r.db.ExecContext(ctx, query,
+		rate.BaseCurrency, rate.QuoteCurrency, rate.Rate, rate.ValidFrom, rate.ValidTo); err != nil {
+		return fmt.Errorf("error adding exchange rate: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const holdColumns = `id, wallet_id, amount, captured_amount, status, expires_at, created_at, updated_at`
+
+type lockedHold struct {
+	models.Hold
+	IsExpired bool `db:"is_expired"`
+}
+
+func (r *postgresWalletRepo) GetHold(ctx context.Context, id uuid.UUID) (*models.Hold, error) {
+	var hold models.Hold
+	query := `SELECT ` + holdColumns + ` FROM holds WHERE id = $1`
+	if err := r.db.GetContext(ctx, &hold, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", repository.ErrHoldNotFound, id)
+		}
+		return nil, fmt.Errorf("error getting hold: %w", err)
+	}
+
+	return &hold, nil
+}
+
+// AuthorizeHoldWithRetry резервирует средства: доступный баланс уменьшается, учётный не меняется
+func (r *postgresWalletRepo) AuthorizeHoldWithRetry(ctx context.Context, hold *models.Hold, actor string) error {
+	return r.withRetry(ctx, "authorize_hold", func() error {
+		return r.inTx(ctx, func(tx *sqlx.Tx) error {
+			if _, err := r.expireHolds(ctx, tx, &hold.WalletID, 0); err != nil {
+				return err
+			}
+
+			state, err := r.adjustBalance(ctx, tx, hold.WalletID, 0, hold.Amount)
+			if err != nil {
+				return err
+			}
+			if !state.canDebit() {
+				return ErrInsufficientFunds
+			}
+
+			hold.Status = models.HoldActive
+			hold.CapturedAmount = 0
+			query := `INSERT INTO holds (id, wallet_id, amount, status, expires_at)
+				VALUES ($1, $2, $3, $4, $5)
+				RETURNING created_at, updated_at`
+			err = tx.QueryRowxContext(ctx, query, hold.ID, hold.WalletID, hold.Amount, hold.Status, hold.ExpiresAt).
+				Scan(&hold.CreatedAt, &hold.UpdatedAt)
+			if err != nil {
+				return fmt.Errorf("create hold: %w", err)
+			}
+
+			return r.createTransaction(ctx, tx, &models.Transaction{
+				ID:            uuid.New(),
+				WalletID:      hold.WalletID,
+				OperationType: models.OperationHold,
+				Amount:        hold.Amount,
+				Status:        models.TransactionStatusPending,
+				HoldID:        &hold.ID,
+				Actor:         actorValue(actor),
+			})
+		})
+	})
+}
+
+// CaptureHoldWithRetry списывает amount из резерва (0 - весь резерв) и освобождает остаток
+func (r *postgresWalletRepo) CaptureHoldWithRetry(ctx context.Context, holdID uuid.UUID, amount int64, actor string) (*models.Hold, error) {
+	var hold *models.Hold
+	err := r.withRetry(ctx, "capture_hold", func() error {
+		return r.inTx(ctx, func(tx *sqlx.Tx) error {
+			var err error
+			hold, err = r.lockActiveHold(ctx, tx, holdID)
+			if err != nil {
+				return err
+			}
+
+			captured := amount
+			if captured == 0 {
+				captured = hold.Amount
+			}
+			if captured > hold.Amount {
+				return fmt.Errorf("%w: requested %d, held %d", repository.ErrCaptureExceedsHold, captured, hold.Amount)
+			}
+
+			state, err := r.adjustBalance(ctx, tx, hold.WalletID, -captured, -hold.Amount)
+			if err != nil {
+				return err
+			}
+			if err := r.checkLimits(ctx, tx, hold.WalletID, state.CurrencyCode, models.OperationCapture, captured); err != nil {
+				return err
+			}
+
+			holdTxID, err := r.finishHold(ctx, tx, hold, models.HoldCaptured, captured, models.TransactionStatusCaptured)
+			if err != nil {
+				return err
+			}
+
+			captureID := uuid.New()
+			if err := r.createTransaction(ctx, tx, &models.Transaction{
+				ID:                   captureID,
+				WalletID:             hold.WalletID,
+				OperationType:        models.OperationCapture,
+				Amount:               captured,
+				Status:               transactionStatusCompleted,
+				RelatedTransactionID: holdTxID,
+				HoldID:               &hold.ID,
+				Actor:                actorValue(actor),
+			}); err != nil {
+				return err
+			}
+
+			return r.postAgainstClearing(ctx, tx, models.OperationCapture, captureID, hold.WalletID,
+				state.CurrencyCode, -captured)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// VoidHoldWithRetry отменяет резерв и возвращает средства в доступный баланс
+func (r *postgresWalletRepo) VoidHoldWithRetry(ctx context.Context, holdID uuid.UUID) (*models.Hold, error) {
+	var hold *models.Hold
+	err := r.withRetry(ctx, "void_hold", func() error {
+		return r.inTx(ctx, func(tx *sqlx.Tx) error {
+			var err error
+			hold, err = r.lockActiveHold(ctx, tx, holdID)
+			if err != nil {
+				return err
+			}
+
+			if _, err := r.adjustBalance(ctx, tx, hold.WalletID, 0, -hold.Amount); err != nil {
+				return err
+			}
+
+			holdTxID, err := r.finishHold(ctx, tx, hold, models.HoldVoided, 0, models.TransactionStatusVoided)
+			if err != nil {
+				return err
+			}
+			return r.recordTransactionEvents(ctx, tx, models.EventWalletFundsReleased, []uuid.UUID{*holdTxID})
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// ExpireHolds освобождает не более limit истёкших резервов и возвращает их количество
+func (r *postgresWalletRepo) ExpireHolds(ctx context.Context, limit int) (int, error) {
+	var expired int
+	err := r.withRetry(ctx, "expire_holds", func() error {
+		return r.inTx(ctx, func(tx *sqlx.Tx) error {
+			var err error
+			expired, err = r.expireHolds(ctx, tx, nil, limit)
+			return err
+		})
+	})
+	return expired, err
+}
+
+// lockActiveHold блокирует резерв и проверяет, что его ещё можно списать или отменить
+func (r *postgresWalletRepo) lockActiveHold(ctx context.Context, tx *sqlx.Tx, holdID uuid.UUID) (*models.Hold, error) {
+	var hold lockedHold
+	query := `SELECT ` + holdColumns + `, expires_at <= CURRENT_TIMESTAMP AS is_expired
+		FROM holds WHERE id = $1 FOR UPDATE`
+	if err := tx.GetContext(ctx, &hold, query, holdID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", repository.ErrHoldNotFound, holdID)
+		}
+		return nil, fmt.Errorf("lock hold: %w", err)
+	}
+
+	if hold.Status != models.HoldActive {
+		return nil, fmt.Errorf("%w: hold %s is %s", repository.ErrHoldNotActive, holdID, hold.Status)
+	}
+	if hold.IsExpired {
+		return nil, fmt.Errorf("%w: %s", repository.ErrHoldExpired, holdID)
+	}
+
+	return &hold.Hold, nil
+}
+
+// finishHold переводит резерв и связанную с ним запись HOLD в конечный статус
+// и возвращает идентификатор записи HOLD
+func (r *postgresWalletRepo) finishHold(ctx context.Context, tx *sqlx.Tx, hold *models.Hold, status models.HoldStatus, captured int64, txStatus string) (*uuid.UUID, error) {
+	query := `UPDATE holds SET status = $1, captured_amount = $2 WHERE id = $3 RETURNING updated_at`
+	if err := tx.QueryRowxContext(ctx, query, status, captured, hold.ID).Scan(&hold.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("update hold: %w", err)
+	}
+	hold.Status = status
+	hold.CapturedAmount = captured
+
+	var holdTxID uuid.UUID
+	query = `UPDATE transactions SET status = $1
+		WHERE hold_id = $2 AND operation_type = $3
+		RETURNING id`
+	if err := tx.GetContext(ctx, &holdTxID, query, txStatus, hold.ID, models.OperationHold); err != nil {
+		return nil, fmt.Errorf("update hold transaction: %w", err)
+	}
+
+	return &holdTxID, nil
+}
+
+// expireHolds освобождает истёкшие резервы (всех кошельков или только walletID) и записывает
+// событие об освобождении каждого из них. limit <= 0 снимает ограничение на количество.
+func (r *postgresWalletRepo) expireHolds(ctx context.Context, tx *sqlx.Tx, walletID *uuid.UUID, limit int) (int, error) {
+	var limitArg interface{}
+	if limit > 0 {
+		limitArg = limit
+	}
+
+	query := `
+		WITH expired AS (
+			UPDATE holds SET status = 'EXPIRED'
+			WHERE id IN (
+				SELECT id FROM holds
+				WHERE status = 'ACTIVE'
+					AND expires_at <= CURRENT_TIMESTAMP
+					AND ($1::uuid IS NULL OR wallet_id = $1)
+				ORDER BY expires_at
+				LIMIT $2
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, wallet_id, amount
+		), released AS (
+			UPDATE wallets w
+			SET held_balance = w.held_balance - e.total
+			FROM (SELECT wallet_id, SUM(amount) AS total FROM expired GROUP BY wallet_id) e
+			WHERE w.id = e.wallet_id
+		), hold_transactions AS (
+			UPDATE transactions t
+			SET status = 'EXPIRED'
+			FROM expired e
+			WHERE t.hold_id = e.id AND t.operation_type = 'HOLD'
+			RETURNING t.id
+		)
+		SELECT id FROM hold_transactions`
+
+	var holdTxIDs []uuid.UUID
+	if err := tx.SelectContext(ctx, &holdTxIDs, query, walletID, limitArg); err != nil {
+		return 0, fmt.Errorf("expire holds: %w", err)
+	}
+
+	// Событие записывается отдельным запросом: в запросе выше изменения кошельков ещё не видны
+	if err := r.recordTransactionEvents(ctx, tx, models.EventWalletFundsReleased, holdTxIDs); err != nil {
+		return 0, err
+	}
+	return len(holdTxIDs), nil
+}
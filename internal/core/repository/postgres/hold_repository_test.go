@@ -0,0 +1,236 @@
+package postgres_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/Nzyazin/itk/internal/core/repository/postgres"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type walletEvent struct {
+	Type    models.EventType `db:"event_type"`
+	Payload json.RawMessage  `db:"payload"`
+}
+
+func walletEvents(t *testing.T, db *sqlx.DB, walletID uuid.UUID) []walletEvent {
+	t.Helper()
+	var events []walletEvent
+	require.NoError(t, db.Select(&events,
+		`SELECT event_type, payload FROM outbox_events WHERE wallet_id = $1 ORDER BY id`, walletID))
+	return events
+}
+
+// expireHold переносит срок резерва в прошлое
+func expireHold(t *testing.T, db *sqlx.DB, holdID uuid.UUID) {
+	t.Helper()
+	_, err := db.Exec(`UPDATE holds SET expires_at = CURRENT_TIMESTAMP - INTERVAL '1 second' WHERE id = $1`, holdID)
+	require.NoError(t, err)
+}
+
+func authorizeHold(t *testing.T, repo repository.WalletRepository, walletID uuid.UUID, amount int64) *models.Hold {
+	t.Helper()
+	hold := &models.Hold{ID: uuid.New(), WalletID: walletID, Amount: amount, ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, repo.AuthorizeHoldWithRetry(context.Background(), hold, ""))
+	return hold
+}
+
+func TestHoldReleaseRecordsEvents(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	db, teardown := setupTestDB(t, log)
+	defer teardown()
+
+	repo := postgres.NewPostgresWalletRepo(db, log)
+	ctx := context.Background()
+
+	voidedWallet, expiredWallet := createWallet(t, db, "USD", 1000), createWallet(t, db, "USD", 1000)
+
+	voided := authorizeHold(t, repo, voidedWallet, 300)
+	_, err := repo.VoidHoldWithRetry(ctx, voided.ID)
+	require.NoError(t, err)
+
+	expired := authorizeHold(t, repo, expiredWallet, 400)
+	expireHold(t, db, expired.ID)
+	count, err := repo.ExpireHolds(ctx, 0)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, count, 1)
+
+	for walletID, status := range map[uuid.UUID]string{voidedWallet: "VOIDED", expiredWallet: "EXPIRED"} {
+		events := walletEvents(t, db, walletID)
+		require.Len(t, events, 2, "hold and release of wallet %s", walletID)
+		assert.Equal(t, models.EventWalletFundsHeld, events[0].Type)
+		assert.Equal(t, models.EventWalletFundsReleased, events[1].Type)
+
+		var payload struct {
+			OperationType models.OperationType `json:"operation_type"`
+			Status        string               `json:"status"`
+			Balance       int64                `json:"balance"`
+			HeldBalance   int64                `json:"held_balance"`
+		}
+		require.NoError(t, json.Unmarshal(events[1].Payload, &payload))
+		assert.Equal(t, models.OperationHold, payload.OperationType)
+		assert.Equal(t, status, payload.Status)
+		assert.Equal(t, int64(1000), payload.Balance)
+		assert.Zero(t, payload.HeldBalance, "event must carry the held balance after release")
+	}
+}
+
+func TestCaptureHold(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	db, teardown := setupTestDB(t, log)
+	defer teardown()
+
+	repo := postgres.NewPostgresWalletRepo(db, log)
+	ctx := context.Background()
+
+	walletID := createWallet(t, db, "USD", 1000)
+	hold := authorizeHold(t, repo, walletID, 500)
+
+	wallet, err := repo.GetByID(ctx, walletID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), wallet.Balance)
+	assert.Equal(t, int64(500), wallet.HeldBalance)
+
+	_, err = repo.CaptureHoldWithRetry(ctx, hold.ID, 600, "")
+	assert.ErrorIs(t, err, repository.ErrCaptureExceedsHold)
+
+	// Частичное списание освобождает остаток резерва
+	captured, err := repo.CaptureHoldWithRetry(ctx, hold.ID, 300, "")
+	require.NoError(t, err)
+	assert.Equal(t, models.HoldCaptured, captured.Status)
+	assert.Equal(t, int64(300), captured.CapturedAmount)
+
+	wallet, err = repo.GetByID(ctx, walletID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(700), wallet.Balance)
+	assert.Zero(t, wallet.HeldBalance)
+
+	_, err = repo.CaptureHoldWithRetry(ctx, hold.ID, 0, "")
+	assert.ErrorIs(t, err, repository.ErrHoldNotActive)
+}
+
+func TestCaptureExpiredHold(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	db, teardown := setupTestDB(t, log)
+	defer teardown()
+
+	repo := postgres.NewPostgresWalletRepo(db, log)
+	ctx := context.Background()
+
+	walletID := createWallet(t, db, "USD", 1000)
+	hold := authorizeHold(t, repo, walletID, 500)
+	expireHold(t, db, hold.ID)
+
+	_, err := repo.CaptureHoldWithRetry(ctx, hold.ID, 0, "")
+	assert.ErrorIs(t, err, repository.ErrHoldExpired)
+
+	wallet, err := repo.GetByID(ctx, walletID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), wallet.Balance)
+}
+
+func TestVoidHoldTwice(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	db, teardown := setupTestDB(t, log)
+	defer teardown()
+
+	repo := postgres.NewPostgresWalletRepo(db, log)
+	ctx := context.Background()
+
+	walletID := createWallet(t, db, "USD", 1000)
+	hold := authorizeHold(t, repo, walletID, 500)
+
+	voided, err := repo.VoidHoldWithRetry(ctx, hold.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.HoldVoided, voided.Status)
+
+	_, err = repo.VoidHoldWithRetry(ctx, hold.ID)
+	assert.ErrorIs(t, err, repository.ErrHoldNotActive)
+
+	wallet, err := repo.GetByID(ctx, walletID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), wallet.Balance)
+	assert.Zero(t, wallet.HeldBalance, "second void must not release the hold again")
+}
+
+func TestExpiredHoldDoesNotReduceAvailableBalance(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	db, teardown := setupTestDB(t, log)
+	defer teardown()
+
+	repo := postgres.NewPostgresWalletRepo(db, log)
+
+	walletID := createWallet(t, db, "USD", 1000)
+	expireHold(t, db, authorizeHold(t, repo, walletID, 800).ID)
+
+	// Истёкший, но ещё не освобождённый резерв не мешает новому резервированию
+	authorizeHold(t, repo, walletID, 600)
+
+	wallet, err := repo.GetByID(context.Background(), walletID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(600), wallet.HeldBalance)
+}
+
+func TestExpireHoldsSkipsLockedHolds(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	db, teardown := setupTestDB(t, log)
+	defer teardown()
+
+	repo := postgres.NewPostgresWalletRepo(db, log)
+	ctx := context.Background()
+
+	lockedWallet, freeWallet := createWallet(t, db, "USD", 1000), createWallet(t, db, "USD", 1000)
+	locked, free := authorizeHold(t, repo, lockedWallet, 300), authorizeHold(t, repo, freeWallet, 400)
+	expireHold(t, db, locked.ID)
+	expireHold(t, db, free.ID)
+
+	// Резерв, заблокированный другой транзакцией, пропускается, а не ожидается
+	lockTx, err := db.Beginx()
+	require.NoError(t, err)
+	defer lockTx.Rollback()
+	_, err = lockTx.Exec(`SELECT id FROM holds WHERE id = $1 FOR UPDATE`, locked.ID)
+	require.NoError(t, err)
+
+	_, err = repo.ExpireHolds(ctx, 0)
+	require.NoError(t, err)
+
+	holdStatus := func(id uuid.UUID) models.HoldStatus {
+		hold, err := repo.GetHold(ctx, id)
+		require.NoError(t, err)
+		return hold.Status
+	}
+	assert.Equal(t, models.HoldExpired, holdStatus(free.ID))
+	assert.Equal(t, models.HoldActive, holdStatus(locked.ID))
+
+	require.NoError(t, lockTx.Rollback())
+	_, err = repo.ExpireHolds(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, models.HoldExpired, holdStatus(locked.ID))
+
+	for _, walletID := range []uuid.UUID{lockedWallet, freeWallet} {
+		wallet, err := repo.GetByID(ctx, walletID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1000), wallet.Balance)
+		assert.Zero(t, wallet.HeldBalance)
+	}
+}
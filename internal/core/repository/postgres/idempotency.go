@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// idempotencyKeyConstraint - уникальность ключа в пределах кошелька
+const idempotencyKeyConstraint = "idempotency_keys_pkey"
+
+type idempotentResult struct {
+	RequestHash     string `db:"request_hash"`
+	ResponseBalance int64  `db:"response_balance"`
+}
+
+// loadIdempotentResult возвращает баланс, сохранённый при первом выполнении операции с тем же ключом
+// для того же кошелька. Если ключ уже использовался для другого запроса, возвращается ErrIdempotencyConflict.
+func (r *postgresWalletRepo) loadIdempotentResult(ctx context.Context, tx *sqlx.Tx, params repository.OperationParams) (*int64, error) {
+	if params.IdempotencyKey == "" {
+		return nil, nil
+	}
+
+	var result idempotentResult
+	query := `SELECT request_hash, response_balance FROM idempotency_keys WHERE wallet_id = $1 AND key = $2`
+	err := tx.GetContext(ctx, &result, query, params.WalletID, params.IdempotencyKey)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("load idempotency key: %w", err)
+	}
+
+	if result.RequestHash != params.RequestHash {
+		return nil, fmt.Errorf("%w: %s", repository.ErrIdempotencyConflict, params.IdempotencyKey)
+	}
+
+	return &result.ResponseBalance, nil
+}
+
+// saveIdempotentResult сохраняет результат операции в той же транзакции, что и саму операцию
+func (r *postgresWalletRepo) saveIdempotentResult(ctx context.Context, tx *sqlx.Tx, params repository.OperationParams, transactionID uuid.UUID, balance int64) error {
+	if params.IdempotencyKey == "" {
+		return nil
+	}
+
+	query := `INSERT INTO idempotency_keys (key, request_hash, wallet_id, transaction_id, response_balance)
+		VALUES ($1, $2, $3, $4, $5)`
+	_, err := tx.ExecContext(ctx, query,
+		params.IdempotencyKey, params.RequestHash, params.WalletID, transactionID, balance)
+	if err != nil {
+		return fmt.Errorf("save idempotency key: %w", err)
+	}
+
+	return nil
+}
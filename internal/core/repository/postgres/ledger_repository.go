@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// systemAccountID возвращает идентификатор служебного счёта, создавая его при первом обращении.
+// Счёт создаётся вне текущей транзакции, поэтому закэшированный идентификатор не может
+// указывать на строку, исчезнувшую при откате.
+func (r *postgresWalletRepo) systemAccountID(ctx context.Context, kind, currencyCode string) (uuid.UUID, error) {
+	code := models.SystemAccountCode(kind, currencyCode)
+	if id, ok := r.systemAccounts.Load(code); ok {
+		return id.(uuid.UUID), nil
+	}
+
+	insert := `INSERT INTO ledger_accounts (id, code, account_type, currency_code)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (code) DO NOTHING`
+	if _, err := r.db.ExecContext(ctx, insert, uuid.New(), code, models.LedgerAccountSystem, currencyCode); err != nil {
+		return uuid.Nil, fmt.Errorf("create system account %s: %w", code, err)
+	}
+
+	var id uuid.UUID
+	if err := r.db.GetContext(ctx, &id, `SELECT id FROM ledger_accounts WHERE code = $1`, code); err != nil {
+		return uuid.Nil, fmt.Errorf("get system account %s: %w", code, err)
+	}
+
+	r.systemAccounts.Store(code, id)
+	return id, nil
+}
+
+// postEntry записывает в журнал сбалансированную запись, связанную с операцией.
+// Баланс проверяется отложенным триггером при фиксации транзакции.
+func (r *postgresWalletRepo) postEntry(ctx context.Context, tx *sqlx.Tx, entryType models.OperationType, transactionID uuid.UUID, currencyCode string, postings ...models.Posting) error {
+	entryID := uuid.New()
+	query := `INSERT INTO journal_entries (id, entry_type, transaction_id) VALUES ($1, $2, $3)`
+	if _, err := tx.ExecContext(ctx, query, entryID, entryType, transactionID); err != nil {
+		return fmt.Errorf("create journal entry: %w", err)
+	}
+
+	values := make([]string, 0, len(postings))
+	args := make([]interface{}, 0, len(postings)*2+2)
+	args = append(args, entryID, currencyCode)
+	for _, p := range postings {
+		args = append(args, p.AccountID, p.Amount)
+		values = append(values, fmt.Sprintf("($1, $%d, $%d, $2)", len(args)-1, len(args)))
+	}
+
+	query = `INSERT INTO postings (entry_id, account_id, amount, currency_code) VALUES ` + strings.Join(values, ", ")
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("create postings: %w", err)
+	}
+
+	return nil
+}
+
+// postAgainstClearing проводит изменение баланса кошелька на delta встречной проводкой
+// по клиринговому счёту валюты: так выражаются пополнения, снятия и возвраты
+func (r *postgresWalletRepo) postAgainstClearing(ctx context.Context, tx *sqlx.Tx, entryType models.OperationType, transactionID, walletID uuid.UUID, currencyCode string, delta int64) error {
+	return r.postAgainstSystem(ctx, tx, models.SystemAccountClearing, entryType, transactionID, walletID, currencyCode, delta)
+}
+
+// postAgainstSystem проводит изменение баланса кошелька на delta встречной проводкой по служебному счёту вида kind
+func (r *postgresWalletRepo) postAgainstSystem(ctx context.Context, tx *sqlx.Tx, kind string, entryType models.OperationType, transactionID, walletID uuid.UUID, currencyCode string, delta int64) error {
+	systemID, err := r.systemAccountID(ctx, kind, currencyCode)
+	if err != nil {
+		return err
+	}
+
+	return r.postEntry(ctx, tx, entryType, transactionID, currencyCode,
+		models.Posting{AccountID: walletID, Amount: delta},
+		models.Posting{AccountID: systemID, Amount: -delta},
+	)
+}
+
+// GetLedgerBalance сопоставляет баланс кошелька с суммой проводок по его счёту
+func (r *postgresWalletRepo) GetLedgerBalance(ctx context.Context, walletID uuid.UUID) (*models.WalletLedgerBalance, error) {
+	var balance models.WalletLedgerBalance
+	query := `SELECT w.id AS wallet_id, w.currency_code, w.balance,
+			COALESCE((SELECT SUM(p.amount) FROM postings p WHERE p.account_id = w.id), 0) AS ledger_balance
+		FROM wallets w
+		WHERE w.id = $1`
+	if err := r.db.GetContext(ctx, &balance, query, walletID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: wallet with id %s", repository.ErrNotFound, walletID)
+		}
+		return nil, fmt.Errorf("error getting ledger balance: %w", err)
+	}
+
+	return &balance, nil
+}
+
+// ReconcileLedger находит кошельки, баланс которых расходится с журналом,
+// и считает сумму всех проводок по валютам
+func (r *postgresWalletRepo) ReconcileLedger(ctx context.Context) (*models.LedgerReconciliation, error) {
+	reconciliation := &models.LedgerReconciliation{
+		Mismatches:    []models.WalletLedgerBalance{},
+		TrialBalances: []models.TrialBalance{},
+	}
+
+	query := `SELECT w.id AS wallet_id, w.currency_code, w.balance, COALESCE(l.total, 0) AS ledger_balance
+		FROM wallets w
+		LEFT JOIN (SELECT account_id, SUM(amount) AS total FROM postings GROUP BY account_id) l
+			ON l.account_id = w.id
+		WHERE w.balance <> COALESCE(l.total, 0)
+		ORDER BY w.id`
+	if err := r.db.SelectContext(ctx, &reconciliation.Mismatches, query); err != nil {
+		return nil, fmt.Errorf("find ledger mismatches: %w", err)
+	}
+
+	query = `SELECT currency_code, SUM(amount) AS total FROM postings GROUP BY currency_code ORDER BY currency_code`
+	if err := r.db.SelectContext(ctx, &reconciliation.TrialBalances, query); err != nil {
+		return nil, fmt.Errorf("compute trial balance: %w", err)
+	}
+
+	return reconciliation, nil
+}
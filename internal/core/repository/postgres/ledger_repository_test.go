@@ -0,0 +1,128 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/Nzyazin/itk/internal/core/repository/postgres"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createWallet создаёт кошелёк с начальным балансом; ненулевой баланс проводится входящим остатком
+func createWallet(t *testing.T, db *sqlx.DB, currency string, balance int64) uuid.UUID {
+	t.Helper()
+	id := uuid.New()
+	_, err := db.Exec(`INSERT INTO wallets (id, balance, currency_code) VALUES ($1, $2, $3)`, id, balance, currency)
+	require.NoError(t, err)
+	return id
+}
+
+func TestLedgerPostingsBalancePerTransaction(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	db, teardown := setupTestDB(t, log)
+	defer teardown()
+
+	repo := postgres.NewPostgresWalletRepo(db, log)
+	ctx := context.Background()
+
+	first, second := createWallet(t, db, "USD", 0), createWallet(t, db, "USD", 0)
+	operations := []repository.OperationParams{
+		{WalletID: first, Amount: 1000, OperationType: models.OperationDeposit},
+		{WalletID: first, Amount: 300, OperationType: models.OperationWithdraw},
+	}
+	for _, params := range operations {
+		_, err := repo.ExecuteTxWithRetry(ctx, params)
+		require.NoError(t, err)
+	}
+	_, err := repo.TransferTxWithRetry(ctx, repository.OperationParams{
+		WalletID: first, TargetWalletID: second, Amount: 200,
+	})
+	require.NoError(t, err)
+
+	var entries []struct {
+		TransactionID uuid.UUID `db:"transaction_id"`
+		Postings      int       `db:"postings"`
+		Total         int64     `db:"total"`
+	}
+	err = db.Select(&entries, `SELECT je.transaction_id, COUNT(*) AS postings, SUM(p.amount) AS total
+		FROM journal_entries je
+		JOIN postings p ON p.entry_id = je.id
+		WHERE je.transaction_id IN (SELECT id FROM transactions WHERE wallet_id = ANY($1))
+		GROUP BY je.id, je.transaction_id`, pq.Array([]uuid.UUID{first, second}))
+	require.NoError(t, err)
+	require.Len(t, entries, 3, "one journal entry per operation")
+	for _, entry := range entries {
+		assert.Equal(t, 2, entry.Postings, "transaction %s", entry.TransactionID)
+		assert.Zero(t, entry.Total, "postings of transaction %s must sum to zero", entry.TransactionID)
+	}
+
+	for id, want := range map[uuid.UUID]int64{first: 500, second: 200} {
+		balance, err := repo.GetLedgerBalance(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, want, balance.Balance)
+		assert.True(t, balance.IsConsistent(), "wallet %s: balance %d, ledger %d", id, balance.Balance, balance.LedgerBalance)
+	}
+}
+
+func TestWalletBalanceChangeWithoutPostingsRejected(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	db, teardown := setupTestDB(t, log)
+	defer teardown()
+
+	walletID := createWallet(t, db, "USD", 100)
+
+	_, err := db.Exec(`UPDATE wallets SET balance = balance + 5 WHERE id = $1`, walletID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not match ledger balance")
+
+	var balance int64
+	require.NoError(t, db.Get(&balance, `SELECT balance FROM wallets WHERE id = $1`, walletID))
+	assert.Equal(t, int64(100), balance)
+}
+
+func TestReconcileLedgerFlagsDriftedWallet(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	db, teardown := setupTestDB(t, log)
+	defer teardown()
+
+	repo := postgres.NewPostgresWalletRepo(db, log)
+	ctx := context.Background()
+
+	consistent, drifted := createWallet(t, db, "USD", 100), createWallet(t, db, "USD", 100)
+
+	// Расхождение можно получить, только отключив триггеры журнала: так сверка проверяется
+	// на случай правки базы в обход сервиса
+	tx, err := db.Beginx()
+	require.NoError(t, err)
+	_, err = tx.Exec(`SET LOCAL session_replication_role = replica`)
+	require.NoError(t, err)
+	_, err = tx.Exec(`UPDATE wallets SET balance = balance + 5 WHERE id = $1`, drifted)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	reconciliation, err := repo.ReconcileLedger(ctx)
+	require.NoError(t, err)
+	assert.False(t, reconciliation.IsBalanced())
+
+	mismatches := make(map[uuid.UUID]models.WalletLedgerBalance)
+	for _, mismatch := range reconciliation.Mismatches {
+		mismatches[mismatch.WalletID] = mismatch
+	}
+	require.Contains(t, mismatches, drifted)
+	assert.Equal(t, int64(105), mismatches[drifted].Balance)
+	assert.Equal(t, int64(100), mismatches[drifted].LedgerBalance)
+	assert.NotContains(t, mismatches, consistent)
+}
@@ -0,0 +1,174 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// debitOperationTypes - операции, которые учитываются в лимитах списаний. Возврат пополнения
+// (REVERSAL_DEBIT) исправляет ошибочное зачисление по решению оператора и лимиты клиента не расходует.
+var debitOperationTypes = []string{
+	string(models.OperationWithdraw),
+	string(models.OperationTransferOut),
+	string(models.OperationCapture),
+	string(models.OperationConversionOut),
+}
+
+const limitColumns = `wallet_id, currency_code, max_withdrawal, daily_withdrawal, monthly_withdrawal, hourly_deposits, updated_at`
+
+func (r *postgresWalletRepo) GetSpendingLimits(ctx context.Context, scope models.LimitScope) (*models.SpendingLimits, error) {
+	column, value, err := limitScopeColumn(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var limits models.SpendingLimits
+	query := `SELECT ` + limitColumns + ` FROM spending_limits WHERE ` + column + ` = $1`
+	if err := r.db.GetContext(ctx, &limits, query, value); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &models.SpendingLimits{LimitScope: scope}, nil
+		}
+		return nil, fmt.Errorf("get spending limits: %w", err)
+	}
+	return &limits, nil
+}
+
+// SetSpendingLimits заменяет все лимиты области целиком
+func (r *postgresWalletRepo) SetSpendingLimits(ctx context.Context, limits *models.SpendingLimits) error {
+	column, value, err := limitScopeColumn(limits.LimitScope)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO spending_limits
+        (` + column + `, max_withdrawal, daily_withdrawal, monthly_withdrawal, hourly_deposits)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (` + column + `) WHERE ` + column + ` IS NOT NULL DO UPDATE SET
+            max_withdrawal = EXCLUDED.max_withdrawal,
+            daily_withdrawal = EXCLUDED.daily_withdrawal,
+            monthly_withdrawal = EXCLUDED.monthly_withdrawal,
+            hourly_deposits = EXCLUDED.hourly_deposits,
+            updated_at = CURRENT_TIMESTAMP
+        RETURNING updated_at`
+	err = r.db.QueryRowxContext(ctx, query, value,
+		limits.MaxWithdrawal, limits.DailyWithdrawal, limits.MonthlyWithdrawal, limits.HourlyDeposits,
+	).Scan(&limits.UpdatedAt)
+	if err != nil {
+		var pgErr *pq.Error
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return fmt.Errorf("%w: %s %v", repository.ErrNotFound, column, value)
+		}
+		return fmt.Errorf("set spending limits: %w", err)
+	}
+	return nil
+}
+
+func (r *postgresWalletRepo) DeleteSpendingLimits(ctx context.Context, scope models.LimitScope) error {
+	column, value, err := limitScopeColumn(scope)
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM spending_limits WHERE `+column+` = $1`, value); err != nil {
+		return fmt.Errorf("delete spending limits: %w", err)
+	}
+	return nil
+}
+
+func limitScopeColumn(scope models.LimitScope) (string, any, error) {
+	switch {
+	case scope.WalletID != nil && scope.CurrencyCode == nil:
+		return "wallet_id", *scope.WalletID, nil
+	case scope.CurrencyCode != nil && scope.WalletID == nil:
+		return "currency_code", *scope.CurrencyCode, nil
+	}
+	return "", nil, errors.New("limit scope must be either a wallet or a currency")
+}
+
+// checkLimits проверяет лимиты кошелька для операции, уже применённой к балансу. Строка кошелька
+// к этому моменту заблокирована, поэтому конкурирующие операции проверяются по очереди и не могут
+// вместе превысить лимит. Текущая операция ещё не записана в историю и учитывается отдельно.
+// Лимиты списаний действуют на все операции клиента, уменьшающие учётный баланс, включая списание
+// резерва и обмен валюты: иначе через них можно было бы обойти дневной лимит.
+func (r *postgresWalletRepo) checkLimits(ctx context.Context, tx *sqlx.Tx, walletID uuid.UUID, currencyCode string, operationType models.OperationType, amount int64) error {
+	if operationType == models.OperationReversalDebit || (operationType != models.OperationDeposit && operationType.Sign() >= 0) {
+		return nil
+	}
+
+	// Каждый вид лимита наследуется отдельно: лимит кошелька заменяет лимит валюты того же вида,
+	// а незаданный (NULL) берётся из валюты. Снять лимит валюты для отдельного кошелька нельзя,
+	// можно только задать кошельку другое значение.
+	var limits models.SpendingLimits
+	const limitsQuery = `
+        SELECT
+            COALESCE(w.max_withdrawal, c.max_withdrawal) AS max_withdrawal,
+            COALESCE(w.daily_withdrawal, c.daily_withdrawal) AS daily_withdrawal,
+            COALESCE(w.monthly_withdrawal, c.monthly_withdrawal) AS monthly_withdrawal,
+            COALESCE(w.hourly_deposits, c.hourly_deposits) AS hourly_deposits
+        FROM (SELECT $1::uuid AS wallet_id, $2::text AS currency_code) s
+        LEFT JOIN spending_limits w ON w.wallet_id = s.wallet_id
+        LEFT JOIN spending_limits c ON c.currency_code = s.currency_code`
+	if err := tx.GetContext(ctx, &limits, limitsQuery, walletID, currencyCode); err != nil {
+		return fmt.Errorf("load spending limits: %w", err)
+	}
+
+	if operationType == models.OperationDeposit {
+		if limits.HourlyDeposits == nil {
+			return nil
+		}
+		var deposits int64
+		const depositsQuery = `
+            SELECT COUNT(*) FROM transactions
+            WHERE wallet_id = $1 AND operation_type = 'DEPOSIT' AND status <> 'FAILED'
+                AND created_at > CURRENT_TIMESTAMP - INTERVAL '1 hour'`
+		if err := tx.GetContext(ctx, &deposits, depositsQuery, walletID); err != nil {
+			return fmt.Errorf("count deposits: %w", err)
+		}
+		if deposits+1 > *limits.HourlyDeposits {
+			return fmt.Errorf("%w: no more than %d deposits per hour", repository.ErrLimitExceeded, *limits.HourlyDeposits)
+		}
+		return nil
+	}
+
+	if limits.MaxWithdrawal != nil && amount > *limits.MaxWithdrawal {
+		return fmt.Errorf("%w: single withdrawal limit is %d", repository.ErrLimitExceeded, *limits.MaxWithdrawal)
+	}
+	if limits.DailyWithdrawal == nil && limits.MonthlyWithdrawal == nil {
+		return nil
+	}
+
+	// Возвращённая часть списания не учитывается в лимите
+	var totals struct {
+		Daily   int64 `db:"daily"`
+		Monthly int64 `db:"monthly"`
+	}
+	const totalsQuery = `
+        SELECT
+            COALESCE(SUM(amount - refunded_amount) FILTER (
+                WHERE created_at >= date_trunc('day', CURRENT_TIMESTAMP AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'), 0) AS daily,
+            COALESCE(SUM(amount - refunded_amount), 0) AS monthly
+        FROM transactions
+        WHERE wallet_id = $1 AND operation_type = ANY($2) AND status <> 'FAILED'
+            AND created_at >= date_trunc('month', CURRENT_TIMESTAMP AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'`
+	if err := tx.GetContext(ctx, &totals, totalsQuery, walletID, pq.Array(debitOperationTypes)); err != nil {
+		return fmt.Errorf("sum withdrawals: %w", err)
+	}
+
+	if limits.DailyWithdrawal != nil && totals.Daily+amount > *limits.DailyWithdrawal {
+		return fmt.Errorf("%w: daily withdrawal limit is %d, already withdrawn %d",
+			repository.ErrLimitExceeded, *limits.DailyWithdrawal, totals.Daily)
+	}
+	if limits.MonthlyWithdrawal != nil && totals.Monthly+amount > *limits.MonthlyWithdrawal {
+		return fmt.Errorf("%w: monthly withdrawal limit is %d, already withdrawn %d",
+			repository.ErrLimitExceeded, *limits.MonthlyWithdrawal, totals.Monthly)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package postgres_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/Nzyazin/itk/internal/core/repository/postgres"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setDailyWithdrawalLimit(t *testing.T, repo repository.WalletRepository, walletID uuid.UUID, limit int64) {
+	t.Helper()
+	err := repo.SetSpendingLimits(context.Background(), &models.SpendingLimits{
+		LimitScope:      models.LimitScope{WalletID: &walletID},
+		DailyWithdrawal: &limit,
+	})
+	require.NoError(t, err)
+}
+
+func TestDailyLimitCountsAllDebits(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	db, teardown := setupTestDB(t, log)
+	defer teardown()
+
+	repo := postgres.NewPostgresWalletRepo(db, log)
+	ctx := context.Background()
+
+	walletID, counterparty := createWallet(t, db, "USD", 10000), createWallet(t, db, "USD", 0)
+	setDailyWithdrawalLimit(t, repo, walletID, 1000)
+
+	_, err := repo.ExecuteTxWithRetry(ctx, repository.OperationParams{
+		WalletID: walletID, Amount: 400, OperationType: models.OperationWithdraw,
+	})
+	require.NoError(t, err)
+	_, err = repo.TransferTxWithRetry(ctx, repository.OperationParams{
+		WalletID: walletID, TargetWalletID: counterparty, Amount: 300,
+	})
+	require.NoError(t, err)
+
+	// Списание резерва расходует тот же дневной лимит
+	hold := authorizeHold(t, repo, walletID, 500)
+	_, err = repo.CaptureHoldWithRetry(ctx, hold.ID, 400, "")
+	assert.ErrorIs(t, err, repository.ErrLimitExceeded)
+	_, err = repo.CaptureHoldWithRetry(ctx, hold.ID, 300, "")
+	require.NoError(t, err)
+
+	_, err = repo.ExecuteTxWithRetry(ctx, repository.OperationParams{
+		WalletID: walletID, Amount: 1, OperationType: models.OperationWithdraw,
+	})
+	assert.ErrorIs(t, err, repository.ErrLimitExceeded)
+
+	wallet, err := repo.GetByID(ctx, walletID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(9000), wallet.Balance)
+}
+
+func TestConcurrentWithdrawalsRespectDailyLimit(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	db, teardown := setupTestDB(t, log)
+	defer teardown()
+
+	repo := postgres.NewPostgresWalletRepo(db, log)
+	ctx := context.Background()
+
+	walletID := createWallet(t, db, "USD", 10000)
+	setDailyWithdrawalLimit(t, repo, walletID, 1000)
+
+	// Каждое списание укладывается в лимит, но вместе они превышают его вдвое
+	const goroutines = 20
+	const amount = int64(100)
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, goroutines)
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.ExecuteTxWithRetry(ctx, repository.OperationParams{
+				WalletID: walletID, Amount: amount, OperationType: models.OperationWithdraw,
+			})
+			errCh <- err
+		}()
+	}
+	wg.Wait()
+	close(errCh)
+
+	var succeeded int
+	for err := range errCh {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assert.ErrorIs(t, err, repository.ErrLimitExceeded)
+	}
+	assert.Equal(t, 10, succeeded)
+
+	wallet, err := repo.GetByID(ctx, walletID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(9000), wallet.Balance)
+}
+
+func TestReversalIgnoresWithdrawalLimits(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	db, teardown := setupTestDB(t, log)
+	defer teardown()
+
+	repo := postgres.NewPostgresWalletRepo(db, log)
+	ctx := context.Background()
+
+	walletID := createWallet(t, db, "USD", 0)
+	_, err := repo.ExecuteTxWithRetry(ctx, repository.OperationParams{
+		WalletID: walletID, Amount: 2000, OperationType: models.OperationDeposit,
+	})
+	require.NoError(t, err)
+	depositID := lastTransactionID(t, db, walletID, models.OperationDeposit)
+
+	setDailyWithdrawalLimit(t, repo, walletID, 1000)
+	_, err = repo.ExecuteTxWithRetry(ctx, repository.OperationParams{
+		WalletID: walletID, Amount: 600, OperationType: models.OperationWithdraw,
+	})
+	require.NoError(t, err)
+
+	// Возврат больше оставшегося дневного лимита проходит и не расходует его
+	outcome, err := repo.ReverseTransactionWithRetry(ctx, depositID, 900, "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(500), outcome.Balance)
+
+	_, err = repo.ExecuteTxWithRetry(ctx, repository.OperationParams{
+		WalletID: walletID, Amount: 400, OperationType: models.OperationWithdraw,
+	})
+	require.NoError(t, err)
+	_, err = repo.ExecuteTxWithRetry(ctx, repository.OperationParams{
+		WalletID: walletID, Amount: 1, OperationType: models.OperationWithdraw,
+	})
+	assert.ErrorIs(t, err, repository.ErrLimitExceeded)
+}
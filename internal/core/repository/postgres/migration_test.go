@@ -0,0 +1,98 @@
+package postgres_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBalanceAfterBackfill применяет миграцию 000019 к истории, записанной до неё
+func TestBalanceAfterBackfill(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	db, teardown := setupTestDB(t, log)
+	defer teardown()
+
+	files, err := migrationFiles()
+	require.NoError(t, err)
+	var before, backfill []string
+	for i, file := range files {
+		if strings.HasPrefix(filepath.Base(file), "000019_") {
+			before, backfill = files[:i], files[i:i+1]
+			break
+		}
+	}
+	require.NotEmpty(t, backfill, "migration 000019 not found")
+
+	migrationDB := createTestDatabase(t, db)
+	require.NoError(t, applyMigrationFiles(migrationDB, before))
+
+	// Кошелёк был перенесён с остатком 500, затем: пополнение 1000, списание 300, отклонённое
+	// списание, резерв без изменения баланса и пополнение 200
+	walletID := uuid.New()
+	_, err = migrationDB.Exec(`INSERT INTO wallets (id, balance, currency_code) VALUES ($1, 1400, 'USD')`, walletID)
+	require.NoError(t, err)
+
+	start := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	history := []struct {
+		operationType string
+		amount        int64
+		status        string
+		balanceAfter  *int64
+	}{
+		{"DEPOSIT", 1000, "COMPLETED", ptr(1500)},
+		{"WITHDRAW", 300, "COMPLETED", ptr(1200)},
+		{"WITHDRAW", 5000, "FAILED", nil},
+		{"HOLD", 100, "VOIDED", nil},
+		{"DEPOSIT", 200, "COMPLETED", ptr(1400)},
+	}
+	ids := make([]uuid.UUID, len(history))
+	for i, item := range history {
+		ids[i] = uuid.New()
+		_, err := migrationDB.Exec(`INSERT INTO transactions (id, wallet_id, operation_type, amount, status, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			ids[i], walletID, item.operationType, item.amount, item.status, start.Add(time.Duration(i)*time.Minute))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, applyMigrationFiles(migrationDB, backfill))
+
+	var previousSeq int64
+	for i, item := range history {
+		var row struct {
+			BalanceAfter *int64     `db:"balance_after"`
+			PostedAt     *time.Time `db:"posted_at"`
+			Seq          *int64     `db:"seq"`
+		}
+		require.NoError(t, migrationDB.Get(&row,
+			`SELECT balance_after, posted_at, seq FROM transactions WHERE id = $1`, ids[i]))
+
+		if item.balanceAfter == nil {
+			assert.Nil(t, row.BalanceAfter, "%s %s must not get a balance", item.operationType, item.status)
+			continue
+		}
+		require.NotNil(t, row.BalanceAfter, "operation %d", i)
+		assert.Equal(t, *item.balanceAfter, *row.BalanceAfter, "operation %d", i)
+		require.NotNil(t, row.PostedAt)
+		assert.True(t, start.Add(time.Duration(i)*time.Minute).Equal(*row.PostedAt), "posted_at is taken from created_at")
+		require.NotNil(t, row.Seq)
+		assert.Greater(t, *row.Seq, previousSeq, "seq follows created_at")
+		previousSeq = *row.Seq
+	}
+
+	// Новые операции продолжают нумерацию после восстановленной истории
+	var nextSeq int64
+	require.NoError(t, migrationDB.Get(&nextSeq, `SELECT nextval('transactions_seq')`))
+	assert.Greater(t, nextSeq, previousSeq)
+}
+
+func ptr(v int64) *int64 {
+	return &v
+}
@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// outboxLockKey - ключ рекомендательной блокировки ретранслятора. Одновременно события публикует
+// только один экземпляр сервиса, иначе события одного кошелька могли бы обогнать друг друга.
+const outboxLockKey int64 = 0x6f7574626f78
+
+// OutboxMaxAttempts - число попыток публикации события, после которого оно откладывается.
+// Задержка между попытками удваивается от секунды до пяти минут, поэтому событие откладывается
+// примерно через час неудачных попыток.
+const OutboxMaxAttempts = 20
+
+type postgresOutboxRepo struct {
+	db  *sqlx.DB
+	log logger.Logger
+}
+
+func NewPostgresOutboxRepo(db *sqlx.DB, log logger.Logger) repository.OutboxRepository {
+	return &postgresOutboxRepo{db: db, log: log}
+}
+
+func (r *postgresOutboxRepo) ProcessOutbox(ctx context.Context, limit int, publish func(events []models.OutboxEvent) []error) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin outbox transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var locked bool
+	if err := tx.GetContext(ctx, &locked, `SELECT pg_try_advisory_xact_lock($1)`, outboxLockKey); err != nil {
+		return 0, fmt.Errorf("lock outbox: %w", err)
+	}
+	if !locked {
+		return 0, nil
+	}
+
+	// События кошелька после неудачного не выдаются: их всё равно нельзя опубликовать раньше него,
+	// а иначе такие события могли бы занять всю пачку и остановить публикацию других кошельков
+	var events []models.OutboxEvent
+	query := `SELECT id, event_id, wallet_id, event_type, payload, created_at, attempts
+		FROM outbox_events e
+		WHERE published_at IS NULL AND parked_at IS NULL
+			AND (retry_at IS NULL OR retry_at <= CURRENT_TIMESTAMP)
+			AND NOT EXISTS (
+				SELECT 1 FROM outbox_events f
+				WHERE f.wallet_id = e.wallet_id AND f.id < e.id
+					AND f.published_at IS NULL AND f.attempts > 0)
+		ORDER BY id
+		LIMIT $1`
+	if err := tx.SelectContext(ctx, &events, query, limit); err != nil {
+		return 0, fmt.Errorf("load outbox events: %w", err)
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	results := publish(events)
+
+	published := make([]int64, 0, len(events))
+	for i, event := range events {
+		switch {
+		case results[i] == nil:
+			published = append(published, event.Sequence)
+			continue
+		case errors.Is(results[i], repository.ErrEventDeferred):
+			continue
+		}
+
+		var parked bool
+		err := tx.GetContext(ctx, &parked, `UPDATE outbox_events SET
+				attempts = attempts + 1,
+				last_error = $1,
+				retry_at = CURRENT_TIMESTAMP + LEAST(INTERVAL '1 second' * power(2, attempts), INTERVAL '5 minutes'),
+				parked_at = CASE WHEN attempts + 1 >= $3 THEN CURRENT_TIMESTAMP END
+			WHERE id = $2
+			RETURNING parked_at IS NOT NULL`,
+			results[i].Error(), event.Sequence, OutboxMaxAttempts)
+		if err != nil {
+			return 0, fmt.Errorf("record publish failure: %w", err)
+		}
+		if parked {
+			r.log.Error("Outbox event parked after failed attempts",
+				logger.StringField("event_id", event.ID.String()),
+				logger.StringField("wallet_id", event.WalletID.String()),
+				logger.Int64Field("attempts", int64(event.Attempts+1)),
+				logger.ErrorField("error", results[i]))
+		}
+	}
+
+	if len(published) > 0 {
+		_, err := tx.ExecContext(ctx,
+			`UPDATE outbox_events SET published_at = CURRENT_TIMESTAMP WHERE id = ANY($1)`, pq.Array(published))
+		if err != nil {
+			return 0, fmt.Errorf("mark events published: %w", err)
+		}
+	}
+
+	// Если фиксация не удалась, опубликованные события будут отправлены повторно
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit outbox: %w", err)
+	}
+	return len(published), nil
+}
+
+// recordTransactionEvent записывает событие об операции из истории кошелька вместе с балансом
+// кошелька после неё. Вызывается в транзакции операции после изменения баланса.
+func (r *postgresWalletRepo) recordTransactionEvent(ctx context.Context, tx *sqlx.Tx, transaction *models.Transaction) error {
+	eventType := models.TransactionEventType(transaction.OperationType)
+	return r.recordTransactionEvents(ctx, tx, eventType, []uuid.UUID{transaction.ID})
+}
+
+// recordTransactionEvents записывает событие eventType для каждой из операций в порядке их создания.
+// Событие содержит текущий статус операции, поэтому подходит и для смены статуса резерва.
+func (r *postgresWalletRepo) recordTransactionEvents(ctx context.Context, tx *sqlx.Tx, eventType models.EventType, transactionIDs []uuid.UUID) error {
+	const query = `INSERT INTO outbox_events (event_id, wallet_id, event_type, payload)
+        SELECT gen_random_uuid(), t.wallet_id, $1, jsonb_build_object(
+            'transaction_id', t.id,
+            'wallet_id', t.wallet_id,
+            'operation_type', t.operation_type,
+            'amount', t.amount,
+            'status', t.status,
+            'currency', w.currency_code,
+            'balance', COALESCE(t.balance_after, w.balance),
+            'held_balance', w.held_balance,
+            'counterparty_wallet_id', t.counterparty_wallet_id,
+            'related_transaction_id', t.related_transaction_id,
+            'hold_id', t.hold_id,
+            'created_at', t.created_at)
+        FROM transactions t
+        JOIN wallets w ON w.id = t.wallet_id
+        WHERE t.id = ANY($2)
+        ORDER BY t.created_at, t.id`
+	if len(transactionIDs) == 0 {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, query, eventType, pq.Array(transactionIDs)); err != nil {
+		return fmt.Errorf("record %s event: %w", eventType, err)
+	}
+	return nil
+}
+
+// recordEvent записывает в outbox произвольное событие кошелька
+func (r *postgresWalletRepo) recordEvent(ctx context.Context, tx *sqlx.Tx, walletID uuid.UUID, eventType models.EventType, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal %s event: %w", eventType, err)
+	}
+
+	query := `INSERT INTO outbox_events (event_id, wallet_id, event_type, payload) VALUES ($1, $2, $3, $4)`
+	if _, err := tx.ExecContext(ctx, query, uuid.New(), walletID, eventType, data); err != nil {
+		return fmt.Errorf("record %s event: %w", eventType, err)
+	}
+	return nil
+}
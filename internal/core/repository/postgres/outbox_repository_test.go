@@ -0,0 +1,118 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/Nzyazin/itk/internal/core/events"
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/Nzyazin/itk/internal/core/repository/postgres"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type outboxRow struct {
+	ID          int64        `db:"id"`
+	Attempts    int          `db:"attempts"`
+	PublishedAt sql.NullTime `db:"published_at"`
+	ParkedAt    sql.NullTime `db:"parked_at"`
+}
+
+func outboxRows(t *testing.T, db *sqlx.DB, walletID uuid.UUID) []outboxRow {
+	t.Helper()
+	var rows []outboxRow
+	require.NoError(t, db.Select(&rows,
+		`SELECT id, attempts, published_at, parked_at FROM outbox_events WHERE wallet_id = $1 ORDER BY id`, walletID))
+	return rows
+}
+
+func deposit(t *testing.T, repo repository.WalletRepository, walletID uuid.UUID, amount int64) {
+	t.Helper()
+	_, err := repo.ExecuteTxWithRetry(context.Background(), repository.OperationParams{
+		WalletID: walletID, Amount: amount, OperationType: models.OperationDeposit,
+	})
+	require.NoError(t, err)
+}
+
+func TestOutboxPoisonedWalletDoesNotBlockOthers(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	db, teardown := setupTestDB(t, log)
+	defer teardown()
+
+	repo := postgres.NewPostgresWalletRepo(db, log)
+	outbox := postgres.NewPostgresOutboxRepo(db, log)
+	ctx := context.Background()
+
+	poisoned, healthy := createWallet(t, db, "USD", 0), createWallet(t, db, "USD", 0)
+	deposit(t, repo, poisoned, 100)
+	deposit(t, repo, poisoned, 200)
+	deposit(t, repo, healthy, 300)
+
+	// Публикация событий отравленного кошелька всегда завершается ошибкой;
+	// следующие события кошелька откладываются, как это делает ретранслятор
+	errPoisoned := errors.New("payload rejected by broker")
+	publish := func(batch []models.OutboxEvent) []error {
+		results := make([]error, len(batch))
+		failed := false
+		for i, event := range batch {
+			if event.WalletID != poisoned {
+				continue
+			}
+			if failed {
+				results[i] = events.ErrPreviousEventFailed
+				continue
+			}
+			results[i] = errPoisoned
+			failed = true
+		}
+		return results
+	}
+	// Пачка из двух событий вмещает только события отравленного кошелька
+	runUntil := func(done func() bool) {
+		t.Helper()
+		for pass := 0; pass < 100 && !done(); pass++ {
+			_, err := outbox.ProcessOutbox(ctx, 2, publish)
+			require.NoError(t, err)
+		}
+		require.True(t, done(), "outbox did not reach the expected state")
+	}
+
+	healthyPublished := func() bool {
+		for _, row := range outboxRows(t, db, healthy) {
+			if !row.PublishedAt.Valid {
+				return false
+			}
+		}
+		return true
+	}
+	runUntil(healthyPublished)
+
+	rows := outboxRows(t, db, poisoned)
+	require.Len(t, rows, 2)
+	assert.Equal(t, 1, rows[0].Attempts)
+	assert.False(t, rows[0].PublishedAt.Valid)
+	assert.Zero(t, rows[1].Attempts, "event after a failed one must not be issued")
+
+	// Последняя попытка откладывает событие
+	_, err := db.Exec(`UPDATE outbox_events SET attempts = $1, retry_at = NULL WHERE id = $2`,
+		postgres.OutboxMaxAttempts-1, rows[0].ID)
+	require.NoError(t, err)
+	runUntil(func() bool { return outboxRows(t, db, poisoned)[0].ParkedAt.Valid })
+
+	rows = outboxRows(t, db, poisoned)
+	assert.Equal(t, postgres.OutboxMaxAttempts, rows[0].Attempts)
+	assert.False(t, rows[1].PublishedAt.Valid, "events after a parked one must keep the wallet order")
+	assert.Zero(t, rows[1].Attempts)
+	assert.False(t, rows[1].ParkedAt.Valid)
+
+	deposit(t, repo, healthy, 400)
+	runUntil(healthyPublished)
+}
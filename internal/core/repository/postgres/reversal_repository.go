@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+func (r *postgresWalletRepo) GetTransaction(ctx context.Context, id uuid.UUID) (*models.Transaction, error) {
+	var transaction models.Transaction
+	query := `SELECT ` + transactionColumns + ` FROM transactions WHERE id = $1`
+	if err := r.db.GetContext(ctx, &transaction, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", repository.ErrTransactionNotFound, id)
+		}
+		return nil, fmt.Errorf("error getting transaction: %w", err)
+	}
+
+	return &transaction, nil
+}
+
+// ReverseTransactionWithRetry создаёт компенсирующую операцию на amount (0 - весь остаток)
+// и в той же транзакции отмечает исходную операцию как полностью или частично возвращённую
+func (r *postgresWalletRepo) ReverseTransactionWithRetry(ctx context.Context, transactionID uuid.UUID, amount int64, actor string) (*repository.ReversalOutcome, error) {
+	var outcome *repository.ReversalOutcome
+	err := r.withRetry(ctx, "reverse_transaction", func() error {
+		return r.inTx(ctx, func(tx *sqlx.Tx) error {
+			var err error
+			outcome, err = r.reverseTransaction(ctx, tx, transactionID, amount, actor)
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return outcome, nil
+}
+
+func (r *postgresWalletRepo) reverseTransaction(ctx context.Context, tx *sqlx.Tx, transactionID uuid.UUID, amount int64, actor string) (*repository.ReversalOutcome, error) {
+	var original models.Transaction
+	query := `SELECT ` + transactionColumns + ` FROM transactions WHERE id = $1 FOR UPDATE`
+	if err := tx.GetContext(ctx, &original, query, transactionID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", repository.ErrTransactionNotFound, transactionID)
+		}
+		return nil, fmt.Errorf("lock transaction: %w", err)
+	}
+
+	reversalType, ok := original.OperationType.ReversalType()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", repository.ErrNotReversible, original.OperationType)
+	}
+
+	switch original.Status {
+	case transactionStatusCompleted, models.TransactionStatusPartiallyReversed:
+	case models.TransactionStatusReversed:
+		return nil, fmt.Errorf("%w: %s", repository.ErrAlreadyReversed, transactionID)
+	default:
+		return nil, fmt.Errorf("%w: status %s", repository.ErrNotReversible, original.Status)
+	}
+
+	remaining := original.RemainingReversible()
+	if amount == 0 {
+		amount = remaining
+	}
+	if amount > remaining {
+		return nil, fmt.Errorf("%w: requested %d, remaining %d", repository.ErrReversalExceedsOriginal, amount, remaining)
+	}
+
+	state, err := r.updateBalance(ctx, tx, original.WalletID, amount, reversalType)
+	if err != nil {
+		return nil, err
+	}
+
+	original.RefundedAmount += amount
+	original.Status = models.TransactionStatusPartiallyReversed
+	if original.RemainingReversible() == 0 {
+		original.Status = models.TransactionStatusReversed
+	}
+
+	query = `UPDATE transactions SET refunded_amount = $1, status = $2 WHERE id = $3`
+	if _, err := tx.ExecContext(ctx, query, original.RefundedAmount, original.Status, original.ID); err != nil {
+		return nil, fmt.Errorf("update reversed transaction: %w", err)
+	}
+
+	reversal := &models.Transaction{
+		ID:                   uuid.New(),
+		WalletID:             original.WalletID,
+		OperationType:        reversalType,
+		Amount:               amount,
+		Status:               transactionStatusCompleted,
+		RelatedTransactionID: &original.ID,
+		Actor:                actorValue(actor),
+	}
+	if err := r.createTransaction(ctx, tx, reversal); err != nil {
+		return nil, err
+	}
+
+	if err := r.postAgainstClearing(ctx, tx, reversalType, reversal.ID, reversal.WalletID,
+		state.CurrencyCode, reversalType.Sign()*amount); err != nil {
+		return nil, err
+	}
+
+	return &repository.ReversalOutcome{
+		Original: &original,
+		Reversal: reversal,
+		Balance:  state.Balance,
+	}, nil
+}
@@ -0,0 +1,173 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/Nzyazin/itk/internal/core/repository/postgres"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lastTransactionID(t *testing.T, db *sqlx.DB, walletID uuid.UUID, operationType models.OperationType) uuid.UUID {
+	t.Helper()
+	var id uuid.UUID
+	require.NoError(t, db.Get(&id, `SELECT id FROM transactions
+		WHERE wallet_id = $1 AND operation_type = $2 ORDER BY created_at DESC LIMIT 1`, walletID, operationType))
+	return id
+}
+
+func TestPartialReversalsUpToOriginalAmount(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	db, teardown := setupTestDB(t, log)
+	defer teardown()
+
+	repo := postgres.NewPostgresWalletRepo(db, log)
+	ctx := context.Background()
+
+	walletID := createWallet(t, db, "USD", 1000)
+	_, err := repo.ExecuteTxWithRetry(ctx, repository.OperationParams{
+		WalletID: walletID, Amount: 600, OperationType: models.OperationWithdraw,
+	})
+	require.NoError(t, err)
+	withdrawID := lastTransactionID(t, db, walletID, models.OperationWithdraw)
+
+	steps := []struct {
+		amount   int64
+		refunded int64
+		status   string
+		balance  int64
+	}{
+		{200, 200, models.TransactionStatusPartiallyReversed, 600},
+		{150, 350, models.TransactionStatusPartiallyReversed, 750},
+		// Без суммы возвращается весь остаток
+		{0, 600, models.TransactionStatusReversed, 1000},
+	}
+	for _, step := range steps {
+		outcome, err := repo.ReverseTransactionWithRetry(ctx, withdrawID, step.amount, "")
+		require.NoError(t, err)
+		assert.Equal(t, step.refunded, outcome.Original.RefundedAmount)
+		assert.Equal(t, step.status, outcome.Original.Status)
+		assert.Equal(t, step.balance, outcome.Balance)
+		assert.Equal(t, models.OperationReversalCredit, outcome.Reversal.OperationType)
+		require.NotNil(t, outcome.Reversal.RelatedTransactionID)
+		assert.Equal(t, withdrawID, *outcome.Reversal.RelatedTransactionID)
+
+		stored, err := repo.GetTransaction(ctx, withdrawID)
+		require.NoError(t, err)
+		assert.Equal(t, step.refunded, stored.RefundedAmount)
+		assert.Equal(t, step.status, stored.Status)
+	}
+
+	_, err = repo.ReverseTransactionWithRetry(ctx, withdrawID, 1, "")
+	assert.ErrorIs(t, err, repository.ErrAlreadyReversed)
+
+	var reversed int64
+	require.NoError(t, db.Get(&reversed, `SELECT COALESCE(SUM(amount), 0) FROM transactions
+		WHERE related_transaction_id = $1 AND operation_type = 'REVERSAL_CREDIT'`, withdrawID))
+	assert.Equal(t, int64(600), reversed, "reversals never exceed the original amount")
+}
+
+func TestReversalExceedingRemainingAmount(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	db, teardown := setupTestDB(t, log)
+	defer teardown()
+
+	repo := postgres.NewPostgresWalletRepo(db, log)
+	ctx := context.Background()
+
+	walletID := createWallet(t, db, "USD", 0)
+	_, err := repo.ExecuteTxWithRetry(ctx, repository.OperationParams{
+		WalletID: walletID, Amount: 500, OperationType: models.OperationDeposit,
+	})
+	require.NoError(t, err)
+	depositID := lastTransactionID(t, db, walletID, models.OperationDeposit)
+
+	_, err = repo.ReverseTransactionWithRetry(ctx, depositID, 300, "")
+	require.NoError(t, err)
+
+	_, err = repo.ReverseTransactionWithRetry(ctx, depositID, 201, "")
+	assert.ErrorIs(t, err, repository.ErrReversalExceedsOriginal)
+
+	stored, err := repo.GetTransaction(ctx, depositID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(300), stored.RefundedAmount)
+	assert.Equal(t, models.TransactionStatusPartiallyReversed, stored.Status)
+
+	wallet, err := repo.GetByID(ctx, walletID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(200), wallet.Balance)
+}
+
+func TestTransferIsNotReversible(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	db, teardown := setupTestDB(t, log)
+	defer teardown()
+
+	repo := postgres.NewPostgresWalletRepo(db, log)
+	ctx := context.Background()
+
+	source, target := createWallet(t, db, "USD", 1000), createWallet(t, db, "USD", 0)
+	_, err := repo.TransferTxWithRetry(ctx, repository.OperationParams{
+		WalletID: source, TargetWalletID: target, Amount: 100, OperationType: models.OperationTransfer,
+	})
+	require.NoError(t, err)
+
+	for walletID, operationType := range map[uuid.UUID]models.OperationType{
+		source: models.OperationTransferOut,
+		target: models.OperationTransferIn,
+	} {
+		_, err := repo.ReverseTransactionWithRetry(ctx, lastTransactionID(t, db, walletID, operationType), 0, "")
+		assert.ErrorIs(t, err, repository.ErrNotReversible, "%s", operationType)
+	}
+}
+
+func TestReversalAndCaptureRecordActor(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	db, teardown := setupTestDB(t, log)
+	defer teardown()
+
+	repo := postgres.NewPostgresWalletRepo(db, log)
+	ctx := context.Background()
+
+	walletID := createWallet(t, db, "USD", 1000)
+	_, err := repo.ExecuteTxWithRetry(ctx, repository.OperationParams{
+		WalletID: walletID, Amount: 300, OperationType: models.OperationDeposit, Actor: "api_key:client",
+	})
+	require.NoError(t, err)
+
+	outcome, err := repo.ReverseTransactionWithRetry(ctx, lastTransactionID(t, db, walletID, models.OperationDeposit), 100, "user:operator")
+	require.NoError(t, err)
+	require.NotNil(t, outcome.Reversal.Actor)
+	assert.Equal(t, "user:operator", *outcome.Reversal.Actor)
+
+	hold := &models.Hold{ID: uuid.New(), WalletID: walletID, Amount: 200, ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, repo.AuthorizeHoldWithRetry(ctx, hold, "api_key:merchant"))
+	_, err = repo.CaptureHoldWithRetry(ctx, hold.ID, 0, "api_key:merchant")
+	require.NoError(t, err)
+
+	for operationType, actor := range map[models.OperationType]string{
+		models.OperationReversalDebit: "user:operator",
+		models.OperationHold:          "api_key:merchant",
+		models.OperationCapture:       "api_key:merchant",
+	} {
+		stored, err := repo.GetTransaction(ctx, lastTransactionID(t, db, walletID, operationType))
+		require.NoError(t, err)
+		require.NotNil(t, stored.Actor, "%s", operationType)
+		assert.Equal(t, actor, *stored.Actor, "%s", operationType)
+	}
+}
@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+func (r *postgresWalletRepo) GetBalanceAsOf(ctx context.Context, walletID uuid.UUID, at time.Time) (int64, error) {
+	return r.balanceAt(ctx, r.db, walletID, at, true)
+}
+
+func (r *postgresWalletRepo) ReadStatement(ctx context.Context, walletID uuid.UUID, from, to time.Time, fn func(models.StatementBalances, repository.MovementIterator) error) error {
+	// Остатки и операции читаются в одном снимке, чтобы входящий остаток вместе с операциями
+	// периода всегда давал исходящий
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("begin statement transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var balances models.StatementBalances
+	if balances.Opening, err = r.balanceAt(ctx, tx, walletID, from, false); err != nil {
+		return err
+	}
+	if balances.Closing, err = r.balanceAt(ctx, tx, walletID, to, false); err != nil {
+		return err
+	}
+
+	movements := func(visit func(models.Transaction) error) error {
+		query := `SELECT ` + transactionColumns + ` FROM transactions
+			WHERE wallet_id = $1 AND balance_after IS NOT NULL AND posted_at >= $2 AND posted_at < $3
+			ORDER BY posted_at, seq`
+		rows, err := tx.QueryxContext(ctx, query, walletID, from, to)
+		if err != nil {
+			return fmt.Errorf("list statement transactions: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var transaction models.Transaction
+			if err := rows.StructScan(&transaction); err != nil {
+				return fmt.Errorf("scan statement transaction: %w", err)
+			}
+			if err := visit(transaction); err != nil {
+				return err
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("list statement transactions: %w", err)
+		}
+		return nil
+	}
+
+	return fn(balances, movements)
+}
+
+// balanceAt возвращает баланс кошелька после последней операции, проведённой до at (или в момент at,
+// если inclusive). Если таких операций нет, возвращается баланс до первой операции кошелька.
+func (r *postgresWalletRepo) balanceAt(ctx context.Context, q sqlx.QueryerContext, walletID uuid.UUID, at time.Time, inclusive bool) (int64, error) {
+	comparison := "<"
+	if inclusive {
+		comparison = "<="
+	}
+
+	var balance int64
+	query := `SELECT balance_after FROM transactions
+		WHERE wallet_id = $1 AND balance_after IS NOT NULL AND posted_at ` + comparison + ` $2
+		ORDER BY posted_at DESC, seq DESC
+		LIMIT 1`
+	err := sqlx.GetContext(ctx, q, &balance, query, walletID, at)
+	if err == nil {
+		return balance, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("get balance as of: %w", err)
+	}
+
+	return r.initialBalance(ctx, q, walletID)
+}
+
+// initialBalance возвращает баланс кошелька до первой изменившей его операции, а если операций
+// не было - текущий баланс
+func (r *postgresWalletRepo) initialBalance(ctx context.Context, q sqlx.QueryerContext, walletID uuid.UUID) (int64, error) {
+	var first models.Transaction
+	query := `SELECT ` + transactionColumns + ` FROM transactions
+		WHERE wallet_id = $1 AND balance_after IS NOT NULL
+		ORDER BY posted_at, seq
+		LIMIT 1`
+	err := sqlx.GetContext(ctx, q, &first, query, walletID)
+	if err == nil {
+		return *first.BalanceAfter - first.OperationType.Sign()*first.Amount, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("get first transaction: %w", err)
+	}
+
+	var balance int64
+	if err := sqlx.GetContext(ctx, q, &balance, `SELECT balance FROM wallets WHERE id = $1`, walletID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("%w: wallet with id %s", repository.ErrNotFound, walletID)
+		}
+		return 0, fmt.Errorf("get wallet balance: %w", err)
+	}
+	return balance, nil
+}
@@ -5,9 +5,11 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"time"
+	"strings"
+	"sync"
 
 	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/Nzyazin/itk/internal/core/retry"
 	"github.com/Nzyazin/itk/internal/core/logger"
 	"github.com/Nzyazin/itk/internal/core/models"
 	"github.com/google/uuid"
@@ -17,12 +19,12 @@ import (
 )
 
 const (
-    transactionStatusCompleted = "COMPLETED"
-    transactionStatusFailed    = "FAILED"
+    transactionStatusCompleted = models.TransactionStatusCompleted
+    transactionStatusFailed    = models.TransactionStatusFailed
 )
 
 var (
-	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrInsufficientFunds = repository.ErrInsufficientFunds
 	ErrInvalidAmount     = errors.New("amount must be positive")
 	ErrInvalidOperationType = errors.New("invalid operation type")
 )
@@ -30,22 +32,74 @@ var (
 type postgresWalletRepo struct {
 	db *sqlx.DB
 	log logger.Logger
+	retryPolicy retry.Policy
+	txStrategy TxStrategy
+	// systemAccounts кэширует идентификаторы служебных счетов журнала по их коду
+	systemAccounts sync.Map
 }
 
-func NewPostgresWalletRepo(db *sqlx.DB, log logger.Logger) repository.WalletRepository {
-	return &postgresWalletRepo{
+// Option настраивает репозиторий кошельков
+type Option func(*postgresWalletRepo)
+
+// WithRetryPolicy задаёт повторы транзакций при конфликтах сериализации
+func WithRetryPolicy(policy retry.Policy) Option {
+	return func(r *postgresWalletRepo) {
+		r.retryPolicy = policy
+	}
+}
+
+// TxStrategy определяет, как операции с балансом изолируются от конкурирующих
+type TxStrategy string
+
+const (
+	// TxStrategySerializable выполняет операции в транзакциях SERIALIZABLE и повторяет их
+	// при конфликтах сериализации
+	TxStrategySerializable TxStrategy = "serializable"
+	// TxStrategyRowLock выполняет операции в транзакциях READ COMMITTED под блокировками строк
+	// кошельков: конкурирующие операции над одним кошельком ждут друг друга, а не повторяются
+	TxStrategyRowLock TxStrategy = "row_lock"
+)
+
+// ParseTxStrategy разбирает стратегию из конфигурации; пустое значение - SERIALIZABLE
+func ParseTxStrategy(value string) (TxStrategy, error) {
+	switch strategy := TxStrategy(value); strategy {
+	case "":
+		return TxStrategySerializable, nil
+	case TxStrategySerializable, TxStrategyRowLock:
+		return strategy, nil
+	}
+	return "", fmt.Errorf("unknown transaction strategy %q", value)
+}
+
+// WithTxStrategy задаёт стратегию изоляции операций с балансом
+func WithTxStrategy(strategy TxStrategy) Option {
+	return func(r *postgresWalletRepo) {
+		r.txStrategy = strategy
+	}
+}
+
+func NewPostgresWalletRepo(db *sqlx.DB, log logger.Logger, opts ...Option) repository.WalletRepository {
+	r := &postgresWalletRepo{
 		db: db,
 		log: log,
+		retryPolicy: retry.DefaultPolicy(),
+		txStrategy: TxStrategySerializable,
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
+const walletColumns = `id, balance, held_balance, overdraft_limit, currency_code, status, owner_ref, created_at, updated_at`
+
 func (r *postgresWalletRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
 	var wallet models.Wallet
-	query := `SELECT id, balance, currency_code, created_at, updated_at FROM wallets WHERE id = $1`
+	query := `SELECT ` + walletColumns + ` FROM wallets WHERE id = $1`
 	err := r.db.GetContext(ctx, &wallet, query, id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("%w: wallet with id %s not found", sql.ErrNoRows, id)
+			return nil, fmt.Errorf("%w: wallet with id %s", repository.ErrNotFound, id)
 		}
 		return nil, fmt.Errorf("error getting wallet: %w", err)
 	}
@@ -53,13 +107,34 @@ func (r *postgresWalletRepo) GetByID(ctx context.Context, id uuid.UUID) (*models
 	return &wallet, nil
 }
 
+func (r *postgresWalletRepo) Create(ctx context.Context, wallet *models.Wallet) error {
+	query := `INSERT INTO wallets (id, balance, currency_code, owner_ref)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at, updated_at`
+	err := r.db.QueryRowxContext(ctx, query, wallet.ID, wallet.Balance, wallet.CurrencyCode, wallet.OwnerRef).
+		Scan(&wallet.CreatedAt, &wallet.UpdatedAt)
+	if err != nil {
+		var pgErr *pq.Error
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return fmt.Errorf("%w: wallet with id %s", repository.ErrAlreadyExists, wallet.ID)
+		}
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			// Валюта удалена после проверки в usecase
+			return fmt.Errorf("%w: currency with code %s", repository.ErrNotFound, wallet.CurrencyCode)
+		}
+		return fmt.Errorf("error creating wallet: %w", err)
+	}
+
+	return nil
+}
+
 func (r *postgresWalletRepo) GetCurrencyByCode(ctx context.Context, code string) (*models.Currency, error) {
 	var currency models.Currency
-	query := `SELECT code, name, minor_units FROM currencies WHERE code = $1`
+	query := `SELECT ` + currencyColumns + ` FROM currencies WHERE code = $1`
 	err := r.db.GetContext(ctx, &currency, query, code)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("currency with code %s not found", code)
+			return nil, fmt.Errorf("%w: currency with code %s", repository.ErrNotFound, code)
 		}
 		return nil, fmt.Errorf("error getting currency: %w", err)
 	}
@@ -67,38 +142,202 @@ func (r *postgresWalletRepo) GetCurrencyByCode(ctx context.Context, code string)
 	return &currency, nil
 }
 
-const maxRetries = 27
-const baseSleep = 270 * time.Millisecond
+// GetByIDs возвращает существующие кошельки из списка; отсутствующие идентификаторы пропускаются
+func (r *postgresWalletRepo) GetByIDs(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*models.Wallet, error) {
+	var found []models.Wallet
+	query := `SELECT ` + walletColumns + ` FROM wallets WHERE id = ANY($1)`
+	if err := r.db.SelectContext(ctx, &found, query, pq.Array(ids)); err != nil {
+		return nil, fmt.Errorf("error getting wallets: %w", err)
+	}
 
-func (r *postgresWalletRepo) ExecuteTxWithRetry(ctx context.Context, walletID uuid.UUID, amount int64, opType models.OperationType) (int64, error) {
-    var lastErr error
-    for attempt := 0; attempt < maxRetries; attempt++ {
-        newBalance, err := r.executeTx(ctx, walletID, amount, opType)
-        if err == nil {
-            return newBalance, nil
-        }
+	wallets := make(map[uuid.UUID]*models.Wallet, len(found))
+	for i := range found {
+		wallets[found[i].ID] = &found[i]
+	}
+	return wallets, nil
+}
 
-        var pgErr *pq.Error
-        if errors.As(err, &pgErr) && (pgErr.Code == "40001" || pgErr.Code == "40P01") {
-			sleep := time.Duration((attempt+1)*(attempt+1)) * baseSleep
-            time.Sleep(sleep)
-            lastErr = err
-            continue
-        }
+// SetOverdraftLimit задаёт кредитную линию кошелька и возвращает изменённый кошелёк
+func (r *postgresWalletRepo) SetOverdraftLimit(ctx context.Context, id uuid.UUID, limit int64) (*models.Wallet, error) {
+	var wallet models.Wallet
+	query := `UPDATE wallets SET overdraft_limit = $1 WHERE id = $2 RETURNING ` + walletColumns
+	if err := r.db.GetContext(ctx, &wallet, query, limit, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: wallet with id %s", repository.ErrNotFound, id)
+		}
+		return nil, fmt.Errorf("error setting overdraft limit: %w", err)
+	}
+
+	return &wallet, nil
+}
+
+const transactionColumns = `id, wallet_id, operation_type, amount, refunded_amount, status,
+	counterparty_wallet_id, related_transaction_id, hold_id, failure_reason, exchange_rate, quote_id, actor,
+	balance_after, posted_at, created_at`
+
+// ListTransactions возвращает операции кошелька от новых к старым, начиная после курсора
+func (r *postgresWalletRepo) ListTransactions(ctx context.Context, filter models.TransactionFilter) ([]models.Transaction, error) {
+	conditions := []string{"wallet_id = $1"}
+	args := []interface{}{filter.WalletID}
+	addArg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.OperationType != "" {
+		conditions = append(conditions, "operation_type = "+addArg(filter.OperationType))
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = "+addArg(filter.Status))
+	}
+	if filter.From != nil {
+		conditions = append(conditions, "created_at >= "+addArg(*filter.From))
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "created_at < "+addArg(*filter.To))
+	}
+	if filter.After != nil {
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) < (%s, %s)",
+			addArg(filter.After.CreatedAt), addArg(filter.After.ID)))
+	}
 
+	query := `SELECT ` + transactionColumns + ` FROM transactions
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT ` + addArg(filter.Limit)
+
+	transactions := make([]models.Transaction, 0, filter.Limit)
+	if err := r.db.SelectContext(ctx, &transactions, query, args...); err != nil {
+		return nil, fmt.Errorf("error listing transactions: %w", err)
+	}
+
+	return transactions, nil
+}
+
+func (r *postgresWalletRepo) ExecuteTxWithRetry(ctx context.Context, params repository.OperationParams) (int64, error) {
+    var newBalance int64
+    err := r.withRetry(ctx, "execute_tx", func() error {
+        var err error
+        newBalance, err = r.executeTx(ctx, params)
+        return err
+    })
+    if err != nil {
+        return 0, err
+    }
+    return newBalance, nil
+}
+
+// TransferTxWithRetry переводит средства между кошельками одной валюты в одной транзакции
+// и возвращает новый баланс кошелька-отправителя
+func (r *postgresWalletRepo) TransferTxWithRetry(ctx context.Context, params repository.OperationParams) (int64, error) {
+    params.OperationType = models.OperationTransfer
+    var newBalance int64
+    err := r.withRetry(ctx, "transfer_tx", func() error {
+        var err error
+        newBalance, err = r.executeTx(ctx, params)
+        return err
+    })
+    if err != nil {
         return 0, err
     }
+    return newBalance, nil
+}
+
+// RecordFailedOperation сохраняет отклонённую операцию со статусом FAILED. Запись выполняется
+// отдельно от транзакции операции, которая к этому моменту уже откачена. Для переводов и обменов
+// записывается списание с кошелька-отправителя; несуществующий получатель не указывается.
+// Перевод с несуществующего кошелька записывается получателю как отклонённое зачисление.
+func (r *postgresWalletRepo) RecordFailedOperation(ctx context.Context, params repository.OperationParams, reason models.FailureReason) error {
+    operationType := params.OperationType
+    var counterparty *uuid.UUID
+    if operationType == models.OperationTransfer {
+        operationType = models.OperationTransferOut
+    }
+    if params.TargetWalletID != uuid.Nil {
+        counterparty = &params.TargetWalletID
+    }
+
+    recorded, err := r.insertFailedOperation(ctx, params.WalletID, operationType, params.Amount, counterparty, reason, params.Actor)
+    if err != nil {
+        return err
+    }
+    if !recorded && operationType == models.OperationTransferOut && counterparty != nil {
+        recorded, err = r.insertFailedOperation(ctx, *counterparty, models.OperationTransferIn, params.Amount, nil, reason, params.Actor)
+        if err != nil {
+            return err
+        }
+    }
+
+    if !recorded {
+        r.log.Warn("Failed operation not recorded: wallet does not exist",
+            logger.StringField("wallet_id", params.WalletID.String()),
+            logger.StringField("reason", string(reason)))
+    }
+    return nil
+}
+
+// insertFailedOperation записывает отказ в историю кошелька walletID, если кошелёк существует
+func (r *postgresWalletRepo) insertFailedOperation(ctx context.Context, walletID uuid.UUID, operationType models.OperationType,
+    amount int64, counterparty *uuid.UUID, reason models.FailureReason, actor string) (bool, error) {
+    query := `INSERT INTO transactions (id, wallet_id, operation_type, amount, status, counterparty_wallet_id, failure_reason, actor)
+        SELECT $1, w.id, $3, $4, $5, (SELECT id FROM wallets WHERE id = $6), $7, $8
+        FROM wallets w
+        WHERE w.id = $2`
+    res, err := r.db.ExecContext(ctx, query, uuid.New(), walletID, operationType, amount,
+        transactionStatusFailed, counterparty, reason, actorValue(actor))
+    if err != nil {
+        return false, fmt.Errorf("record failed operation: %w", err)
+    }
+
+    n, err := res.RowsAffected()
+    if err != nil {
+        return false, fmt.Errorf("record failed operation: %w", err)
+    }
+    return n > 0, nil
+}
 
-    return 0, fmt.Errorf("failed after %d retries: %w", maxRetries, lastErr)
+// withRetry повторяет fn при конфликтах сериализации и взаимных блокировках по политике репозитория
+func (r *postgresWalletRepo) withRetry(ctx context.Context, operation string, fn func() error) error {
+    err := r.retryPolicy.Do(ctx, operation, isRetryable, fn)
+    if errors.Is(err, retry.ErrExhausted) {
+        r.log.Warn("Operation retries exhausted",
+            logger.StringField("operation", operation),
+            logger.ErrorField("error", err))
+        return fmt.Errorf("%w: %w", repository.ErrRetriesExhausted, err)
+    }
+    return err
 }
 
-func (r *postgresWalletRepo) executeTx(ctx context.Context, walletID uuid.UUID, amount int64, operationType models.OperationType) (int64, error) {
+func isRetryable(err error) bool {
+    var pgErr *pq.Error
+    if !errors.As(err, &pgErr) {
+        return false
+    }
+    switch {
+    case pgErr.Code == "40001" || pgErr.Code == "40P01":
+        return true
+    case pgErr.Code == "23505" && pgErr.Constraint == idempotencyKeyConstraint:
+        // Параллельный запрос с тем же ключом успел зафиксироваться первым:
+        // при повторе его результат будет прочитан из idempotency_keys
+        return true
+    }
+    return false
+}
+
+// inTx выполняет fn в транзакции с уровнем изоляции стратегии репозитория: фиксирует её при успехе
+// и откатывает при ошибке. В режиме блокировок строк корректность обеспечивают UPDATE и FOR UPDATE
+// по строкам кошельков, которые каждая операция выполняет до проверок баланса, статуса и лимитов.
+func (r *postgresWalletRepo) inTx(ctx context.Context, fn func(tx *sqlx.Tx) error) (err error) {
     var isCommitted bool
-    tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+    isolation := sql.LevelSerializable
+    if r.txStrategy == TxStrategyRowLock {
+        isolation = sql.LevelReadCommitted
+    }
+    tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: isolation})
     if err != nil {
         r.log.Error("Error beginning transaction", 
             logger.ErrorField("error", err))
-        return 0, fmt.Errorf("error beginning transaction: %w", err)
+        return fmt.Errorf("error beginning transaction: %w", err)
     }
 
     defer func() {
@@ -114,85 +353,292 @@ func (r *postgresWalletRepo) executeTx(ctx context.Context, walletID uuid.UUID,
         }
     }()
 
-    newBalance, err := r.updateBalance(ctx, tx, walletID, amount, operationType)
-    if err != nil {
-        return 0, err
-    }
-
-    if err := r.createTransaction(ctx, tx, walletID, amount, operationType, transactionStatusCompleted); err != nil {
-        return 0, err
+    if err = fn(tx); err != nil {
+        return err
     }
 
     if err = tx.Commit(); err != nil {
-        if pgErr, ok := err.(*pq.Error); ok && (pgErr.Code == "40001" || pgErr.Code == "40P01") {
-            return 0, pgErr
+        if isRetryable(err) {
+            return err
         }
         r.log.Error("Error committing transaction", 
             logger.ErrorField("error", err))
-        return 0, fmt.Errorf("commit failed: %w", err)
+        return fmt.Errorf("commit failed: %w", err)
     }
 
     isCommitted = true
+    return nil
+}
+
+func (r *postgresWalletRepo) executeTx(ctx context.Context, params repository.OperationParams) (int64, error) {
+    var newBalance int64
+    err := r.inTx(ctx, func(tx *sqlx.Tx) error {
+        var err error
+        newBalance, err = r.applyOperation(ctx, tx, params)
+        return err
+    })
+    if err != nil {
+        return 0, err
+    }
     return newBalance, nil
 }
 
-func (r *postgresWalletRepo) updateBalance(ctx context.Context, tx *sqlx.Tx, walletID uuid.UUID, amount int64, operationType models.OperationType) (int64, error) {
-    var delta int64
-    switch operationType {
-    case models.OperationDeposit:
-        delta = amount
-    case models.OperationWithdraw:
-        delta = -amount
-    default:
-        return 0, ErrInvalidOperationType
+// applyOperation выполняет операцию в уже открытой транзакции и возвращает новый баланс кошелька
+// params.WalletID. Повтор операции с использованным ключом идемпотентности возвращает сохранённый результат.
+func (r *postgresWalletRepo) applyOperation(ctx context.Context, tx *sqlx.Tx, params repository.OperationParams) (int64, error) {
+    replayed, err := r.loadIdempotentResult(ctx, tx, params)
+    if err != nil {
+        return 0, err
+    }
+    if replayed != nil {
+        return *replayed, nil
     }
 
+    var transactionID uuid.UUID
     var newBalance int64
-    updateQuery := `
-        UPDATE wallets
-        SET balance = balance + $1
-        WHERE id = $2
-        RETURNING balance
-    `
-    err := tx.GetContext(ctx, &newBalance, updateQuery, delta, walletID)
+    if params.OperationType == models.OperationTransfer {
+        transactionID, newBalance, err = r.applyTransfer(ctx, tx, params)
+    } else {
+        transactionID, newBalance, err = r.applyBalanceChange(ctx, tx, params)
+    }
     if err != nil {
-        if errors.Is(err, sql.ErrNoRows) {
-            return 0, fmt.Errorf("wallet not found: %s", walletID)
+        return 0, err
+    }
+
+    if err := r.saveIdempotentResult(ctx, tx, params, transactionID, newBalance); err != nil {
+        return 0, err
+    }
+    return newBalance, nil
+}
+
+func (r *postgresWalletRepo) applyBalanceChange(ctx context.Context, tx *sqlx.Tx, params repository.OperationParams) (uuid.UUID, int64, error) {
+    state, err := r.updateBalance(ctx, tx, params.WalletID, params.Amount, params.OperationType)
+    if err != nil {
+        return uuid.Nil, 0, err
+    }
+
+    transactionID := uuid.New()
+    if err := r.createTransaction(ctx, tx, &models.Transaction{
+        ID:            transactionID,
+        WalletID:      params.WalletID,
+        OperationType: params.OperationType,
+        Amount:        params.Amount,
+        Status:        transactionStatusCompleted,
+        Actor:         actorValue(params.Actor),
+    }); err != nil {
+        return uuid.Nil, 0, err
+    }
+
+    if err := r.postAgainstClearing(ctx, tx, params.OperationType, transactionID, params.WalletID,
+        state.CurrencyCode, params.OperationType.Sign()*params.Amount); err != nil {
+        return uuid.Nil, 0, err
+    }
+
+    return transactionID, state.Balance, nil
+}
+
+// applyTransfer переводит средства между кошельками одной валюты и возвращает
+// идентификатор операции списания и новый баланс кошелька-отправителя
+func (r *postgresWalletRepo) applyTransfer(ctx context.Context, tx *sqlx.Tx, params repository.OperationParams) (uuid.UUID, int64, error) {
+    fromID, toID, amount := params.WalletID, params.TargetWalletID, params.Amount
+
+    wallets, err := r.lockWallets(ctx, tx, fromID, toID)
+    if err != nil {
+        return uuid.Nil, 0, err
+    }
+    if wallets[fromID].CurrencyCode != wallets[toID].CurrencyCode {
+        return uuid.Nil, 0, fmt.Errorf("%w: %s -> %s", repository.ErrCurrencyMismatch,
+            wallets[fromID].CurrencyCode, wallets[toID].CurrencyCode)
+    }
+
+    state, err := r.updateBalance(ctx, tx, fromID, amount, models.OperationTransferOut)
+    if err != nil {
+        return uuid.Nil, 0, err
+    }
+    if _, err := r.updateBalance(ctx, tx, toID, amount, models.OperationTransferIn); err != nil {
+        return uuid.Nil, 0, err
+    }
+
+    outID, inID := uuid.New(), uuid.New()
+    if err := r.createTransaction(ctx, tx, &models.Transaction{
+        ID:                   outID,
+        WalletID:             fromID,
+        OperationType:        models.OperationTransferOut,
+        Amount:               amount,
+        Status:               transactionStatusCompleted,
+        CounterpartyWalletID: &toID,
+        RelatedTransactionID: &inID,
+        Actor:                actorValue(params.Actor),
+    }); err != nil {
+        return uuid.Nil, 0, err
+    }
+
+    if err := r.createTransaction(ctx, tx, &models.Transaction{
+        ID:                   inID,
+        WalletID:             toID,
+        OperationType:        models.OperationTransferIn,
+        Amount:               amount,
+        Status:               transactionStatusCompleted,
+        CounterpartyWalletID: &fromID,
+        RelatedTransactionID: &outID,
+        Actor:                actorValue(params.Actor),
+    }); err != nil {
+        return uuid.Nil, 0, err
+    }
+
+    if err := r.postEntry(ctx, tx, models.OperationTransfer, outID, state.CurrencyCode,
+        models.Posting{AccountID: fromID, Amount: -amount},
+        models.Posting{AccountID: toID, Amount: amount},
+    ); err != nil {
+        return uuid.Nil, 0, err
+    }
+
+    return outID, state.Balance, nil
+}
+
+// lockWallets блокирует строки кошельков в порядке возрастания id, чтобы встречные
+// операции над одной парой кошельков не приводили к взаимной блокировке
+func (r *postgresWalletRepo) lockWallets(ctx context.Context, tx *sqlx.Tx, ids ...uuid.UUID) (map[uuid.UUID]*models.Wallet, error) {
+    var locked []models.Wallet
+    query := `SELECT ` + walletColumns + `
+        FROM wallets
+        WHERE id = ANY($1)
+        ORDER BY id
+        FOR UPDATE`
+    if err := tx.SelectContext(ctx, &locked, query, pq.Array(ids)); err != nil {
+        return nil, fmt.Errorf("lock wallets: %w", err)
+    }
+
+    wallets := make(map[uuid.UUID]*models.Wallet, len(locked))
+    for i := range locked {
+        wallets[locked[i].ID] = &locked[i]
+    }
+    for _, id := range ids {
+        if _, ok := wallets[id]; !ok {
+            return nil, fmt.Errorf("%w: wallet with id %s", repository.ErrNotFound, id)
         }
-        return 0, fmt.Errorf("update balance: %w", err)
     }
 
-    if newBalance < 0 {
-        return 0, ErrInsufficientFunds
+    return wallets, nil
+}
+
+func (r *postgresWalletRepo) updateBalance(ctx context.Context, tx *sqlx.Tx, walletID uuid.UUID, amount int64, operationType models.OperationType) (*balanceState, error) {
+    delta := operationType.Sign() * amount
+    switch {
+    case delta == 0:
+        return nil, ErrInvalidOperationType
+    case delta < 0:
+        // Истёкшие резервы не должны уменьшать доступный баланс, даже если их ещё не освободил фоновый процесс
+        if _, err := r.expireHolds(ctx, tx, &walletID, 0); err != nil {
+            return nil, err
+        }
     }
 
-    return newBalance, nil
+    state, err := r.adjustBalance(ctx, tx, walletID, delta, 0)
+    if err != nil {
+        return nil, err
+    }
+
+    if delta < 0 && !state.canDebit() {
+        return nil, ErrInsufficientFunds
+    }
+
+    if err := r.checkLimits(ctx, tx, walletID, state.CurrencyCode, operationType, amount); err != nil {
+        return nil, err
+    }
+
+    return state, nil
 }
 
-func (r *postgresWalletRepo) createTransaction(ctx context.Context, tx *sqlx.Tx, walletID uuid.UUID, amount int64, operationType models.OperationType, status string) error {
-    transaction := &models.Transaction{
-        ID:            uuid.New(),
-        WalletID:      walletID,
-        OperationType: operationType,
-        Amount:        amount,
-        Status:        status,
+type balanceState struct {
+    Balance        int64  `db:"balance"`
+    HeldBalance    int64  `db:"held_balance"`
+    OverdraftLimit int64  `db:"overdraft_limit"`
+    CurrencyCode   string `db:"currency_code"`
+    Status         models.WalletStatus `db:"status"`
+}
+
+// canDebit проверяет, что после списания доступный баланс не опустился ниже кредитной линии,
+// по тому же правилу, что и предварительная проверка в сценарии операции
+func (s *balanceState) canDebit() bool {
+    wallet := models.Wallet{Balance: s.Balance, HeldBalance: s.HeldBalance, OverdraftLimit: s.OverdraftLimit}
+    return wallet.CanDebit(0)
+}
+
+// adjustBalance изменяет учётный баланс и сумму резервов кошелька и возвращает их новые значения.
+// Статус кошелька проверяется под блокировкой строки до изменения баланса, поэтому заморозка действует
+// и на операции, которые уже выполняются или повторяются после конфликта, а операция с закрытым
+// кошельком отклоняется до проверки ограничений таблицы.
+func (r *postgresWalletRepo) adjustBalance(ctx context.Context, tx *sqlx.Tx, walletID uuid.UUID, delta, heldDelta int64) (*balanceState, error) {
+    var status models.WalletStatus
+    err := tx.GetContext(ctx, &status, `SELECT status FROM wallets WHERE id = $1 FOR UPDATE`, walletID)
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return nil, fmt.Errorf("%w: wallet with id %s", repository.ErrNotFound, walletID)
+        }
+        return nil, fmt.Errorf("lock wallet: %w", err)
+    }
+
+    // Освобождение резерва разрешено в любом статусе
+    debit := delta < 0 || heldDelta > 0
+    if (debit && !status.AllowsDebit()) || (delta > 0 && !status.AllowsCredit()) {
+        return nil, fmt.Errorf("%w: wallet %s is %s", repository.ErrWalletNotActive, walletID, status)
     }
 
+    var state balanceState
+    updateQuery := `
+        UPDATE wallets
+        SET balance = balance + $1, held_balance = held_balance + $2
+        WHERE id = $3
+        RETURNING balance, held_balance, overdraft_limit, currency_code, status
+    `
+    if err := tx.GetContext(ctx, &state, updateQuery, delta, heldDelta, walletID); err != nil {
+        return nil, fmt.Errorf("update balance: %w", err)
+    }
+
+    return &state, nil
+}
+
+// createTransaction сохраняет операцию в истории и записывает событие о ней. Операция, изменившая
+// баланс, сохраняет баланс кошелька после неё: переданный в BalanceAfter или текущий, поэтому
+// вызывается после изменения баланса.
+func (r *postgresWalletRepo) createTransaction(ctx context.Context, tx *sqlx.Tx, transaction *models.Transaction) error {
     const query = `INSERT INTO transactions 
-        (id, wallet_id, operation_type, amount, status) 
-        VALUES ($1, $2, $3, $4, $5)`
+        (id, wallet_id, operation_type, amount, status, counterparty_wallet_id, related_transaction_id, hold_id,
+            exchange_rate, quote_id, actor, balance_after) 
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11,
+            CASE WHEN $12::boolean THEN COALESCE($13::bigint, (SELECT balance FROM wallets WHERE id = $2)) END)
+        RETURNING created_at, balance_after, posted_at`
 
-    _, err := tx.ExecContext(ctx, query,
+    movesBalance := transaction.OperationType.Sign() != 0 && transaction.Status != transactionStatusFailed
+
+    err := tx.QueryRowxContext(ctx, query,
         transaction.ID,
         transaction.WalletID,
         transaction.OperationType,
         transaction.Amount,
         transaction.Status,
-    )
+        transaction.CounterpartyWalletID,
+        transaction.RelatedTransactionID,
+        transaction.HoldID,
+        transaction.ExchangeRate,
+        transaction.QuoteID,
+        transaction.Actor,
+        movesBalance,
+        transaction.BalanceAfter,
+    ).Scan(&transaction.CreatedAt, &transaction.BalanceAfter, &transaction.PostedAt)
 
     if err != nil {
         return fmt.Errorf("create transaction: %w", err)
     }
 
-    return nil
+    return r.recordTransactionEvent(ctx, tx, transaction)
+}
+
+// actorValue сохраняет пустого инициатора как NULL: операция выполнена самим сервисом
+func actorValue(actor string) *string {
+    if actor == "" {
+        return nil
+    }
+    return &actor
 }
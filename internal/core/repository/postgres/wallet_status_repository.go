@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// ChangeStatusWithRetry меняет статус кошелька и записывает изменение в историю в одной транзакции.
+// Кошелёк блокируется, поэтому операции, начатые до смены статуса, завершаются до неё,
+// а начатые после - уже видят новый статус.
+func (r *postgresWalletRepo) ChangeStatusWithRetry(ctx context.Context, change *models.WalletStatusChange) (*models.Wallet, error) {
+	var wallet models.Wallet
+	err := r.withRetry(ctx, "change_status", func() error {
+		return r.inTx(ctx, func(tx *sqlx.Tx) error {
+			if change.ToStatus == models.WalletClosed {
+				// Истёкшие, но ещё не освобождённые резервы не должны мешать закрытию
+				if _, err := r.expireHolds(ctx, tx, &change.WalletID, 0); err != nil {
+					return err
+				}
+			}
+
+			wallets, err := r.lockWallets(ctx, tx, change.WalletID)
+			if err != nil {
+				return err
+			}
+			current := wallets[change.WalletID]
+			if !current.Status.CanTransitionTo(change.ToStatus) {
+				return fmt.Errorf("%w: %s -> %s", repository.ErrStatusTransition, current.Status, change.ToStatus)
+			}
+			if change.ToStatus == models.WalletClosed && (current.Balance != 0 || current.HeldBalance != 0) {
+				return fmt.Errorf("%w: balance %d, held %d", repository.ErrWalletNotEmpty, current.Balance, current.HeldBalance)
+			}
+			change.FromStatus = current.Status
+
+			query := `UPDATE wallets SET status = $1 WHERE id = $2 RETURNING ` + walletColumns
+			if err := tx.GetContext(ctx, &wallet, query, change.ToStatus, change.WalletID); err != nil {
+				return fmt.Errorf("update wallet status: %w", err)
+			}
+
+			historyQuery := `INSERT INTO wallet_status_history (wallet_id, from_status, to_status, reason, actor)
+				VALUES ($1, $2, $3, $4, $5)
+				RETURNING id, created_at`
+			err = tx.QueryRowxContext(ctx, historyQuery,
+				change.WalletID, change.FromStatus, change.ToStatus, change.Reason, change.Actor,
+			).Scan(&change.ID, &change.CreatedAt)
+			if err != nil {
+				return fmt.Errorf("record status change: %w", err)
+			}
+			return r.recordEvent(ctx, tx, change.WalletID, models.EventWalletStatusChanged, change)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+// ListStatusHistory возвращает смены статуса кошелька от новых к старым
+func (r *postgresWalletRepo) ListStatusHistory(ctx context.Context, walletID uuid.UUID) ([]models.WalletStatusChange, error) {
+	changes := []models.WalletStatusChange{}
+	query := `SELECT id, wallet_id, from_status, to_status, reason, actor, created_at
+		FROM wallet_status_history
+		WHERE wallet_id = $1
+		ORDER BY created_at DESC, id DESC`
+	if err := r.db.SelectContext(ctx, &changes, query, walletID); err != nil {
+		return nil, fmt.Errorf("list status history: %w", err)
+	}
+	return changes, nil
+}
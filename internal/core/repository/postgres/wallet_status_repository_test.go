@@ -0,0 +1,215 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/Nzyazin/itk/internal/core/repository/postgres"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func changeStatus(t *testing.T, repo repository.WalletRepository, walletID uuid.UUID, status models.WalletStatus) {
+	t.Helper()
+	_, err := repo.ChangeStatusWithRetry(context.Background(), &models.WalletStatusChange{
+		WalletID: walletID,
+		ToStatus: status,
+		Reason:   "test",
+		Actor:    "test",
+	})
+	require.NoError(t, err)
+}
+
+func depositID(t *testing.T, db *sqlx.DB, walletID uuid.UUID) uuid.UUID {
+	t.Helper()
+	var id uuid.UUID
+	require.NoError(t, db.Get(&id, `SELECT id FROM transactions
+		WHERE wallet_id = $1 AND operation_type = 'DEPOSIT' ORDER BY created_at LIMIT 1`, walletID))
+	return id
+}
+
+// statusOperations - все пути изменения баланса кошелька wallet; counterparty - активный кошелёк
+// той же валюты, eur - активный кошелёк в евро
+func statusOperations(repo repository.WalletRepository, db *sqlx.DB, t *testing.T, wallet, counterparty, eur uuid.UUID) map[string]func(ctx context.Context) error {
+	return map[string]func(ctx context.Context) error{
+		"withdraw": func(ctx context.Context) error {
+			_, err := repo.ExecuteTxWithRetry(ctx, repository.OperationParams{
+				WalletID: wallet, Amount: 10, OperationType: models.OperationWithdraw,
+			})
+			return err
+		},
+		"idempotent withdraw": func(ctx context.Context) error {
+			_, err := repo.ExecuteTxWithRetry(ctx, repository.OperationParams{
+				WalletID: wallet, Amount: 10, OperationType: models.OperationWithdraw,
+				IdempotencyKey: uuid.NewString(), RequestHash: "withdraw",
+			})
+			return err
+		},
+		"transfer out": func(ctx context.Context) error {
+			_, err := repo.TransferTxWithRetry(ctx, repository.OperationParams{
+				WalletID: wallet, TargetWalletID: counterparty, Amount: 10,
+			})
+			return err
+		},
+		"atomic batch withdraw": func(ctx context.Context) error {
+			outcomes, err := repo.ExecuteBatchWithRetry(ctx, []repository.OperationParams{
+				{WalletID: wallet, Amount: 10, OperationType: models.OperationWithdraw},
+			}, true)
+			if err != nil {
+				return err
+			}
+			return outcomes[0].Err
+		},
+		"best-effort batch withdraw": func(ctx context.Context) error {
+			outcomes, err := repo.ExecuteBatchWithRetry(ctx, []repository.OperationParams{
+				{WalletID: wallet, Amount: 10, OperationType: models.OperationWithdraw},
+			}, false)
+			if err != nil {
+				return err
+			}
+			return outcomes[0].Err
+		},
+		"wallet operations withdraw": func(ctx context.Context) error {
+			outcomes, err := repo.ExecuteWalletOperationsWithRetry(ctx, wallet, []repository.OperationParams{
+				{WalletID: wallet, Amount: 10, OperationType: models.OperationWithdraw},
+			})
+			if err != nil {
+				return err
+			}
+			return outcomes[0].Err
+		},
+		"hold": func(ctx context.Context) error {
+			return repo.AuthorizeHoldWithRetry(ctx, &models.Hold{
+				ID: uuid.New(), WalletID: wallet, Amount: 10, ExpiresAt: time.Now().Add(time.Hour),
+			}, "")
+		},
+		"reversal of deposit": func(ctx context.Context) error {
+			_, err := repo.ReverseTransactionWithRetry(ctx, depositID(t, db, wallet), 10, "")
+			return err
+		},
+		"conversion": func(ctx context.Context) error {
+			quote := &models.FXQuote{
+				ID: uuid.New(), WalletID: wallet, TargetWalletID: eur,
+				SourceCurrency: "USD", TargetCurrency: "EUR",
+				SourceAmount: 10, TargetAmount: 9, Rate: decimal.RequireFromString("0.9"),
+				ExpiresAt: time.Now().Add(time.Minute),
+			}
+			if err := repo.CreateQuote(ctx, quote); err != nil {
+				return err
+			}
+			_, err := repo.ConvertWithRetry(ctx, quote.ID, "")
+			return err
+		},
+	}
+}
+
+func TestFrozenWalletRejectsDebits(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	db, teardown := setupTestDB(t, log)
+	defer teardown()
+
+	repo := postgres.NewPostgresWalletRepo(db, log)
+	ctx := context.Background()
+
+	wallet := createWallet(t, db, "USD", 0)
+	counterparty, eur := createWallet(t, db, "USD", 1000), createWallet(t, db, "EUR", 0)
+	_, err := repo.ExecuteTxWithRetry(ctx, repository.OperationParams{
+		WalletID: wallet, Amount: 1000, OperationType: models.OperationDeposit,
+	})
+	require.NoError(t, err)
+	changeStatus(t, repo, wallet, models.WalletFrozen)
+
+	for name, operation := range statusOperations(repo, db, t, wallet, counterparty, eur) {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, operation(ctx), repository.ErrWalletNotActive)
+		})
+	}
+
+	// Зачисления на замороженный кошелёк разрешены
+	_, err = repo.ExecuteTxWithRetry(ctx, repository.OperationParams{
+		WalletID: wallet, Amount: 5, OperationType: models.OperationDeposit,
+		IdempotencyKey: uuid.NewString(), RequestHash: "deposit",
+	})
+	require.NoError(t, err)
+	_, err = repo.TransferTxWithRetry(ctx, repository.OperationParams{
+		WalletID: counterparty, TargetWalletID: wallet, Amount: 5,
+	})
+	require.NoError(t, err)
+
+	stored, err := repo.GetByID(ctx, wallet)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1010), stored.Balance)
+	assert.Zero(t, stored.HeldBalance)
+}
+
+func TestClosedWalletRejectsAllOperations(t *testing.T) {
+	log, cleanup := logger.NewLogger()
+	defer cleanup()
+
+	db, teardown := setupTestDB(t, log)
+	defer teardown()
+
+	repo := postgres.NewPostgresWalletRepo(db, log)
+	ctx := context.Background()
+
+	wallet := createWallet(t, db, "USD", 0)
+	counterparty, eur := createWallet(t, db, "USD", 1000), createWallet(t, db, "EUR", 0)
+	for _, operationType := range []models.OperationType{models.OperationDeposit, models.OperationWithdraw} {
+		_, err := repo.ExecuteTxWithRetry(ctx, repository.OperationParams{
+			WalletID: wallet, Amount: 100, OperationType: operationType,
+		})
+		require.NoError(t, err)
+	}
+	changeStatus(t, repo, wallet, models.WalletClosed)
+
+	operations := statusOperations(repo, db, t, wallet, counterparty, eur)
+	operations["deposit"] = func(ctx context.Context) error {
+		_, err := repo.ExecuteTxWithRetry(ctx, repository.OperationParams{
+			WalletID: wallet, Amount: 10, OperationType: models.OperationDeposit,
+		})
+		return err
+	}
+	operations["idempotent deposit"] = func(ctx context.Context) error {
+		_, err := repo.ExecuteTxWithRetry(ctx, repository.OperationParams{
+			WalletID: wallet, Amount: 10, OperationType: models.OperationDeposit,
+			IdempotencyKey: uuid.NewString(), RequestHash: "deposit",
+		})
+		return err
+	}
+	operations["transfer in"] = func(ctx context.Context) error {
+		_, err := repo.TransferTxWithRetry(ctx, repository.OperationParams{
+			WalletID: counterparty, TargetWalletID: wallet, Amount: 10,
+		})
+		return err
+	}
+	operations["best-effort batch deposit"] = func(ctx context.Context) error {
+		outcomes, err := repo.ExecuteBatchWithRetry(ctx, []repository.OperationParams{
+			{WalletID: wallet, Amount: 10, OperationType: models.OperationDeposit},
+		}, false)
+		if err != nil {
+			return err
+		}
+		return outcomes[0].Err
+	}
+
+	// Закрытый кошелёк отклоняет операцию по статусу, а не нарушением ограничения нулевого баланса
+	for name, operation := range operations {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, operation(ctx), repository.ErrWalletNotActive)
+		})
+	}
+
+	stored, err := repo.GetByID(ctx, wallet)
+	require.NoError(t, err)
+	assert.Equal(t, models.WalletClosed, stored.Status)
+	assert.Zero(t, stored.Balance)
+}
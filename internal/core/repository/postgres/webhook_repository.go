@@ -0,0 +1,210 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type postgresWebhookRepo struct {
+	db  *sqlx.DB
+	log logger.Logger
+}
+
+func NewPostgresWebhookRepo(db *sqlx.DB, log logger.Logger) repository.WebhookRepository {
+	return &postgresWebhookRepo{db: db, log: log}
+}
+
+const subscriptionColumns = `id, wallet_id, url, secret, event_types, created_at`
+
+// subscriptionRow читает event_types как массив строк PostgreSQL
+type subscriptionRow struct {
+	models.WebhookSubscription
+	EventTypes pq.StringArray `db:"event_types"`
+}
+
+func (row *subscriptionRow) subscription() models.WebhookSubscription {
+	subscription := row.WebhookSubscription
+	subscription.EventTypes = make([]models.EventType, len(row.EventTypes))
+	for i, t := range row.EventTypes {
+		subscription.EventTypes[i] = models.EventType(t)
+	}
+	return subscription
+}
+
+func (r *postgresWebhookRepo) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	eventTypes := make(pq.StringArray, len(subscription.EventTypes))
+	for i, t := range subscription.EventTypes {
+		eventTypes[i] = string(t)
+	}
+
+	query := `INSERT INTO webhook_subscriptions (id, wallet_id, url, secret, event_types)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at`
+	err := r.db.QueryRowxContext(ctx, query,
+		subscription.ID, subscription.WalletID, subscription.URL, subscription.Secret, eventTypes,
+	).Scan(&subscription.CreatedAt)
+	if err != nil {
+		var pgErr *pq.Error
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return fmt.Errorf("%w: wallet with id %s", repository.ErrNotFound, subscription.WalletID)
+		}
+		return fmt.Errorf("create webhook subscription: %w", err)
+	}
+	return nil
+}
+
+func (r *postgresWebhookRepo) GetSubscription(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error) {
+	var row subscriptionRow
+	query := `SELECT ` + subscriptionColumns + ` FROM webhook_subscriptions WHERE id = $1`
+	if err := r.db.GetContext(ctx, &row, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", repository.ErrSubscriptionNotFound, id)
+		}
+		return nil, fmt.Errorf("get webhook subscription: %w", err)
+	}
+
+	subscription := row.subscription()
+	return &subscription, nil
+}
+
+func (r *postgresWebhookRepo) ListSubscriptions(ctx context.Context, walletID *uuid.UUID) ([]models.WebhookSubscription, error) {
+	var rows []subscriptionRow
+	query := `SELECT ` + subscriptionColumns + ` FROM webhook_subscriptions
+		WHERE $1::uuid IS NULL OR wallet_id IS NULL OR wallet_id = $1
+		ORDER BY created_at, id`
+	if err := r.db.SelectContext(ctx, &rows, query, walletID); err != nil {
+		return nil, fmt.Errorf("list webhook subscriptions: %w", err)
+	}
+
+	subscriptions := make([]models.WebhookSubscription, len(rows))
+	for i := range rows {
+		subscriptions[i] = rows[i].subscription()
+	}
+	return subscriptions, nil
+}
+
+// DeleteSubscription удаляет подписку вместе с её доставками
+func (r *postgresWebhookRepo) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete webhook subscription: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("%w: %s", repository.ErrSubscriptionNotFound, id)
+	}
+	return nil
+}
+
+func (r *postgresWebhookRepo) EnqueueDeliveries(ctx context.Context, event models.OutboxEvent, payload []byte) (int, error) {
+	query := `INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload)
+		SELECT s.id, $1, $2, $3
+		FROM webhook_subscriptions s
+		WHERE (s.wallet_id IS NULL OR s.wallet_id = $4)
+			AND (cardinality(s.event_types) = 0 OR $2 = ANY(s.event_types))
+		ON CONFLICT (subscription_id, event_id) DO NOTHING`
+	result, err := r.db.ExecContext(ctx, query, event.ID, event.Type, payload, event.WalletID)
+	if err != nil {
+		return 0, fmt.Errorf("enqueue webhook deliveries: %w", err)
+	}
+
+	enqueued, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("enqueue webhook deliveries: %w", err)
+	}
+	return int(enqueued), nil
+}
+
+const deliveryColumns = `d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+	d.next_attempt_at, d.last_error, d.last_status_code, d.delivered_at, d.created_at`
+
+func (r *postgresWebhookRepo) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	query := `UPDATE webhook_deliveries d
+		SET next_attempt_at = CURRENT_TIMESTAMP + $2 * INTERVAL '1 millisecond'
+		FROM webhook_subscriptions s
+		WHERE s.id = d.subscription_id AND d.id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'PENDING' AND next_attempt_at <= CURRENT_TIMESTAMP
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + deliveryColumns + `, s.url, s.secret`
+	if err := r.db.SelectContext(ctx, &deliveries, query, limit, lease.Milliseconds()); err != nil {
+		return nil, fmt.Errorf("claim webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (r *postgresWebhookRepo) MarkDelivered(ctx context.Context, id uuid.UUID, statusCode int) error {
+	query := `UPDATE webhook_deliveries
+		SET status = 'DELIVERED', attempts = attempts + 1, last_status_code = $1, last_error = NULL,
+			delivered_at = CURRENT_TIMESTAMP
+		WHERE id = $2`
+	if _, err := r.db.ExecContext(ctx, query, statusCode, id); err != nil {
+		return fmt.Errorf("mark webhook delivered: %w", err)
+	}
+	return nil
+}
+
+func (r *postgresWebhookRepo) MarkFailed(ctx context.Context, id uuid.UUID, attempt models.DeliveryAttempt) error {
+	status, nextAttemptAt := models.DeliveryPending, attempt.RetryAt
+	if attempt.RetryAt == nil {
+		status = models.DeliveryDead
+	}
+
+	query := `UPDATE webhook_deliveries
+		SET status = $1, attempts = attempts + 1, last_error = $2, last_status_code = $3,
+			next_attempt_at = COALESCE($4, next_attempt_at)
+		WHERE id = $5`
+	if _, err := r.db.ExecContext(ctx, query, status, attempt.Error, attempt.StatusCode, nextAttemptAt, id); err != nil {
+		return fmt.Errorf("mark webhook failed: %w", err)
+	}
+	return nil
+}
+
+func (r *postgresWebhookRepo) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, status models.DeliveryStatus) ([]models.WebhookDelivery, error) {
+	deliveries := []models.WebhookDelivery{}
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries d
+		WHERE d.subscription_id = $1 AND ($2 = '' OR d.status = $2)
+		ORDER BY d.created_at DESC, d.id
+		LIMIT 100`
+	if err := r.db.SelectContext(ctx, &deliveries, query, subscriptionID, status); err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (r *postgresWebhookRepo) Redeliver(ctx context.Context, id uuid.UUID) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	query := `UPDATE webhook_deliveries d
+		SET status = 'PENDING', attempts = 0, next_attempt_at = CURRENT_TIMESTAMP
+		WHERE d.id = $1 AND d.status <> 'PENDING'
+		RETURNING ` + deliveryColumns
+	err := r.db.GetContext(ctx, &delivery, query, id)
+	if err == nil {
+		return &delivery, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("redeliver webhook: %w", err)
+	}
+
+	var exists bool
+	if err := r.db.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM webhook_deliveries WHERE id = $1)`, id); err != nil {
+		return nil, fmt.Errorf("redeliver webhook: %w", err)
+	}
+	if exists {
+		return nil, fmt.Errorf("%w: %s", repository.ErrDeliveryPending, id)
+	}
+	return nil, fmt.Errorf("%w: %s", repository.ErrDeliveryNotFound, id)
+}
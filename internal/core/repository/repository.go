@@ -2,13 +2,163 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/Nzyazin/itk/internal/core/models"
 	"github.com/google/uuid"
 )
 
+// OperationParams описывает операцию над кошельком в минимальных единицах валюты
+type OperationParams struct {
+	WalletID       uuid.UUID
+	TargetWalletID uuid.UUID // только для переводов
+	Amount         int64
+	OperationType  models.OperationType
+	// IdempotencyKey и RequestHash позволяют безопасно повторять запрос:
+	// повтор с тем же ключом и тем же содержимым возвращает исходный результат
+	IdempotencyKey string
+	RequestHash    string
+	Actor          string // клиент API, выполнивший операцию; пусто для внутренних операций
+}
+
+// ReversalOutcome описывает результат возврата операции
+type ReversalOutcome struct {
+	Original *models.Transaction
+	Reversal *models.Transaction
+	Balance  int64
+}
+
+// MovementIterator передаёт в visit по одной операции выписки, не загружая их в память целиком;
+// ошибка visit прекращает перебор
+type MovementIterator func(visit func(models.Transaction) error) error
+
+// BatchItemOutcome описывает результат одной операции пакета: новый баланс кошелька
+// или ошибку, из-за которой операция не выполнена
+type BatchItemOutcome struct {
+	Balance int64
+	Err     error
+}
+
+// ConversionOutcome описывает результат обмена по котировке
+type ConversionOutcome struct {
+	Debit   *models.Transaction
+	Credit  *models.Transaction
+	Balance int64 // баланс кошелька-источника
+}
+
+// ExchangeRateProvider возвращает курс обмена, действующий в момент at.
+// Если курс задан только для обратной пары, используется обратное значение.
+type ExchangeRateProvider interface {
+	GetRate(ctx context.Context, base, quote string, at time.Time) (*models.ExchangeRate, error)
+}
+
+// ExchangeRateRepository хранит курсы обмена с периодами действия
+type ExchangeRateRepository interface {
+	ExchangeRateProvider
+	AddRate(ctx context.Context, rate *models.ExchangeRate) error
+}
+
+// OutboxRepository выдаёт неопубликованные события в порядке записи
+type OutboxRepository interface {
+	// ProcessOutbox передаёт publish очередную пачку событий, если её не обрабатывает другой
+	// ретранслятор. publish возвращает результат публикации каждого события; события с nil
+	// отмечаются опубликованными, события с ErrEventDeferred выдаются повторно без изменений,
+	// остальные считаются неудачной попыткой и повторяются с задержкой, а после исчерпания попыток
+	// откладываются. События кошелька, следующие за неудачным, не выдаются, пока оно не опубликовано,
+	// поэтому не занимают пачку. Возвращает число опубликованных.
+	ProcessOutbox(ctx context.Context, limit int, publish func(events []models.OutboxEvent) []error) (int, error)
+}
+
+// WebhookRepository хранит подписки на события и очередь их доставки
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error
+	GetSubscription(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error)
+	// ListSubscriptions возвращает подписки кошелька вместе с общими; nil - все подписки
+	ListSubscriptions(ctx context.Context, walletID *uuid.UUID) ([]models.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id uuid.UUID) error
+
+	// EnqueueDeliveries ставит событие в очередь по всем подходящим подпискам. Повторная
+	// постановка того же события не создаёт новых доставок.
+	EnqueueDeliveries(ctx context.Context, event models.OutboxEvent, payload []byte) (int, error)
+	// ClaimDueDeliveries выдаёт доставки, время попытки которых наступило, и откладывает их
+	// на lease, чтобы их не отправил одновременно другой экземпляр сервиса
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	MarkDelivered(ctx context.Context, id uuid.UUID, statusCode int) error
+	MarkFailed(ctx context.Context, id uuid.UUID, attempt models.DeliveryAttempt) error
+	ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, status models.DeliveryStatus) ([]models.WebhookDelivery, error)
+	// Redeliver возвращает доставленную или исчерпавшую попытки доставку в очередь
+	Redeliver(ctx context.Context, id uuid.UUID) (*models.WebhookDelivery, error)
+}
+
+// APIKeyRepository хранит хэши выпущенных API-ключей
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key *models.APIKey) error
+	GetAPIKey(ctx context.Context, id uuid.UUID) (*models.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) (*models.APIKey, error)
+	// RotateAPIKey сохраняет новый ключ и ограничивает срок действия старого моментом expiresAt
+	// в одной транзакции. Отозванный ключ ротировать нельзя.
+	RotateAPIKey(ctx context.Context, id uuid.UUID, next *models.APIKey, expiresAt time.Time) error
+}
+
+// CurrencyRepository управляет справочником валют
+type CurrencyRepository interface {
+	List(ctx context.Context) ([]models.Currency, error)
+	GetByCode(ctx context.Context, code string) (*models.Currency, error)
+	Create(ctx context.Context, currency *models.Currency) error
+	// Update изменяет валюту; точность нельзя изменить, пока на кошельках в этой валюте есть средства
+	Update(ctx context.Context, currency *models.Currency) error
+	SetActive(ctx context.Context, code string, active bool) (*models.Currency, error)
+	// Delete удаляет валюту, на которую не ссылается ни один кошелёк
+	Delete(ctx context.Context, code string) error
+}
+
 type WalletRepository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Wallet, error)
+	GetByIDs(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*models.Wallet, error)
+	Create(ctx context.Context, wallet *models.Wallet) error
+	SetOverdraftLimit(ctx context.Context, id uuid.UUID, limit int64) (*models.Wallet, error)
+	// ChangeStatusWithRetry заполняет FromStatus, ID и CreatedAt изменения
+	ChangeStatusWithRetry(ctx context.Context, change *models.WalletStatusChange) (*models.Wallet, error)
+	ListStatusHistory(ctx context.Context, walletID uuid.UUID) ([]models.WalletStatusChange, error)
 	GetCurrencyByCode(ctx context.Context, code string) (*models.Currency, error)
-    ExecuteTxWithRetry(ctx context.Context, walletID uuid.UUID, amount int64, opType models.OperationType) (int64, error)
+	ListTransactions(ctx context.Context, filter models.TransactionFilter) ([]models.Transaction, error)
+	// GetBalanceAsOf возвращает баланс кошелька после всех операций, проведённых не позже at
+	GetBalanceAsOf(ctx context.Context, walletID uuid.UUID, at time.Time) (int64, error)
+	// ReadStatement читает в одном снимке данных остатки кошелька на начало и конец периода [from, to)
+	// и передаёт их в fn вместе с перебором операций периода в порядке проведения
+	ReadStatement(ctx context.Context, walletID uuid.UUID, from, to time.Time, fn func(models.StatementBalances, MovementIterator) error) error
+    ExecuteTxWithRetry(ctx context.Context, params OperationParams) (int64, error)
+	TransferTxWithRetry(ctx context.Context, params OperationParams) (int64, error)
+	// ExecuteBatchWithRetry выполняет операции по порядку в одной транзакции. В режиме atomic первая
+	// неудачная операция откатывает весь пакет, иначе откатывается только она сама
+	ExecuteBatchWithRetry(ctx context.Context, items []OperationParams, atomic bool) ([]BatchItemOutcome, error)
+	// ExecuteWalletOperationsWithRetry выполняет зачисления и списания одного кошелька по порядку
+	// в одной транзакции с одним изменением баланса. Каждая операция проверяется отдельно,
+	// и её ошибка возвращается в результате, не затрагивая остальные
+	ExecuteWalletOperationsWithRetry(ctx context.Context, walletID uuid.UUID, items []OperationParams) ([]BatchItemOutcome, error)
+	RecordFailedOperation(ctx context.Context, params OperationParams, reason models.FailureReason) error
+
+	GetHold(ctx context.Context, id uuid.UUID) (*models.Hold, error)
+	// Методы, создающие операции, сохраняют в них actor - клиента API, выполнившего запрос
+	AuthorizeHoldWithRetry(ctx context.Context, hold *models.Hold, actor string) error
+	CaptureHoldWithRetry(ctx context.Context, holdID uuid.UUID, amount int64, actor string) (*models.Hold, error)
+	VoidHoldWithRetry(ctx context.Context, holdID uuid.UUID) (*models.Hold, error)
+	ExpireHolds(ctx context.Context, limit int) (int, error)
+
+	GetTransaction(ctx context.Context, id uuid.UUID) (*models.Transaction, error)
+	ReverseTransactionWithRetry(ctx context.Context, transactionID uuid.UUID, amount int64, actor string) (*ReversalOutcome, error)
+
+	CreateQuote(ctx context.Context, quote *models.FXQuote) error
+	GetQuote(ctx context.Context, id uuid.UUID) (*models.FXQuote, error)
+	ConvertWithRetry(ctx context.Context, quoteID uuid.UUID, actor string) (*ConversionOutcome, error)
+
+	// Лимиты проверяются при каждом изменении баланса внутри транзакции операции
+	GetSpendingLimits(ctx context.Context, scope models.LimitScope) (*models.SpendingLimits, error)
+	SetSpendingLimits(ctx context.Context, limits *models.SpendingLimits) error
+	DeleteSpendingLimits(ctx context.Context, scope models.LimitScope) error
+
+	GetLedgerBalance(ctx context.Context, walletID uuid.UUID) (*models.WalletLedgerBalance, error)
+	ReconcileLedger(ctx context.Context) (*models.LedgerReconciliation, error)
 }
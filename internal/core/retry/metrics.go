@@ -0,0 +1,31 @@
+package retry
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	outcomeSuccess   = "success"
+	outcomeFailed    = "failed" // ошибка, которую нельзя повторить
+	outcomeExhausted = "exhausted"
+	outcomeCanceled  = "canceled"
+)
+
+var attemptsHistogram = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "wallet_retry_attempts",
+	Help:    "Number of attempts made by retried operations.",
+	Buckets: []float64{1, 2, 3, 5, 8, 13, 21},
+}, []string{"operation", "outcome"})
+
+var retriesCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "wallet_retries_total",
+	Help: "Number of repeated attempts made after retryable errors.",
+}, []string{"operation"})
+
+func observe(operation, outcome string, attempts int) {
+	attemptsHistogram.WithLabelValues(operation, outcome).Observe(float64(attempts))
+	if attempts > 1 {
+		retriesCounter.WithLabelValues(operation).Add(float64(attempts - 1))
+	}
+}
@@ -0,0 +1,98 @@
+// Package retry повторяет операции, завершившиеся временной ошибкой, с экспоненциальной
+// задержкой и случайным разбросом. Повторы ограничены числом попыток, общим временем
+// и контекстом запроса.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// ErrExhausted означает, что попытки или отведённое на них время закончились
+var ErrExhausted = errors.New("retries exhausted")
+
+// Policy задаёт правила повторов
+type Policy struct {
+	MaxAttempts int           // наибольшее число попыток, включая первую
+	MaxElapsed  time.Duration // время, после которого новая попытка не начинается; 0 - без ограничения
+	BaseDelay   time.Duration // задержка перед второй попыткой; далее удваивается
+	MaxDelay    time.Duration // наибольшая задержка между попытками
+}
+
+// DefaultPolicy укладывается в таймаут HTTP-запроса с запасом на саму операцию
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts: 10,
+		MaxElapsed:  5 * time.Second,
+		BaseDelay:   10 * time.Millisecond,
+		MaxDelay:    time.Second,
+	}
+}
+
+// Validate проверяет, что политика допускает хотя бы одну попытку
+func (p Policy) Validate() error {
+	switch {
+	case p.MaxAttempts < 1:
+		return errors.New("retry: max attempts must be at least 1")
+	case p.MaxElapsed < 0 || p.BaseDelay < 0 || p.MaxDelay < 0:
+		return errors.New("retry: durations must not be negative")
+	case p.MaxDelay < p.BaseDelay:
+		return errors.New("retry: max delay must not be less than base delay")
+	}
+	return nil
+}
+
+// Do выполняет fn, пока она возвращает ошибку, для которой retryable возвращает true.
+// Ожидание прерывается отменой ctx; попытка, которая не успеет начаться до истечения
+// MaxElapsed, не выполняется. Число попыток каждой операции учитывается в метриках.
+func (p Policy) Do(ctx context.Context, operation string, retryable func(error) bool, fn func() error) error {
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			observe(operation, outcomeSuccess, attempt)
+			return nil
+		}
+		if !retryable(err) {
+			observe(operation, outcomeFailed, attempt)
+			return err
+		}
+		if attempt >= p.MaxAttempts {
+			observe(operation, outcomeExhausted, attempt)
+			return fmt.Errorf("%w after %d attempts: %w", ErrExhausted, attempt, err)
+		}
+
+		delay := p.Backoff(attempt)
+		if p.MaxElapsed > 0 && time.Since(start)+delay > p.MaxElapsed {
+			observe(operation, outcomeExhausted, attempt)
+			return fmt.Errorf("%w after %d attempts in %s: %w", ErrExhausted, attempt, time.Since(start).Round(time.Millisecond), err)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			observe(operation, outcomeCanceled, attempt)
+			return fmt.Errorf("retry %s: %w", operation, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// Backoff возвращает случайную задержку после неудачной попытки attempt: от нуля до
+// BaseDelay·2^(attempt-1), но не больше MaxDelay. Разброс не даёт конфликтующим
+// транзакциям повторяться одновременно и снова мешать друг другу.
+func (p Policy) Backoff(attempt int) time.Duration {
+	ceiling := p.BaseDelay
+	for i := 1; i < attempt && ceiling < p.MaxDelay; i++ {
+		ceiling *= 2
+	}
+	ceiling = min(ceiling, p.MaxDelay)
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling + 1)
+}
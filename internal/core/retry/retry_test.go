@@ -0,0 +1,107 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errConflict = errors.New("serialization failure")
+
+func isConflict(err error) bool {
+	return errors.Is(err, errConflict)
+}
+
+func testPolicy() Policy {
+	return Policy{MaxAttempts: 5, BaseDelay: time.Millisecond, MaxDelay: 4 * time.Millisecond}
+}
+
+func TestDoRetriesUntilSuccess(t *testing.T) {
+	calls := 0
+	err := testPolicy().Do(context.Background(), "test", isConflict, func() error {
+		calls++
+		if calls < 3 {
+			return errConflict
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestDoStopsOnPermanentError(t *testing.T) {
+	permanent := errors.New("insufficient funds")
+	calls := 0
+	err := testPolicy().Do(context.Background(), "test", isConflict, func() error {
+		calls++
+		return permanent
+	})
+
+	assert.Same(t, permanent, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestDoExhaustsAttempts(t *testing.T) {
+	calls := 0
+	err := testPolicy().Do(context.Background(), "test", isConflict, func() error {
+		calls++
+		return errConflict
+	})
+
+	assert.ErrorIs(t, err, ErrExhausted)
+	assert.ErrorIs(t, err, errConflict)
+	assert.Equal(t, 5, calls)
+}
+
+func TestDoRespectsMaxElapsed(t *testing.T) {
+	policy := Policy{MaxAttempts: 1000, MaxElapsed: 30 * time.Millisecond, BaseDelay: 5 * time.Millisecond, MaxDelay: 5 * time.Millisecond}
+	start := time.Now()
+	err := policy.Do(context.Background(), "test", isConflict, func() error { return errConflict })
+
+	assert.ErrorIs(t, err, ErrExhausted)
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestDoAbortsOnContextCancel(t *testing.T) {
+	policy := Policy{MaxAttempts: 10, BaseDelay: time.Hour, MaxDelay: time.Hour}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	calls := 0
+	done := make(chan error)
+	go func() {
+		done <- policy.Do(ctx, "test", isConflict, func() error {
+			calls++
+			return errConflict
+		})
+	}()
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, calls)
+	case <-time.After(time.Second):
+		t.Fatal("retry did not stop after context cancellation")
+	}
+}
+
+func TestBackoffBounds(t *testing.T) {
+	policy := Policy{BaseDelay: 10 * time.Millisecond, MaxDelay: 80 * time.Millisecond}
+	for attempt, ceiling := range map[int]time.Duration{1: 10, 2: 20, 3: 40, 4: 80, 10: 80} {
+		for i := 0; i < 100; i++ {
+			delay := policy.Backoff(attempt)
+			assert.GreaterOrEqual(t, delay, time.Duration(0))
+			assert.LessOrEqual(t, delay, ceiling*time.Millisecond)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, DefaultPolicy().Validate())
+	assert.Error(t, Policy{MaxAttempts: 0}.Validate())
+	assert.Error(t, Policy{MaxAttempts: 1, BaseDelay: time.Second, MaxDelay: time.Millisecond}.Validate())
+}
@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/auth"
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/google/uuid"
+)
+
+// maxRotationGracePeriod ограничивает время, в течение которого старый ключ действует после ротации
+const maxRotationGracePeriod = 7 * 24 * time.Hour
+
+// APIKeyUsecase выпускает, ротирует и отзывает API-ключи
+type APIKeyUsecase interface {
+	CreateAPIKey(ctx context.Context, req models.APIKeyRequest) (*models.IssuedAPIKey, error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	RotateAPIKey(ctx context.Context, id uuid.UUID, req models.RotateAPIKeyRequest) (*models.IssuedAPIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) (*models.APIKey, error)
+}
+
+type apiKeyUsecase struct {
+	repo repository.APIKeyRepository
+	log  logger.Logger
+}
+
+func NewAPIKeyUsecase(repo repository.APIKeyRepository, log logger.Logger) APIKeyUsecase {
+	return &apiKeyUsecase{repo: repo, log: log}
+}
+
+// CreateAPIKey выпускает ключ; сам ключ возвращается только в ответе
+func (uc *apiKeyUsecase) CreateAPIKey(ctx context.Context, req models.APIKeyRequest) (*models.IssuedAPIKey, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidAPIKey)
+	}
+	if len(req.Scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidAPIKey)
+	}
+	for _, scope := range req.Scopes {
+		if !scope.IsValid() {
+			return nil, fmt.Errorf("%w: unknown scope %s", ErrInvalidAPIKey, scope)
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidAPIKey)
+	}
+
+	issued, err := newAPIKey(name, req.Scopes, req.WalletIDs)
+	if err != nil {
+		return nil, err
+	}
+	issued.ExpiresAt = req.ExpiresAt
+
+	if err := uc.repo.CreateAPIKey(ctx, issued.APIKey); err != nil {
+		return nil, err
+	}
+
+	uc.log.Info("API key created",
+		logger.StringField("key_id", issued.ID.String()),
+		logger.StringField("name", issued.Name),
+		logger.StringField("created_by", auth.Actor(ctx)))
+	return issued, nil
+}
+
+func (uc *apiKeyUsecase) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	return uc.repo.ListAPIKeys(ctx)
+}
+
+// RotateAPIKey выпускает ключ с теми же правами. Старый ключ действует ещё GracePeriodSeconds,
+// чтобы клиент успел перейти на новый; при нулевом периоде он перестаёт действовать сразу.
+func (uc *apiKeyUsecase) RotateAPIKey(ctx context.Context, id uuid.UUID, req models.RotateAPIKeyRequest) (*models.IssuedAPIKey, error) {
+	grace := time.Duration(req.GracePeriodSeconds) * time.Second
+	if grace < 0 || grace > maxRotationGracePeriod {
+		return nil, fmt.Errorf("%w: grace_period_seconds must be between 0 and %d",
+			ErrInvalidAPIKey, int64(maxRotationGracePeriod/time.Second))
+	}
+
+	current, err := uc.repo.GetAPIKey(ctx, id)
+	if err != nil {
+		return nil, mapAPIKeyError(err)
+	}
+
+	issued, err := newAPIKey(current.Name, current.Scopes, current.WalletIDs)
+	if err != nil {
+		return nil, err
+	}
+	issued.ExpiresAt = current.ExpiresAt
+
+	if err := uc.repo.RotateAPIKey(ctx, id, issued.APIKey, time.Now().Add(grace)); err != nil {
+		return nil, mapAPIKeyError(err)
+	}
+
+	uc.log.Info("API key rotated",
+		logger.StringField("key_id", id.String()),
+		logger.StringField("new_key_id", issued.ID.String()),
+		logger.StringField("rotated_by", auth.Actor(ctx)))
+	return issued, nil
+}
+
+// RevokeAPIKey отзывает ключ немедленно
+func (uc *apiKeyUsecase) RevokeAPIKey(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
+	key, err := uc.repo.RevokeAPIKey(ctx, id)
+	if err != nil {
+		return nil, mapAPIKeyError(err)
+	}
+
+	uc.log.Info("API key revoked",
+		logger.StringField("key_id", id.String()),
+		logger.StringField("revoked_by", auth.Actor(ctx)))
+	return key, nil
+}
+
+func newAPIKey(name string, scopes []models.Scope, walletIDs []uuid.UUID) (*models.IssuedAPIKey, error) {
+	key, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	if walletIDs == nil {
+		walletIDs = []uuid.UUID{}
+	}
+	return &models.IssuedAPIKey{
+		APIKey: &models.APIKey{
+			ID:        uuid.New(),
+			Name:      name,
+			Prefix:    prefix,
+			Hash:      hash,
+			Scopes:    scopes,
+			WalletIDs: walletIDs,
+		},
+		Key: key,
+	}, nil
+}
+
+func mapAPIKeyError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrAPIKeyNotFound):
+		return fmt.Errorf("%w: %v", ErrAPIKeyNotFound, err)
+	case errors.Is(err, repository.ErrAPIKeyRevoked):
+		return fmt.Errorf("%w: %v", ErrAPIKeyRevoked, err)
+	}
+	return err
+}
@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/google/uuid"
+)
+
+// MaxBatchSize ограничивает число операций в пакете, чтобы транзакция пакета оставалась короткой
+const MaxBatchSize = 1000
+
+// ProcessBatch выполняет пакет операций по порядку в одной транзакции. Кошельки и валюты
+// загружаются один раз на пакет; баланс проверяется только в транзакции, так как операции
+// пакета могут менять баланс одного и того же кошелька.
+func (uc *walletUsecase) ProcessBatch(ctx context.Context, req models.BatchRequest) (*models.BatchResult, error) {
+	req.Mode = models.BatchMode(strings.ToUpper(string(req.Mode)))
+	if req.Mode == "" {
+		req.Mode = models.BatchModeAtomic
+	}
+	if req.Mode != models.BatchModeAtomic && req.Mode != models.BatchModeBestEffort {
+		return nil, fmt.Errorf("%w: unknown mode %s", ErrInvalidBatch, req.Mode)
+	}
+	if len(req.Operations) == 0 || len(req.Operations) > MaxBatchSize {
+		return nil, fmt.Errorf("%w: batch must contain from 1 to %d operations", ErrInvalidBatch, MaxBatchSize)
+	}
+	atomic := req.Mode == models.BatchModeAtomic
+
+	wallets, err := uc.repo.GetByIDs(ctx, batchWalletIDs(req.Operations))
+	if err != nil {
+		return nil, fmt.Errorf("load wallets: %w", err)
+	}
+	currencies := make(map[string]*models.Currency)
+
+	result := &models.BatchResult{Mode: req.Mode, Items: make([]models.BatchItemResult, len(req.Operations))}
+	for i, op := range req.Operations {
+		result.Items[i] = models.BatchItemResult{Index: i, WalletID: op.WalletID}
+	}
+
+	items := make([]repository.OperationParams, 0, len(req.Operations))
+	positions := make([]int, 0, len(req.Operations))
+	for i, op := range req.Operations {
+		params, currency, err := uc.prepareBatchItem(ctx, op, wallets, currencies)
+		result.Items[i].Currency = currency
+		if err != nil {
+			uc.failBatchItem(ctx, result, i, params, err)
+			if atomic {
+				return result, nil
+			}
+			continue
+		}
+		items = append(items, params)
+		positions = append(positions, i)
+	}
+
+	outcomes, err := uc.repo.ExecuteBatchWithRetry(ctx, items, atomic)
+	if err != nil {
+		uc.log.Error("Batch failed", logger.ErrorField("error", err))
+		return nil, mapRepositoryError(err)
+	}
+
+	applied := 0
+	for k, outcome := range outcomes {
+		i := positions[k]
+		if outcome.Err != nil {
+			uc.failBatchItem(ctx, result, i, items[k], mapRepositoryError(outcome.Err))
+			if atomic {
+				return result, nil
+			}
+			continue
+		}
+
+		item := &result.Items[i]
+		item.Balance, err = uc.convertAmountFromMinorUnits(outcome.Balance, item.Currency)
+		if err != nil {
+			return nil, err
+		}
+		item.Status = models.BatchItemApplied
+		result.Committed = true
+		applied++
+	}
+
+	uc.log.Info("Batch processed",
+		logger.StringField("mode", string(req.Mode)),
+		logger.Int64Field("operations", int64(len(req.Operations))),
+		logger.Int64Field("applied", int64(applied)))
+	return result, nil
+}
+
+// prepareBatchItem проверяет операцию пакета и переводит её сумму в минимальные единицы
+func (uc *walletUsecase) prepareBatchItem(ctx context.Context, op models.WalletOperation, wallets map[uuid.UUID]*models.Wallet, currencies map[string]*models.Currency) (repository.OperationParams, *models.Currency, error) {
+	op.OperationType = models.OperationType(strings.ToUpper(string(op.OperationType)))
+	switch op.OperationType {
+	case models.OperationDeposit, models.OperationWithdraw:
+	case models.OperationTransfer:
+		if op.WalletID == op.TargetWalletID {
+			return repository.OperationParams{}, nil, ErrSameWallet
+		}
+	default:
+		return repository.OperationParams{}, nil, ErrInvalidOperationType
+	}
+
+	wallet, ok := wallets[op.WalletID]
+	if !ok {
+		return repository.OperationParams{}, nil, fmt.Errorf("%w: %s", ErrWalletNotFound, op.WalletID)
+	}
+	if err := uc.authorizeWallet(ctx, wallet); err != nil {
+		return repository.OperationParams{}, nil, err
+	}
+
+	currency, ok := currencies[wallet.CurrencyCode]
+	if !ok {
+		var err error
+		if currency, err = uc.getCurrency(ctx, wallet); err != nil {
+			return repository.OperationParams{}, nil, err
+		}
+		currencies[wallet.CurrencyCode] = currency
+	}
+
+	amount, err := uc.convertAmountToMinorUnits(op.Amount, currency)
+	if err != nil {
+		return repository.OperationParams{}, currency, err
+	}
+
+	params := uc.operationParams(op, wallet.ID, amount)
+	if op.OperationType == models.OperationTransfer {
+		params.TargetWalletID = op.TargetWalletID
+		target, ok := wallets[op.TargetWalletID]
+		if !ok {
+			return params, currency, fmt.Errorf("%w: %s", ErrWalletNotFound, op.TargetWalletID)
+		}
+		if target.CurrencyCode != wallet.CurrencyCode {
+			return params, currency, ErrCurrencyMismatch
+		}
+	}
+	return params, currency, nil
+}
+
+// failBatchItem отмечает операцию пакета как неудачную и сохраняет отказ в истории.
+// В атомарном пакете остальные операции отмечаются откаченными.
+func (uc *walletUsecase) failBatchItem(ctx context.Context, result *models.BatchResult, index int, params repository.OperationParams, err error) {
+	result.Items[index].Status = models.BatchItemFailed
+	result.Items[index].Err = err
+	if params.Amount > 0 {
+		uc.recordFailure(ctx, params, err)
+	}
+
+	if result.Mode != models.BatchModeAtomic {
+		return
+	}
+	for i := range result.Items {
+		if i != index {
+			result.Items[i].Status = models.BatchItemRolledBack
+		}
+	}
+	result.Committed = false
+	uc.log.Warn("Atomic batch rolled back",
+		logger.Int64Field("failed_index", int64(index)),
+		logger.ErrorField("error", err))
+}
+
+func batchWalletIDs(ops []models.WalletOperation) []uuid.UUID {
+	seen := make(map[uuid.UUID]struct{}, len(ops))
+	ids := make([]uuid.UUID, 0, len(ops))
+	add := func(id uuid.UUID) {
+		if _, ok := seen[id]; !ok && id != uuid.Nil {
+			seen[id] = struct{}{}
+			ids = append(ids, id)
+		}
+	}
+	for _, op := range ops {
+		add(op.WalletID)
+		add(op.TargetWalletID)
+	}
+	return ids
+}
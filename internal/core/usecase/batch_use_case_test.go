@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchRepoStub выполняет пакет над кошельками в памяти; операции из fails завершаются
+// заданной ошибкой, а в атомарном режиме прерывают пакет, как транзакция хранилища
+type batchRepoStub struct {
+	*walletRepoStub
+	fails   map[int]error
+	batches [][]repository.OperationParams
+}
+
+func newBatchRepoStub(wallets ...*models.Wallet) *batchRepoStub {
+	return &batchRepoStub{walletRepoStub: newWalletRepoStub(wallets...), fails: make(map[int]error)}
+}
+
+func (r *batchRepoStub) GetByIDs(_ context.Context, ids []uuid.UUID) (map[uuid.UUID]*models.Wallet, error) {
+	wallets := make(map[uuid.UUID]*models.Wallet)
+	for _, id := range ids {
+		if wallet, ok := r.wallets[id]; ok {
+			wallets[id] = wallet
+		}
+	}
+	return wallets, nil
+}
+
+func (r *batchRepoStub) ExecuteBatchWithRetry(_ context.Context, items []repository.OperationParams, atomic bool) ([]repository.BatchItemOutcome, error) {
+	r.batches = append(r.batches, items)
+	balances := make(map[uuid.UUID]int64)
+	for id, wallet := range r.wallets {
+		balances[id] = wallet.Balance
+	}
+
+	outcomes := make([]repository.BatchItemOutcome, len(items))
+	for i, params := range items {
+		if err, ok := r.fails[i]; ok {
+			outcomes[i].Err = err
+			if atomic {
+				return outcomes, nil
+			}
+			continue
+		}
+		balances[params.WalletID] += int64(params.OperationType.Sign()) * params.Amount
+		outcomes[i].Balance = balances[params.WalletID]
+	}
+	return outcomes, nil
+}
+
+func batchStatuses(result *models.BatchResult) []string {
+	statuses := make([]string, len(result.Items))
+	for i, item := range result.Items {
+		statuses[i] = item.Status
+	}
+	return statuses
+}
+
+func TestProcessBatchSize(t *testing.T) {
+	wallet := newWallet("USD", 0)
+	repo := newBatchRepoStub(wallet)
+	uc := newTestUsecase(t, repo)
+	ctx := context.Background()
+
+	deposits := func(n int) []models.WalletOperation {
+		ops := make([]models.WalletOperation, n)
+		for i := range ops {
+			ops[i] = models.WalletOperation{WalletID: wallet.ID, OperationType: models.OperationDeposit, Amount: "0.01"}
+		}
+		return ops
+	}
+
+	for _, n := range []int{0, MaxBatchSize + 1} {
+		_, err := uc.ProcessBatch(ctx, models.BatchRequest{Operations: deposits(n)})
+		assert.ErrorIs(t, err, ErrInvalidBatch, "%d operations", n)
+	}
+	assert.Empty(t, repo.batches)
+
+	result, err := uc.ProcessBatch(ctx, models.BatchRequest{Operations: deposits(MaxBatchSize)})
+	require.NoError(t, err)
+	assert.True(t, result.Committed)
+	require.Len(t, repo.batches, 1)
+	assert.Len(t, repo.batches[0], MaxBatchSize)
+	assert.Equal(t, "10", result.Items[MaxBatchSize-1].Balance.String())
+}
+
+func TestProcessBatchAtomicAbort(t *testing.T) {
+	first, second := newWallet("USD", 100), newWallet("JPY", 0)
+	repo := newBatchRepoStub(first, second)
+	repo.fails[1] = repository.ErrInsufficientFunds
+	uc := newTestUsecase(t, repo)
+
+	result, err := uc.ProcessBatch(context.Background(), models.BatchRequest{
+		Mode: "atomic",
+		Operations: []models.WalletOperation{
+			{WalletID: first.ID, OperationType: models.OperationDeposit, Amount: "1"},
+			{WalletID: first.ID, OperationType: models.OperationWithdraw, Amount: "5"},
+			{WalletID: second.ID, OperationType: models.OperationDeposit, Amount: "3"},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.False(t, result.Committed)
+	assert.Equal(t, models.BatchModeAtomic, result.Mode)
+	assert.Equal(t, []string{models.BatchItemRolledBack, models.BatchItemFailed, models.BatchItemRolledBack}, batchStatuses(result))
+	assert.ErrorIs(t, result.Items[1].Err, ErrInsufficientFunds)
+
+	require.Len(t, repo.failures, 1, "declined operation is recorded")
+	assert.Equal(t, models.FailureInsufficientFunds, repo.failures[0].reason)
+	assert.Equal(t, int64(500), repo.failures[0].params.Amount)
+}
+
+func TestProcessBatchAtomicInvalidOperationSkipsRepository(t *testing.T) {
+	wallet := newWallet("USD", 100)
+	repo := newBatchRepoStub(wallet)
+	uc := newTestUsecase(t, repo)
+
+	result, err := uc.ProcessBatch(context.Background(), models.BatchRequest{
+		Operations: []models.WalletOperation{
+			{WalletID: wallet.ID, OperationType: models.OperationDeposit, Amount: "1"},
+			{WalletID: uuid.New(), OperationType: models.OperationDeposit, Amount: "1"},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.False(t, result.Committed)
+	assert.Equal(t, []string{models.BatchItemRolledBack, models.BatchItemFailed}, batchStatuses(result))
+	assert.ErrorIs(t, result.Items[1].Err, ErrWalletNotFound)
+	assert.Empty(t, repo.batches, "atomic batch with an invalid operation must not reach the repository")
+}
+
+func TestProcessBatchBestEffort(t *testing.T) {
+	first, second := newWallet("USD", 100), newWallet("JPY", 0)
+	repo := newBatchRepoStub(first, second)
+	repo.fails[1] = repository.ErrInsufficientFunds
+	uc := newTestUsecase(t, repo)
+
+	result, err := uc.ProcessBatch(context.Background(), models.BatchRequest{
+		Mode: models.BatchModeBestEffort,
+		Operations: []models.WalletOperation{
+			{WalletID: first.ID, OperationType: models.OperationDeposit, Amount: "1"},
+			{WalletID: first.ID, OperationType: models.OperationWithdraw, Amount: "5"},
+			{WalletID: first.ID, OperationType: models.OperationDeposit, Amount: "0.001"},
+			{WalletID: second.ID, OperationType: models.OperationDeposit, Amount: "3"},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, result.Committed)
+	assert.Equal(t, []string{
+		models.BatchItemApplied, models.BatchItemFailed, models.BatchItemFailed, models.BatchItemApplied,
+	}, batchStatuses(result))
+	assert.ErrorIs(t, result.Items[1].Err, ErrInsufficientFunds)
+	assert.ErrorIs(t, result.Items[2].Err, ErrAmountPrecision)
+	assert.Equal(t, "2", result.Items[0].Balance.String())
+	assert.Equal(t, "3", result.Items[3].Balance.String())
+
+	// Операция со слишком точной суммой отклоняется до хранилища
+	require.Len(t, repo.batches, 1)
+	assert.Len(t, repo.batches[0], 3)
+}
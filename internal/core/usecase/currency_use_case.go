@@ -0,0 +1,176 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+)
+
+// CurrencyUsecase управляет справочником валют
+type CurrencyUsecase interface {
+	ListCurrencies(ctx context.Context) ([]models.Currency, error)
+	GetCurrency(ctx context.Context, code string) (*models.Currency, error)
+	CreateCurrency(ctx context.Context, req models.CurrencyRequest) (*models.Currency, error)
+	UpdateCurrency(ctx context.Context, req models.CurrencyRequest) (*models.Currency, error)
+	SetCurrencyActive(ctx context.Context, code string, active bool) (*models.Currency, error)
+	DeleteCurrency(ctx context.Context, code string) error
+}
+
+type currencyUsecase struct {
+	repo repository.CurrencyRepository
+	log  logger.Logger
+}
+
+func NewCurrencyUsecase(repo repository.CurrencyRepository, log logger.Logger) CurrencyUsecase {
+	return &currencyUsecase{repo: repo, log: log}
+}
+
+func (uc *currencyUsecase) ListCurrencies(ctx context.Context) ([]models.Currency, error) {
+	return uc.repo.List(ctx)
+}
+
+func (uc *currencyUsecase) GetCurrency(ctx context.Context, code string) (*models.Currency, error) {
+	code, err := normalizeCurrencyCode(code)
+	if err != nil {
+		return nil, err
+	}
+
+	currency, err := uc.repo.GetByCode(ctx, code)
+	if err != nil {
+		return nil, mapCurrencyError(err)
+	}
+	return currency, nil
+}
+
+// CreateCurrency добавляет валюту; новая валюта сразу доступна для кошельков
+func (uc *currencyUsecase) CreateCurrency(ctx context.Context, req models.CurrencyRequest) (*models.Currency, error) {
+	code, err := normalizeCurrencyCode(req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if req.MinorUnits == nil {
+		return nil, fmt.Errorf("%w: minor_units is required", ErrInvalidCurrencyData)
+	}
+
+	currency := &models.Currency{Code: code, IsActive: true}
+	if err := applyCurrencyRequest(currency, req); err != nil {
+		return nil, err
+	}
+
+	if err := uc.repo.Create(ctx, currency); err != nil {
+		return nil, mapCurrencyError(err)
+	}
+
+	uc.log.Info("Currency created",
+		logger.StringField("currency", currency.Code),
+		logger.Int64Field("minor_units", currency.MinorUnits))
+	return currency, nil
+}
+
+// UpdateCurrency изменяет название и точность валюты. Точность нельзя изменить,
+// пока на кошельках в этой валюте есть средства: иначе изменится смысл сохранённых сумм.
+func (uc *currencyUsecase) UpdateCurrency(ctx context.Context, req models.CurrencyRequest) (*models.Currency, error) {
+	code, err := normalizeCurrencyCode(req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	currency, err := uc.repo.GetByCode(ctx, code)
+	if err != nil {
+		return nil, mapCurrencyError(err)
+	}
+	if err := applyCurrencyRequest(currency, req); err != nil {
+		return nil, err
+	}
+
+	if err := uc.repo.Update(ctx, currency); err != nil {
+		uc.log.Warn("Currency update rejected",
+			logger.StringField("currency", code),
+			logger.ErrorField("error", err))
+		return nil, mapCurrencyError(err)
+	}
+
+	uc.log.Info("Currency updated", logger.StringField("currency", currency.Code))
+	return currency, nil
+}
+
+// SetCurrencyActive включает или отключает валюту для новых кошельков; существующие кошельки продолжают работать
+func (uc *currencyUsecase) SetCurrencyActive(ctx context.Context, code string, active bool) (*models.Currency, error) {
+	code, err := normalizeCurrencyCode(code)
+	if err != nil {
+		return nil, err
+	}
+
+	currency, err := uc.repo.SetActive(ctx, code, active)
+	if err != nil {
+		return nil, mapCurrencyError(err)
+	}
+
+	uc.log.Info("Currency activity changed",
+		logger.StringField("currency", code),
+		logger.AnyField("active", active))
+	return currency, nil
+}
+
+// DeleteCurrency удаляет валюту, в которой не открыт ни один кошелёк
+func (uc *currencyUsecase) DeleteCurrency(ctx context.Context, code string) error {
+	code, err := normalizeCurrencyCode(code)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.repo.Delete(ctx, code); err != nil {
+		return mapCurrencyError(err)
+	}
+
+	uc.log.Info("Currency deleted", logger.StringField("currency", code))
+	return nil
+}
+
+func normalizeCurrencyCode(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !currencyCodeRegexp.MatchString(code) {
+		return "", ErrInvalidCurrency
+	}
+	return code, nil
+}
+
+// applyCurrencyRequest переносит заданные в запросе поля в валюту и проверяет результат
+func applyCurrencyRequest(currency *models.Currency, req models.CurrencyRequest) error {
+	if name := strings.TrimSpace(req.Name); name != "" {
+		currency.Name = name
+	}
+	if req.MinorUnitName != nil {
+		currency.MinorUnitName = strings.TrimSpace(*req.MinorUnitName)
+	}
+	if req.MinorUnits != nil {
+		currency.MinorUnits = *req.MinorUnits
+	}
+
+	if currency.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidCurrencyData)
+	}
+	if currency.MinorUnits < 0 || currency.MinorUnits > models.MaxMinorUnits {
+		return fmt.Errorf("%w: minor_units must be between 0 and %d", ErrInvalidCurrencyData, models.MaxMinorUnits)
+	}
+	return nil
+}
+
+func mapCurrencyError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return fmt.Errorf("%w: %v", ErrCurrencyNotFound, err)
+	case errors.Is(err, repository.ErrAlreadyExists):
+		return fmt.Errorf("%w: %v", ErrCurrencyAlreadyExists, err)
+	case errors.Is(err, repository.ErrCurrencyInUse):
+		return fmt.Errorf("%w: %v", ErrCurrencyInUse, err)
+	case errors.Is(err, repository.ErrRetriesExhausted):
+		return fmt.Errorf("%w: %v", ErrRetriesExhausted, err)
+	}
+	return err
+}
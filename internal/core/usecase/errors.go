@@ -1,11 +1,110 @@
 package usecase
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Nzyazin/itk/internal/core/repository"
+)
 
 // Определение ошибок сервиса
 var (
 	ErrInvalidAmount      = errors.New("amount must be positive")
+	ErrAmountPrecision    = errors.New("amount has more decimal places than the currency allows")
 	ErrInvalidOperationType = errors.New("invalid operation type")
 	ErrWalletNotFound     = errors.New("wallet not found")
 	ErrInsufficientFunds  = errors.New("insufficient funds")
+	ErrInvalidCurrency    = errors.New("invalid currency code")
+	ErrCurrencyNotFound   = errors.New("currency not found")
+	ErrWalletAlreadyExists = errors.New("wallet already exists")
+	ErrCurrencyMismatch   = errors.New("wallet currencies do not match")
+	ErrSameWallet         = errors.New("source and target wallets must differ")
+	ErrIdempotencyConflict = errors.New("idempotency key already used with a different request")
+	ErrHoldNotFound       = errors.New("hold not found")
+	ErrHoldNotActive      = errors.New("hold is not active")
+	ErrHoldExpired        = errors.New("hold expired")
+	ErrCaptureExceedsHold = errors.New("capture amount exceeds held amount")
+	ErrInvalidHoldExpiry  = errors.New("invalid hold expiry")
+	ErrTransactionNotFound = errors.New("transaction not found")
+	ErrNotReversible      = errors.New("transaction cannot be reversed")
+	ErrAlreadyReversed    = errors.New("transaction already reversed")
+	ErrReversalExceedsOriginal = errors.New("reversal amount exceeds remaining original amount")
+	ErrRetriesExhausted   = errors.New("operation failed after all retries")
+	ErrInvalidCurrencyData = errors.New("invalid currency data")
+	ErrCurrencyAlreadyExists = errors.New("currency already exists")
+	ErrCurrencyInUse      = errors.New("currency is in use")
+	ErrCurrencyInactive   = errors.New("currency is inactive")
+	ErrSameCurrency       = errors.New("wallets have the same currency")
+	ErrInvalidExchangeRate = errors.New("invalid exchange rate")
+	ErrRateNotFound       = errors.New("exchange rate not available")
+	ErrInvalidBatch       = errors.New("invalid batch")
+	ErrRatesReadOnly      = errors.New("exchange rates are managed by an external provider")
+	ErrQuoteNotFound      = errors.New("quote not found")
+	ErrQuoteExpired       = errors.New("quote expired")
+	ErrQuoteUsed          = errors.New("quote already used")
+	ErrLimitExceeded      = errors.New("spending limit exceeded")
+	ErrInvalidLimit       = errors.New("invalid spending limit")
+	ErrWalletNotActive    = errors.New("operation not allowed for wallet status")
+	ErrInvalidWalletStatus = errors.New("invalid wallet status change")
+	ErrStatusTransition   = errors.New("wallet status transition not allowed")
+	ErrWalletNotEmpty     = errors.New("wallet has funds or holds")
+	ErrInvalidWebhook     = errors.New("invalid webhook subscription")
+	ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+	ErrDeliveryNotFound   = errors.New("webhook delivery not found")
+	ErrDeliveryPending    = errors.New("webhook delivery is still pending")
+	ErrForbidden          = errors.New("access to wallet denied")
+	ErrAPIKeyNotFound     = errors.New("api key not found")
+	ErrAPIKeyRevoked      = errors.New("api key revoked")
+	ErrInvalidAPIKey      = errors.New("invalid api key request")
+	ErrInvalidPeriod      = errors.New("invalid statement period")
+	ErrStatementTooLarge  = errors.New("statement period has too many transactions")
 )
+
+// mapRepositoryError переводит ошибки хранилища в ошибки сервиса, сохраняя исходное описание
+func mapRepositoryError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrInsufficientFunds):
+		return fmt.Errorf("%w: %v", ErrInsufficientFunds, err)
+	case errors.Is(err, repository.ErrCurrencyMismatch):
+		return fmt.Errorf("%w: %v", ErrCurrencyMismatch, err)
+	case errors.Is(err, repository.ErrIdempotencyConflict):
+		return fmt.Errorf("%w: %v", ErrIdempotencyConflict, err)
+	case errors.Is(err, repository.ErrHoldNotFound):
+		return fmt.Errorf("%w: %v", ErrHoldNotFound, err)
+	case errors.Is(err, repository.ErrHoldNotActive):
+		return fmt.Errorf("%w: %v", ErrHoldNotActive, err)
+	case errors.Is(err, repository.ErrHoldExpired):
+		return fmt.Errorf("%w: %v", ErrHoldExpired, err)
+	case errors.Is(err, repository.ErrCaptureExceedsHold):
+		return fmt.Errorf("%w: %v", ErrCaptureExceedsHold, err)
+	case errors.Is(err, repository.ErrTransactionNotFound):
+		return fmt.Errorf("%w: %v", ErrTransactionNotFound, err)
+	case errors.Is(err, repository.ErrNotReversible):
+		return fmt.Errorf("%w: %v", ErrNotReversible, err)
+	case errors.Is(err, repository.ErrAlreadyReversed):
+		return fmt.Errorf("%w: %v", ErrAlreadyReversed, err)
+	case errors.Is(err, repository.ErrReversalExceedsOriginal):
+		return fmt.Errorf("%w: %v", ErrReversalExceedsOriginal, err)
+	case errors.Is(err, repository.ErrRateNotFound):
+		return fmt.Errorf("%w: %v", ErrRateNotFound, err)
+	case errors.Is(err, repository.ErrQuoteNotFound):
+		return fmt.Errorf("%w: %v", ErrQuoteNotFound, err)
+	case errors.Is(err, repository.ErrQuoteExpired):
+		return fmt.Errorf("%w: %v", ErrQuoteExpired, err)
+	case errors.Is(err, repository.ErrQuoteUsed):
+		return fmt.Errorf("%w: %v", ErrQuoteUsed, err)
+	case errors.Is(err, repository.ErrWalletNotActive):
+		return fmt.Errorf("%w: %v", ErrWalletNotActive, err)
+	case errors.Is(err, repository.ErrStatusTransition):
+		return fmt.Errorf("%w: %v", ErrStatusTransition, err)
+	case errors.Is(err, repository.ErrWalletNotEmpty):
+		return fmt.Errorf("%w: %v", ErrWalletNotEmpty, err)
+	case errors.Is(err, repository.ErrLimitExceeded):
+		return fmt.Errorf("%w: %v", ErrLimitExceeded, err)
+	case errors.Is(err, repository.ErrRetriesExhausted):
+		return fmt.Errorf("%w: %v", ErrRetriesExhausted, err)
+	case errors.Is(err, repository.ErrNotFound):
+		return fmt.Errorf("%w: %v", ErrWalletNotFound, err)
+	}
+	return err
+}
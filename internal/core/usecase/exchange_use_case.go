@@ -0,0 +1,234 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/auth"
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// QuoteTTL - время, в течение которого котировка может быть использована для обмена
+const QuoteTTL = time.Minute
+
+// CreateQuote рассчитывает сумму зачисления по текущему курсу и фиксирует её вместе с курсом.
+// Сумма зачисления округляется вниз до точности валюты получателя.
+func (uc *walletUsecase) CreateQuote(ctx context.Context, req models.QuoteRequest) (*models.QuoteDetails, error) {
+	if req.WalletID == req.TargetWalletID {
+		return nil, ErrSameWallet
+	}
+
+	source, err := uc.getWallet(ctx, req.WalletID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := uc.lookupWallet(ctx, req.TargetWalletID)
+	if err != nil {
+		return nil, err
+	}
+	if source.CurrencyCode == target.CurrencyCode {
+		return nil, ErrSameCurrency
+	}
+
+	sourceCurrency, err := uc.getCurrency(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	targetCurrency, err := uc.getCurrency(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceAmount, err := uc.convertAmountToMinorUnits(req.Amount, sourceCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	rate, err := uc.rates.GetRate(ctx, sourceCurrency.Code, targetCurrency.Code, now)
+	if err != nil {
+		return nil, mapRepositoryError(err)
+	}
+
+	targetAmount := decimal.New(sourceAmount, -sourceCurrency.Scale()).
+		Mul(rate.Rate).
+		Shift(targetCurrency.Scale()).
+		Floor()
+	if targetAmount.Sign() <= 0 || targetAmount.GreaterThan(maxMinorAmount) {
+		return nil, fmt.Errorf("%w: converted amount is out of range", ErrInvalidAmount)
+	}
+
+	quote := &models.FXQuote{
+		ID:             uuid.New(),
+		WalletID:       source.ID,
+		TargetWalletID: target.ID,
+		SourceCurrency: sourceCurrency.Code,
+		TargetCurrency: targetCurrency.Code,
+		SourceAmount:   sourceAmount,
+		TargetAmount:   targetAmount.IntPart(),
+		Rate:           rate.Rate,
+		ExpiresAt:      now.Add(QuoteTTL),
+	}
+	if err := uc.repo.CreateQuote(ctx, quote); err != nil {
+		return nil, fmt.Errorf("create quote: %w", err)
+	}
+
+	uc.log.Info("FX quote created",
+		logger.StringField("quote_id", quote.ID.String()),
+		logger.StringField("pair", quote.SourceCurrency+"/"+quote.TargetCurrency),
+		logger.StringField("rate", quote.Rate.String()))
+	return quoteDetails(quote, sourceCurrency, targetCurrency), nil
+}
+
+// Convert выполняет обмен по ранее полученной котировке
+func (uc *walletUsecase) Convert(ctx context.Context, quoteID uuid.UUID) (*models.ConversionResult, error) {
+	quote, err := uc.repo.GetQuote(ctx, quoteID)
+	if err != nil {
+		return nil, mapRepositoryError(err)
+	}
+	if _, err := uc.getWallet(ctx, quote.WalletID); err != nil {
+		return nil, err
+	}
+
+	sourceCurrency, err := uc.getCurrencyByCode(ctx, quote.SourceCurrency)
+	if err != nil {
+		return nil, err
+	}
+	targetCurrency, err := uc.getCurrencyByCode(ctx, quote.TargetCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	outcome, err := uc.repo.ConvertWithRetry(ctx, quote.ID, auth.Actor(ctx))
+	if err != nil {
+		err = mapRepositoryError(err)
+		uc.recordFailure(ctx, repository.OperationParams{
+			WalletID:       quote.WalletID,
+			TargetWalletID: quote.TargetWalletID,
+			Amount:         quote.SourceAmount,
+			OperationType:  models.OperationConversionOut,
+			Actor:          auth.Actor(ctx),
+		}, err)
+		return nil, err
+	}
+
+	balance, err := uc.convertAmountFromMinorUnits(outcome.Balance, sourceCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.log.Info("Currency converted",
+		logger.StringField("quote_id", quote.ID.String()),
+		logger.StringField("debit_id", outcome.Debit.ID.String()),
+		logger.StringField("credit_id", outcome.Credit.ID.String()))
+	return &models.ConversionResult{
+		Quote:   quoteDetails(quote, sourceCurrency, targetCurrency),
+		Debit:   outcome.Debit,
+		Credit:  outcome.Credit,
+		Balance: balance,
+	}, nil
+}
+
+// AddExchangeRate добавляет курс пары; без ValidFrom курс действует с текущего момента.
+// Курсы можно добавлять, только если провайдер хранит их сам.
+func (uc *walletUsecase) AddExchangeRate(ctx context.Context, req models.ExchangeRateRequest) (*models.ExchangeRate, error) {
+	store, ok := uc.rates.(repository.ExchangeRateRepository)
+	if !ok {
+		return nil, ErrRatesReadOnly
+	}
+
+	base, err := normalizeCurrencyCode(req.BaseCurrency)
+	if err != nil {
+		return nil, err
+	}
+	quote, err := normalizeCurrencyCode(req.QuoteCurrency)
+	if err != nil {
+		return nil, err
+	}
+	if base == quote {
+		return nil, ErrSameCurrency
+	}
+
+	for _, code := range []string{base, quote} {
+		if _, err := uc.getCurrencyByCode(ctx, code); err != nil {
+			return nil, err
+		}
+	}
+
+	rateValue, err := decimal.NewFromString(strings.TrimSpace(req.Rate))
+	if err != nil || rateValue.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: rate must be a positive number", ErrInvalidExchangeRate)
+	}
+
+	rate := &models.ExchangeRate{
+		BaseCurrency:  base,
+		QuoteCurrency: quote,
+		Rate:          rateValue,
+		ValidFrom:     time.Now(),
+		ValidTo:       req.ValidTo,
+	}
+	if req.ValidFrom != nil {
+		rate.ValidFrom = *req.ValidFrom
+	}
+	if rate.ValidTo != nil && !rate.ValidTo.After(rate.ValidFrom) {
+		return nil, fmt.Errorf("%w: validTo must be after validFrom", ErrInvalidExchangeRate)
+	}
+
+	if err := store.AddRate(ctx, rate); err != nil {
+		return nil, fmt.Errorf("add exchange rate: %w", err)
+	}
+
+	uc.log.Info("Exchange rate added",
+		logger.StringField("pair", base+"/"+quote),
+		logger.StringField("rate", rate.Rate.String()))
+	return rate, nil
+}
+
+// GetExchangeRate возвращает курс пары, действующий в момент at
+func (uc *walletUsecase) GetExchangeRate(ctx context.Context, base, quote string, at time.Time) (*models.ExchangeRate, error) {
+	base, err := normalizeCurrencyCode(base)
+	if err != nil {
+		return nil, err
+	}
+	quote, err = normalizeCurrencyCode(quote)
+	if err != nil {
+		return nil, err
+	}
+
+	rate, err := uc.rates.GetRate(ctx, base, quote, at)
+	if err != nil {
+		return nil, mapRepositoryError(err)
+	}
+	return rate, nil
+}
+
+func (uc *walletUsecase) getCurrencyByCode(ctx context.Context, code string) (*models.Currency, error) {
+	currency, err := uc.repo.GetCurrencyByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrCurrencyNotFound, code)
+		}
+		uc.log.Error("Currency lookup failed",
+			logger.ErrorField("error", err),
+			logger.StringField("code", code))
+		return nil, fmt.Errorf("get currency: %w", err)
+	}
+	return currency, nil
+}
+
+func quoteDetails(quote *models.FXQuote, source, target *models.Currency) *models.QuoteDetails {
+	return &models.QuoteDetails{
+		Quote:          quote,
+		SourceCurrency: source,
+		TargetCurrency: target,
+		SourceAmount:   decimal.New(quote.SourceAmount, -source.Scale()),
+		TargetAmount:   decimal.New(quote.TargetAmount, -target.Scale()),
+	}
+}
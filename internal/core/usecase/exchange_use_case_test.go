@@ -0,0 +1,196 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ratesStub возвращает заданный курс для любой пары и запоминает, запрашивался ли он
+type ratesStub struct {
+	rate      decimal.Decimal
+	requested bool
+}
+
+func (s *ratesStub) GetRate(_ context.Context, base, quote string, at time.Time) (*models.ExchangeRate, error) {
+	s.requested = true
+	return &models.ExchangeRate{BaseCurrency: base, QuoteCurrency: quote, Rate: s.rate, ValidFrom: at}, nil
+}
+
+// quoteRepoStub хранит котировки в памяти и проверяет их срок так же, как хранилище
+type quoteRepoStub struct {
+	*walletRepoStub
+	quotes map[uuid.UUID]*models.FXQuote
+}
+
+func newQuoteRepoStub(wallets ...*models.Wallet) *quoteRepoStub {
+	return &quoteRepoStub{walletRepoStub: newWalletRepoStub(wallets...), quotes: make(map[uuid.UUID]*models.FXQuote)}
+}
+
+func (r *quoteRepoStub) CreateQuote(_ context.Context, quote *models.FXQuote) error {
+	r.quotes[quote.ID] = quote
+	return nil
+}
+
+func (r *quoteRepoStub) GetQuote(_ context.Context, id uuid.UUID) (*models.FXQuote, error) {
+	quote, ok := r.quotes[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", repository.ErrQuoteNotFound, id)
+	}
+	return quote, nil
+}
+
+func (r *quoteRepoStub) ConvertWithRetry(_ context.Context, quoteID uuid.UUID, _ string) (*repository.ConversionOutcome, error) {
+	quote := r.quotes[quoteID]
+	if !quote.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: %s", repository.ErrQuoteExpired, quoteID)
+	}
+	source := r.wallets[quote.WalletID]
+	source.Balance -= quote.SourceAmount
+	return &repository.ConversionOutcome{
+		Debit:   &models.Transaction{ID: uuid.New(), Amount: quote.SourceAmount},
+		Credit:  &models.Transaction{ID: uuid.New(), Amount: quote.TargetAmount},
+		Balance: source.Balance,
+	}, nil
+}
+
+func newExchangeUsecase(t *testing.T, repo repository.WalletRepository, rate string) (*walletUsecase, *ratesStub) {
+	t.Helper()
+	uc := newTestUsecase(t, repo)
+	rates := &ratesStub{rate: decimal.RequireFromString(rate)}
+	uc.rates = rates
+	return uc, rates
+}
+
+func TestCreateQuoteRoundsDownToTargetPrecision(t *testing.T) {
+	tests := []struct {
+		name         string
+		source       string
+		target       string
+		amount       string
+		rate         string
+		sourceAmount int64
+		targetAmount int64
+	}{
+		{"to currency without minor units", "USD", "JPY", "1.99", "151.237", 199, 300},
+		{"to currency with three minor units", "USD", "KWD", "10.01", "0.30712", 1001, 3074},
+		{"from currency without minor units", "JPY", "USD", "1000", "0.006612", 1000, 661},
+		{"from currency with three minor units", "KWD", "EUR", "0.005", "2.9999", 5, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, target := newWallet(tt.source, 1_000_000), newWallet(tt.target, 0)
+			repo := newQuoteRepoStub(source, target)
+			uc, _ := newExchangeUsecase(t, repo, tt.rate)
+
+			before := time.Now()
+			details, err := uc.CreateQuote(context.Background(), models.QuoteRequest{
+				WalletID: source.ID, TargetWalletID: target.ID, Amount: tt.amount,
+			})
+			require.NoError(t, err)
+
+			quote := details.Quote
+			assert.Equal(t, tt.sourceAmount, quote.SourceAmount)
+			assert.Equal(t, tt.targetAmount, quote.TargetAmount)
+			assert.True(t, quote.Rate.Equal(decimal.RequireFromString(tt.rate)))
+			assert.WithinDuration(t, before.Add(QuoteTTL), quote.ExpiresAt, time.Second)
+			assert.Same(t, quote, repo.quotes[quote.ID], "quote must be stored")
+		})
+	}
+}
+
+func TestCreateQuoteRejectsAmountRoundedToZero(t *testing.T) {
+	source, target := newWallet("JPY", 1000), newWallet("USD", 0)
+	repo := newQuoteRepoStub(source, target)
+	uc, _ := newExchangeUsecase(t, repo, "0.0066")
+
+	_, err := uc.CreateQuote(context.Background(), models.QuoteRequest{
+		WalletID: source.ID, TargetWalletID: target.ID, Amount: "1",
+	})
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+	assert.Empty(t, repo.quotes)
+}
+
+func TestCreateQuoteRejectsSameCurrency(t *testing.T) {
+	source, target := newWallet("USD", 1000), newWallet("USD", 0)
+	repo := newQuoteRepoStub(source, target)
+	uc, rates := newExchangeUsecase(t, repo, "1")
+
+	_, err := uc.CreateQuote(context.Background(), models.QuoteRequest{
+		WalletID: source.ID, TargetWalletID: target.ID, Amount: "1",
+	})
+	assert.ErrorIs(t, err, ErrSameCurrency)
+	assert.False(t, rates.requested, "rate must not be requested for the same currency")
+	assert.Empty(t, repo.quotes)
+
+	_, err = uc.CreateQuote(context.Background(), models.QuoteRequest{
+		WalletID: source.ID, TargetWalletID: source.ID, Amount: "1",
+	})
+	assert.ErrorIs(t, err, ErrSameWallet)
+}
+
+func TestConvertExpiredQuote(t *testing.T) {
+	source, target := newWallet("USD", 1000), newWallet("EUR", 0)
+	repo := newQuoteRepoStub(source, target)
+	uc, _ := newExchangeUsecase(t, repo, "0.9")
+	ctx := context.Background()
+
+	details, err := uc.CreateQuote(ctx, models.QuoteRequest{
+		WalletID: source.ID, TargetWalletID: target.ID, Amount: "5",
+	})
+	require.NoError(t, err)
+
+	details.Quote.ExpiresAt = time.Now().Add(-time.Second)
+	_, err = uc.Convert(ctx, details.Quote.ID)
+	assert.ErrorIs(t, err, ErrQuoteExpired)
+	assert.Equal(t, int64(1000), source.Balance)
+	assert.Empty(t, repo.failures, "expired quote is not a declined operation")
+}
+
+func TestConvertReturnsSourceBalance(t *testing.T) {
+	source, target := newWallet("USD", 1000), newWallet("EUR", 0)
+	repo := newQuoteRepoStub(source, target)
+	uc, _ := newExchangeUsecase(t, repo, "0.9")
+	ctx := context.Background()
+
+	details, err := uc.CreateQuote(ctx, models.QuoteRequest{
+		WalletID: source.ID, TargetWalletID: target.ID, Amount: "5",
+	})
+	require.NoError(t, err)
+
+	result, err := uc.Convert(ctx, details.Quote.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "5", result.Balance.String())
+	assert.Equal(t, "4.5", result.Quote.TargetAmount.String())
+}
+
+// currencyErrorRepo завершает чтение валют заданной ошибкой хранилища
+type currencyErrorRepo struct {
+	*walletRepoStub
+	err error
+}
+
+func (r *currencyErrorRepo) GetCurrencyByCode(_ context.Context, _ string) (*models.Currency, error) {
+	return nil, r.err
+}
+
+func TestGetCurrencyByCodeKeepsRepositoryErrors(t *testing.T) {
+	uc := newTestUsecase(t, newWalletRepoStub())
+	_, err := uc.getCurrencyByCode(context.Background(), "XXX")
+	assert.ErrorIs(t, err, ErrCurrencyNotFound)
+
+	dbErr := errors.New("pq: connection reset by peer")
+	uc = newTestUsecase(t, &currencyErrorRepo{walletRepoStub: newWalletRepoStub(), err: dbErr})
+	_, err = uc.getCurrencyByCode(context.Background(), "USD")
+	assert.ErrorIs(t, err, dbErr)
+	assert.NotErrorIs(t, err, ErrCurrencyNotFound, "repository failure must not look like an unknown currency")
+}
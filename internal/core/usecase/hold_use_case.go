@@ -0,0 +1,177 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/auth"
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	DefaultHoldTTL = 7 * 24 * time.Hour
+	MaxHoldTTL     = 30 * 24 * time.Hour
+	// expireHoldsBatch ограничивает количество резервов, освобождаемых за один проход
+	expireHoldsBatch = 500
+)
+
+// AuthorizeHold резервирует средства на кошельке до списания или отмены
+func (uc *walletUsecase) AuthorizeHold(ctx context.Context, req models.HoldRequest) (*models.HoldDetails, error) {
+	ttl := DefaultHoldTTL
+	if req.ExpiresIn != 0 {
+		ttl = time.Duration(req.ExpiresIn) * time.Second
+	}
+	if ttl <= 0 || ttl > MaxHoldTTL {
+		return nil, ErrInvalidHoldExpiry
+	}
+
+	wallet, err := uc.getWallet(ctx, req.WalletID)
+	if err != nil {
+		return nil, err
+	}
+
+	currency, err := uc.getCurrency(ctx, wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := uc.convertAmountToMinorUnits(req.Amount, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	// Доступный баланс проверяется только в транзакции резервирования: held_balance кошелька
+	// может включать истёкшие резервы, которые освобождаются там же
+	if err := uc.checkStatus(wallet, models.OperationHold); err != nil {
+		return nil, err
+	}
+
+	hold := &models.Hold{
+		ID:        uuid.New(),
+		WalletID:  wallet.ID,
+		Amount:    amount,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := uc.repo.AuthorizeHoldWithRetry(ctx, hold, auth.Actor(ctx)); err != nil {
+		return nil, mapRepositoryError(err)
+	}
+
+	uc.log.Info("Hold authorized",
+		logger.StringField("hold_id", hold.ID.String()),
+		logger.StringField("wallet_id", wallet.ID.String()),
+		logger.Int64Field("amount", amount))
+	return uc.holdDetails(hold, currency)
+}
+
+// CaptureHold списывает зарезервированные средства. Пустая сумма списывает резерв целиком,
+// при частичном списании остаток резерва освобождается.
+func (uc *walletUsecase) CaptureHold(ctx context.Context, holdID uuid.UUID, amountStr string) (*models.HoldDetails, error) {
+	hold, currency, err := uc.getHoldWithCurrency(ctx, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	var amount int64
+	if amountStr != "" {
+		amount, err = uc.convertAmountToMinorUnits(amountStr, currency)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	hold, err = uc.repo.CaptureHoldWithRetry(ctx, hold.ID, amount, auth.Actor(ctx))
+	if err != nil {
+		return nil, mapRepositoryError(err)
+	}
+
+	uc.log.Info("Hold captured",
+		logger.StringField("hold_id", hold.ID.String()),
+		logger.Int64Field("captured", hold.CapturedAmount))
+	return uc.holdDetails(hold, currency)
+}
+
+// VoidHold отменяет резерв без списания средств
+func (uc *walletUsecase) VoidHold(ctx context.Context, holdID uuid.UUID) (*models.HoldDetails, error) {
+	hold, currency, err := uc.getHoldWithCurrency(ctx, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	hold, err = uc.repo.VoidHoldWithRetry(ctx, hold.ID)
+	if err != nil {
+		return nil, mapRepositoryError(err)
+	}
+
+	uc.log.Info("Hold voided", logger.StringField("hold_id", hold.ID.String()))
+	return uc.holdDetails(hold, currency)
+}
+
+func (uc *walletUsecase) GetHold(ctx context.Context, holdID uuid.UUID) (*models.HoldDetails, error) {
+	hold, currency, err := uc.getHoldWithCurrency(ctx, holdID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.holdDetails(hold, currency)
+}
+
+// ExpireHolds освобождает истёкшие резервы; вызывается периодически фоновым процессом
+func (uc *walletUsecase) ExpireHolds(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		expired, err := uc.repo.ExpireHolds(ctx, expireHoldsBatch)
+		if err != nil {
+			uc.log.Error("Hold expiry failed", logger.ErrorField("error", err))
+			return total, fmt.Errorf("expire holds: %w", err)
+		}
+		total += expired
+		if expired < expireHoldsBatch {
+			break
+		}
+	}
+
+	if total > 0 {
+		uc.log.Info("Expired holds released", logger.Int64Field("count", int64(total)))
+	}
+	return total, nil
+}
+
+func (uc *walletUsecase) getHoldWithCurrency(ctx context.Context, holdID uuid.UUID) (*models.Hold, *models.Currency, error) {
+	hold, err := uc.repo.GetHold(ctx, holdID)
+	if err != nil {
+		return nil, nil, mapRepositoryError(err)
+	}
+
+	wallet, err := uc.getWallet(ctx, hold.WalletID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	currency, err := uc.getCurrency(ctx, wallet)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return hold, currency, nil
+}
+
+func (uc *walletUsecase) holdDetails(hold *models.Hold, currency *models.Currency) (*models.HoldDetails, error) {
+	amounts := make([]decimal.Decimal, 0, 2)
+	for _, v := range []int64{hold.Amount, hold.CapturedAmount} {
+		amount, err := uc.convertAmountFromMinorUnits(v, currency)
+		if err != nil {
+			return nil, err
+		}
+		amounts = append(amounts, amount)
+	}
+
+	return &models.HoldDetails{
+		Hold:           hold,
+		Currency:       currency,
+		Amount:         amounts[0],
+		CapturedAmount: amounts[1],
+	}, nil
+}
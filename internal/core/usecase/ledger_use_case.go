@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/google/uuid"
+)
+
+// GetLedgerBalance сопоставляет баланс кошелька с балансом, рассчитанным по проводкам журнала
+func (uc *walletUsecase) GetLedgerBalance(ctx context.Context, walletID uuid.UUID) (*models.WalletLedgerBalance, error) {
+	if _, err := uc.getWallet(ctx, walletID); err != nil {
+		return nil, err
+	}
+
+	balance, err := uc.repo.GetLedgerBalance(ctx, walletID)
+	if err != nil {
+		return nil, mapRepositoryError(err)
+	}
+
+	if !balance.IsConsistent() {
+		uc.log.Error("Wallet balance diverges from ledger",
+			logger.StringField("wallet_id", walletID.String()),
+			logger.Int64Field("balance", balance.Balance),
+			logger.Int64Field("ledger_balance", balance.LedgerBalance))
+	}
+
+	return balance, nil
+}
+
+// ReconcileLedger проверяет, что журнал сбалансирован и все балансы кошельков выводятся из проводок
+func (uc *walletUsecase) ReconcileLedger(ctx context.Context) (*models.LedgerReconciliation, error) {
+	reconciliation, err := uc.repo.ReconcileLedger(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reconcile ledger: %w", err)
+	}
+
+	if !reconciliation.IsBalanced() {
+		uc.log.Error("Ledger reconciliation failed",
+			logger.Int64Field("mismatches", int64(len(reconciliation.Mismatches))),
+			logger.AnyField("trial_balances", reconciliation.TrialBalances))
+	}
+
+	return reconciliation, nil
+}
@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+)
+
+// GetSpendingLimits возвращает лимиты, заданные для кошелька или валюты. Лимиты валюты
+// действуют для кошельков, у которых не задан собственный лимит того же вида.
+func (uc *walletUsecase) GetSpendingLimits(ctx context.Context, scope models.LimitScope) (*models.SpendingLimitsResult, error) {
+	scope, currency, err := uc.resolveLimitScope(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	limits, err := uc.repo.GetSpendingLimits(ctx, scope)
+	if err != nil {
+		return nil, mapRepositoryError(err)
+	}
+	return &models.SpendingLimitsResult{Limits: limits, Currency: currency}, nil
+}
+
+// SetSpendingLimits заменяет лимиты кошелька или валюты; суммы переводятся в минимальные единицы валюты
+func (uc *walletUsecase) SetSpendingLimits(ctx context.Context, scope models.LimitScope, req models.SpendingLimitsRequest) (*models.SpendingLimitsResult, error) {
+	scope, currency, err := uc.resolveLimitScope(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := &models.SpendingLimits{LimitScope: scope}
+	if limits.MaxWithdrawal, err = uc.limitAmount("max_withdrawal", req.MaxWithdrawal, currency); err != nil {
+		return nil, err
+	}
+	if limits.DailyWithdrawal, err = uc.limitAmount("daily_withdrawal", req.DailyWithdrawal, currency); err != nil {
+		return nil, err
+	}
+	if limits.MonthlyWithdrawal, err = uc.limitAmount("monthly_withdrawal", req.MonthlyWithdrawal, currency); err != nil {
+		return nil, err
+	}
+	if req.HourlyDeposits != nil && *req.HourlyDeposits <= 0 {
+		return nil, fmt.Errorf("%w: hourly_deposits must be positive", ErrInvalidLimit)
+	}
+	limits.HourlyDeposits = req.HourlyDeposits
+
+	if err := uc.repo.SetSpendingLimits(ctx, limits); err != nil {
+		return nil, mapRepositoryError(err)
+	}
+
+	uc.log.Info("Spending limits updated",
+		logger.StringField("scope", limitScopeName(scope)),
+		logger.AnyField("limits", limits))
+	return &models.SpendingLimitsResult{Limits: limits, Currency: currency}, nil
+}
+
+// DeleteSpendingLimits снимает все лимиты кошелька или валюты
+func (uc *walletUsecase) DeleteSpendingLimits(ctx context.Context, scope models.LimitScope) error {
+	scope, _, err := uc.resolveLimitScope(ctx, scope)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.repo.DeleteSpendingLimits(ctx, scope); err != nil {
+		return mapRepositoryError(err)
+	}
+
+	uc.log.Info("Spending limits removed", logger.StringField("scope", limitScopeName(scope)))
+	return nil
+}
+
+// resolveLimitScope проверяет, что кошелёк или валюта существуют, и возвращает валюту, в которой заданы лимиты
+func (uc *walletUsecase) resolveLimitScope(ctx context.Context, scope models.LimitScope) (models.LimitScope, *models.Currency, error) {
+	if scope.WalletID != nil {
+		wallet, err := uc.getWallet(ctx, *scope.WalletID)
+		if err != nil {
+			return scope, nil, err
+		}
+		currency, err := uc.getCurrency(ctx, wallet)
+		return scope, currency, err
+	}
+
+	code, err := normalizeCurrencyCode(*scope.CurrencyCode)
+	if err != nil {
+		return scope, nil, err
+	}
+	currency, err := uc.getCurrencyByCode(ctx, code)
+	if err != nil {
+		return scope, nil, err
+	}
+	scope.CurrencyCode = &currency.Code
+	return scope, currency, nil
+}
+
+func (uc *walletUsecase) limitAmount(field string, amount *string, currency *models.Currency) (*int64, error) {
+	if amount == nil {
+		return nil, nil
+	}
+	minor, err := uc.convertAmountToMinorUnits(*amount, currency)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidLimit, field, err)
+	}
+	return &minor, nil
+}
+
+func limitScopeName(scope models.LimitScope) string {
+	if scope.WalletID != nil {
+		return "wallet " + scope.WalletID.String()
+	}
+	return "currency " + *scope.CurrencyCode
+}
+
@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Nzyazin/itk/internal/core/auth"
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+)
+
+// ReverseTransaction возвращает операцию полностью или частично. Компенсирующая операция
+// и отметка о возврате в исходной операции фиксируются в одной транзакции.
+func (uc *walletUsecase) ReverseTransaction(ctx context.Context, req models.ReversalRequest) (*models.ReversalResult, error) {
+	original, err := uc.repo.GetTransaction(ctx, req.TransactionID)
+	if err != nil {
+		return nil, mapRepositoryError(err)
+	}
+
+	if _, ok := original.OperationType.ReversalType(); !ok {
+		return nil, fmt.Errorf("%w: %s operations are not reversible", ErrNotReversible, original.OperationType)
+	}
+
+	wallet, err := uc.getWallet(ctx, original.WalletID)
+	if err != nil {
+		return nil, err
+	}
+
+	currency, err := uc.getCurrency(ctx, wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	var amount int64
+	if req.Amount != "" {
+		amount, err = uc.convertAmountToMinorUnits(req.Amount, currency)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	outcome, err := uc.repo.ReverseTransactionWithRetry(ctx, original.ID, amount, auth.Actor(ctx))
+	if err != nil {
+		uc.log.Warn("Reversal rejected",
+			logger.StringField("transaction_id", original.ID.String()),
+			logger.ErrorField("error", err))
+		return nil, mapRepositoryError(err)
+	}
+
+	reversedAmount, err := uc.convertAmountFromMinorUnits(outcome.Reversal.Amount, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := uc.convertAmountFromMinorUnits(outcome.Balance, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.log.Info("Transaction reversed",
+		logger.StringField("transaction_id", original.ID.String()),
+		logger.StringField("reversal_id", outcome.Reversal.ID.String()),
+		logger.Int64Field("amount", outcome.Reversal.Amount))
+
+	return &models.ReversalResult{
+		Original: outcome.Original,
+		Reversal: outcome.Reversal,
+		Currency: currency,
+		Amount:   reversedAmount,
+		Balance:  balance,
+	}, nil
+}
@@ -0,0 +1,44 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reversalRepoStub возвращает заданную операцию; вызов возврата в хранилище завершит тест паникой
+type reversalRepoStub struct {
+	*walletRepoStub
+	transaction *models.Transaction
+}
+
+func (r *reversalRepoStub) GetTransaction(_ context.Context, _ uuid.UUID) (*models.Transaction, error) {
+	return r.transaction, nil
+}
+
+func TestReverseTwoSidedOperationRejected(t *testing.T) {
+	wallet := newWallet("USD", 1000)
+	for _, operationType := range []models.OperationType{
+		models.OperationTransferOut, models.OperationTransferIn,
+		models.OperationConversionOut, models.OperationConversionIn,
+	} {
+		t.Run(string(operationType), func(t *testing.T) {
+			repo := &reversalRepoStub{
+				walletRepoStub: newWalletRepoStub(wallet),
+				transaction: &models.Transaction{
+					ID: uuid.New(), WalletID: wallet.ID, OperationType: operationType,
+					Amount: 100, Status: "COMPLETED",
+				},
+			}
+			uc := newTestUsecase(t, repo)
+
+			_, err := uc.ReverseTransaction(context.Background(), models.ReversalRequest{TransactionID: repo.transaction.ID})
+			require.ErrorIs(t, err, ErrNotReversible)
+			assert.Contains(t, err.Error(), string(operationType))
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/export"
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/google/uuid"
+)
+
+// MaxStatementTransactions ограничивает число операций в выписке, возвращаемой целиком
+const MaxStatementTransactions = 10000
+
+// errStatementLimit прерывает чтение выписки, в которой больше MaxStatementTransactions операций
+var errStatementLimit = errors.New("statement limit reached")
+
+// GetBalanceAsOf возвращает баланс кошелька после всех операций, проведённых не позже at
+func (uc *walletUsecase) GetBalanceAsOf(ctx context.Context, id uuid.UUID, at time.Time) (*models.BalanceAsOf, error) {
+	wallet, err := uc.getWallet(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	currency, err := uc.getCurrency(ctx, wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	minor, err := uc.repo.GetBalanceAsOf(ctx, id, at)
+	if err != nil {
+		uc.log.Error("Balance as of lookup failed",
+			logger.ErrorField("error", err),
+			logger.StringField("wallet_id", id.String()))
+		return nil, fmt.Errorf("get balance as of: %w", err)
+	}
+
+	balance, err := uc.convertAmountFromMinorUnits(minor, currency)
+	if err != nil {
+		return nil, err
+	}
+	return &models.BalanceAsOf{WalletID: id, At: at, Currency: currency, Balance: balance}, nil
+}
+
+// GetStatement возвращает выписку по кошельку за период [from, to)
+func (uc *walletUsecase) GetStatement(ctx context.Context, id uuid.UUID, from, to time.Time) (*models.Statement, error) {
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidPeriod)
+	}
+
+	wallet, err := uc.getWallet(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	currency, err := uc.getCurrency(ctx, wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &models.Statement{WalletID: id, From: from, To: to, Currency: currency}
+	err = uc.repo.ReadStatement(ctx, id, from, to, func(balances models.StatementBalances, movements repository.MovementIterator) error {
+		var err error
+		if statement.OpeningBalance, err = uc.convertAmountFromMinorUnits(balances.Opening, currency); err != nil {
+			return err
+		}
+		if statement.ClosingBalance, err = uc.convertAmountFromMinorUnits(balances.Closing, currency); err != nil {
+			return err
+		}
+
+		return movements(func(t models.Transaction) error {
+			if len(statement.Entries) == MaxStatementTransactions {
+				return errStatementLimit
+			}
+			entry, err := uc.transactionEntry(t, currency)
+			if err != nil {
+				return err
+			}
+			statement.Entries = append(statement.Entries, entry)
+			return nil
+		})
+	})
+	if errors.Is(err, errStatementLimit) {
+		return nil, fmt.Errorf("%w: more than %d, request a shorter period", ErrStatementTooLarge, MaxStatementTransactions)
+	}
+	if err != nil {
+		uc.log.Error("Statement lookup failed",
+			logger.ErrorField("error", err),
+			logger.StringField("wallet_id", id.String()))
+		return nil, fmt.Errorf("get statement: %w", err)
+	}
+
+	return statement, nil
+}
+
+// ExportStatement записывает выписку по кошельку за период [from, to) в w в формате format.
+// Операции записываются по мере чтения, поэтому размер выписки не ограничен. Ошибки до начала
+// записи (кошелёк не найден, неверный период) возвращаются до того, как в w что-либо записано.
+func (uc *walletUsecase) ExportStatement(ctx context.Context, id uuid.UUID, from, to time.Time, format export.Format, w io.Writer) error {
+	if !from.Before(to) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalidPeriod)
+	}
+
+	wallet, err := uc.getWallet(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	currency, err := uc.getCurrency(ctx, wallet)
+	if err != nil {
+		return err
+	}
+
+	writer, err := export.NewWriter(format, w)
+	if err != nil {
+		return err
+	}
+
+	err = uc.repo.ReadStatement(ctx, id, from, to, func(balances models.StatementBalances, movements repository.MovementIterator) error {
+		err := writer.Begin(export.Statement{
+			ID:        uuid.New(),
+			WalletID:  id,
+			Currency:  currency,
+			From:      from,
+			To:        to,
+			CreatedAt: time.Now().UTC(),
+			Balances:  balances,
+		})
+		if err != nil {
+			return err
+		}
+		if err := movements(writer.Write); err != nil {
+			return err
+		}
+		return writer.End()
+	})
+	if err != nil {
+		uc.log.Error("Statement export failed",
+			logger.ErrorField("error", err),
+			logger.StringField("wallet_id", id.String()),
+			logger.StringField("format", string(format)))
+		return fmt.Errorf("export statement: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+)
+
+const (
+	DefaultTransactionsLimit = 50
+	MaxTransactionsLimit     = 200
+)
+
+// ListTransactions возвращает страницу истории операций кошелька с суммами в валюте кошелька
+func (uc *walletUsecase) ListTransactions(ctx context.Context, filter models.TransactionFilter) (*models.TransactionPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultTransactionsLimit
+	}
+	if filter.Limit > MaxTransactionsLimit {
+		filter.Limit = MaxTransactionsLimit
+	}
+
+	wallet, err := uc.getWallet(ctx, filter.WalletID)
+	if err != nil {
+		return nil, err
+	}
+
+	currency, err := uc.getCurrency(ctx, wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	pageSize := filter.Limit
+	filter.Limit++
+	transactions, err := uc.repo.ListTransactions(ctx, filter)
+	if err != nil {
+		uc.log.Error("Transactions lookup failed",
+			logger.ErrorField("error", err),
+			logger.StringField("wallet_id", filter.WalletID.String()))
+		return nil, fmt.Errorf("list transactions: %w", err)
+	}
+
+	page := &models.TransactionPage{Currency: currency}
+	if len(transactions) > pageSize {
+		transactions = transactions[:pageSize]
+		last := transactions[pageSize-1]
+		page.Next = &models.TransactionCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	page.Entries = make([]models.TransactionEntry, 0, len(transactions))
+	for _, t := range transactions {
+		entry, err := uc.transactionEntry(t, currency)
+		if err != nil {
+			return nil, err
+		}
+		page.Entries = append(page.Entries, entry)
+	}
+
+	return page, nil
+}
+
+// transactionEntry переводит сумму операции и баланс после неё в основные единицы валюты кошелька
+func (uc *walletUsecase) transactionEntry(t models.Transaction, currency *models.Currency) (models.TransactionEntry, error) {
+	entry := models.TransactionEntry{Transaction: t}
+
+	var err error
+	entry.Amount, err = uc.convertAmountFromMinorUnits(t.Amount, currency)
+	if err != nil {
+		return entry, err
+	}
+	if t.BalanceAfter != nil {
+		balance, err := uc.convertAmountFromMinorUnits(*t.BalanceAfter, currency)
+		if err != nil {
+			return entry, err
+		}
+		entry.BalanceAfter = &balance
+	}
+	return entry, nil
+}
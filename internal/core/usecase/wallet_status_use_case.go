@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/google/uuid"
+)
+
+// ChangeWalletStatus переводит кошелёк в новый статус. Причина и инициатор обязательны
+// и сохраняются в истории; закрыть можно только кошелёк без средств и резервов.
+func (uc *walletUsecase) ChangeWalletStatus(ctx context.Context, id uuid.UUID, req models.WalletStatusRequest) (*models.WalletDetails, error) {
+	if _, err := uc.getWallet(ctx, id); err != nil {
+		return nil, err
+	}
+
+	change := &models.WalletStatusChange{
+		WalletID: id,
+		ToStatus: models.WalletStatus(strings.ToUpper(strings.TrimSpace(string(req.Status)))),
+		Reason:   strings.TrimSpace(req.Reason),
+		Actor:    strings.TrimSpace(req.Actor),
+	}
+	switch {
+	case !change.ToStatus.IsValid():
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidWalletStatus, req.Status)
+	case change.Reason == "":
+		return nil, fmt.Errorf("%w: reason is required", ErrInvalidWalletStatus)
+	case change.Actor == "":
+		return nil, fmt.Errorf("%w: actor is required", ErrInvalidWalletStatus)
+	}
+
+	wallet, err := uc.repo.ChangeStatusWithRetry(ctx, change)
+	if err != nil {
+		uc.log.Warn("Wallet status change rejected",
+			logger.StringField("wallet_id", id.String()),
+			logger.StringField("status", string(change.ToStatus)),
+			logger.ErrorField("error", err))
+		return nil, mapRepositoryError(err)
+	}
+
+	uc.log.Info("Wallet status changed",
+		logger.StringField("wallet_id", id.String()),
+		logger.StringField("from", string(change.FromStatus)),
+		logger.StringField("to", string(change.ToStatus)),
+		logger.StringField("actor", change.Actor),
+		logger.StringField("reason", change.Reason))
+
+	currency, err := uc.getCurrency(ctx, wallet)
+	if err != nil {
+		return nil, err
+	}
+	return uc.walletDetails(wallet, currency)
+}
+
+// ListWalletStatusHistory возвращает смены статуса кошелька от новых к старым
+func (uc *walletUsecase) ListWalletStatusHistory(ctx context.Context, id uuid.UUID) ([]models.WalletStatusChange, error) {
+	if _, err := uc.getWallet(ctx, id); err != nil {
+		return nil, err
+	}
+	return uc.repo.ListStatusHistory(ctx, id)
+}
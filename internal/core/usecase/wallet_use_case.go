@@ -2,62 +2,324 @@ package usecase
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"regexp"
 	"strings"
+	"time"
 
+	"github.com/Nzyazin/itk/internal/core/auth"
+	"github.com/Nzyazin/itk/internal/core/export"
 	"github.com/Nzyazin/itk/internal/core/logger"
 	"github.com/Nzyazin/itk/internal/core/models"
 	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 )
 
 type WalletUsecase interface {
-	OperateWallet(ctx context.Context, op models.WalletOperation) (decimal.Decimal, error)
+	OperateWallet(ctx context.Context, op models.WalletOperation) (*models.OperationResult, error)
+	Transfer(ctx context.Context, op models.WalletOperation) (*models.OperationResult, error)
+	ProcessBatch(ctx context.Context, req models.BatchRequest) (*models.BatchResult, error)
+	CreateWallet(ctx context.Context, req models.CreateWalletRequest) (*models.Wallet, error)
+	GetWallet(ctx context.Context, id uuid.UUID) (*models.WalletDetails, error)
+	SetOverdraftLimit(ctx context.Context, id uuid.UUID, limit string) (*models.WalletDetails, error)
+	ChangeWalletStatus(ctx context.Context, id uuid.UUID, req models.WalletStatusRequest) (*models.WalletDetails, error)
+	ListWalletStatusHistory(ctx context.Context, id uuid.UUID) ([]models.WalletStatusChange, error)
+	ListTransactions(ctx context.Context, filter models.TransactionFilter) (*models.TransactionPage, error)
+	GetBalanceAsOf(ctx context.Context, id uuid.UUID, at time.Time) (*models.BalanceAsOf, error)
+	GetStatement(ctx context.Context, id uuid.UUID, from, to time.Time) (*models.Statement, error)
+	ExportStatement(ctx context.Context, id uuid.UUID, from, to time.Time, format export.Format, w io.Writer) error
+	// AuthorizeWallet проверяет, что кошелёк доступен клиенту, выполняющему запрос
+	AuthorizeWallet(ctx context.Context, id uuid.UUID) error
+
+	AuthorizeHold(ctx context.Context, req models.HoldRequest) (*models.HoldDetails, error)
+	CaptureHold(ctx context.Context, holdID uuid.UUID, amount string) (*models.HoldDetails, error)
+	VoidHold(ctx context.Context, holdID uuid.UUID) (*models.HoldDetails, error)
+	GetHold(ctx context.Context, holdID uuid.UUID) (*models.HoldDetails, error)
+	ExpireHolds(ctx context.Context) (int, error)
+
+	ReverseTransaction(ctx context.Context, req models.ReversalRequest) (*models.ReversalResult, error)
+
+	CreateQuote(ctx context.Context, req models.QuoteRequest) (*models.QuoteDetails, error)
+	Convert(ctx context.Context, quoteID uuid.UUID) (*models.ConversionResult, error)
+	AddExchangeRate(ctx context.Context, req models.ExchangeRateRequest) (*models.ExchangeRate, error)
+	GetExchangeRate(ctx context.Context, base, quote string, at time.Time) (*models.ExchangeRate, error)
+
+	GetSpendingLimits(ctx context.Context, scope models.LimitScope) (*models.SpendingLimitsResult, error)
+	SetSpendingLimits(ctx context.Context, scope models.LimitScope, req models.SpendingLimitsRequest) (*models.SpendingLimitsResult, error)
+	DeleteSpendingLimits(ctx context.Context, scope models.LimitScope) error
+
+	GetLedgerBalance(ctx context.Context, walletID uuid.UUID) (*models.WalletLedgerBalance, error)
+	ReconcileLedger(ctx context.Context) (*models.LedgerReconciliation, error)
 }
 
+var currencyCodeRegexp = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// amountRegexp задаёт только формат суммы; допустимое число знаков после запятой зависит от валюты
+var amountRegexp = regexp.MustCompile(`^\d{1,30}(\.\d{1,30})?$`)
+
+// maxMinorAmount - наибольшая сумма в минимальных единицах, которую можно сохранить в BIGINT
+var maxMinorAmount = decimal.NewFromInt(math.MaxInt64)
+
 type walletUsecase struct {
-	repo repository.WalletRepository
-	log  logger.Logger
+	repo  repository.WalletRepository
+	rates repository.ExchangeRateProvider
+	log   logger.Logger
 }
 
-func NewWalletUsecase(repo repository.WalletRepository, log logger.Logger) WalletUsecase {
-	return &walletUsecase{repo: repo, log: log}
+func NewWalletUsecase(repo repository.WalletRepository, rates repository.ExchangeRateProvider, log logger.Logger) WalletUsecase {
+	return &walletUsecase{repo: repo, rates: rates, log: log}
 }
 
-func (uc *walletUsecase) OperateWallet(ctx context.Context, op models.WalletOperation) (decimal.Decimal, error) {
+func (uc *walletUsecase) OperateWallet(ctx context.Context, op models.WalletOperation) (*models.OperationResult, error) {
     uc.logStart(op)
     
-    wallet, err := uc.getWallet(ctx, op)
+    wallet, err := uc.getWallet(ctx, op.WalletID)
     if err != nil {
-        return decimal.Zero, err
+        return nil, err
     }
 
     currency, err := uc.getCurrency(ctx, wallet)
     if err != nil {
-        return decimal.Zero, err
+        return nil, err
     }
 
     amount, err := uc.convertAmountToMinorUnits(op.Amount, currency)
     if err != nil {
-        return decimal.Zero, err
+        return nil, err
+    }
+
+    params := uc.operationParams(op, wallet.ID, amount)
+    if op.OperationID == "" {
+        if err := uc.checkStatus(wallet, op.OperationType); err != nil {
+            uc.recordFailure(ctx, params, err)
+            return nil, err
+        }
+        if _, err := uc.checkBalance(wallet, amount, op.OperationType); err != nil {
+            uc.recordFailure(ctx, params, err)
+            return nil, err
+        }
+    }
+
+    newBalance, err := uc.repo.ExecuteTxWithRetry(ctx, params)
+    if err != nil {
+        err = mapRepositoryError(err)
+        uc.recordFailure(ctx, params, err)
+        return nil, err
+    }
+
+    return uc.operationResult(newBalance, currency)
+}
+
+// GetWallet возвращает кошелёк с балансом, переведённым в основные единицы валюты
+func (uc *walletUsecase) GetWallet(ctx context.Context, id uuid.UUID) (*models.WalletDetails, error) {
+    wallet, err := uc.getWallet(ctx, id)
+    if err != nil {
+        return nil, err
+    }
+
+    currency, err := uc.getCurrency(ctx, wallet)
+    if err != nil {
+        return nil, err
+    }
+
+    return uc.walletDetails(wallet, currency)
+}
+
+// SetOverdraftLimit открывает кошельку кредитную линию в основных единицах валюты; "0" закрывает её.
+// Уже использованный кредит сохраняется, но новые списания сверх лимита отклоняются.
+func (uc *walletUsecase) SetOverdraftLimit(ctx context.Context, id uuid.UUID, limit string) (*models.WalletDetails, error) {
+    wallet, err := uc.getWallet(ctx, id)
+    if err != nil {
+        return nil, err
+    }
+
+    currency, err := uc.getCurrency(ctx, wallet)
+    if err != nil {
+        return nil, err
+    }
+
+    var minor int64
+    if zero, parseErr := decimal.NewFromString(strings.TrimSpace(limit)); parseErr != nil || !zero.IsZero() {
+        if minor, err = uc.convertAmountToMinorUnits(limit, currency); err != nil {
+            return nil, err
+        }
+    }
+
+    wallet, err = uc.repo.SetOverdraftLimit(ctx, id, minor)
+    if err != nil {
+        return nil, mapRepositoryError(err)
+    }
+
+    uc.log.Info("Overdraft limit changed",
+        logger.StringField("wallet_id", id.String()),
+        logger.Int64Field("overdraft_limit", minor))
+    return uc.walletDetails(wallet, currency)
+}
+
+func (uc *walletUsecase) walletDetails(wallet *models.Wallet, currency *models.Currency) (*models.WalletDetails, error) {
+    if currency.MinorUnits < 0 {
+        return nil, fmt.Errorf("invalid currency minor units: %d", currency.MinorUnits)
+    }
+
+    return &models.WalletDetails{
+        Wallet:           wallet,
+        Currency:         currency,
+        Balance:          decimal.New(wallet.Balance, -currency.Scale()),
+        HeldBalance:      decimal.New(wallet.HeldBalance, -currency.Scale()),
+        AvailableBalance: decimal.New(wallet.AvailableBalance(), -currency.Scale()),
+        OverdraftLimit:   decimal.New(wallet.OverdraftLimit, -currency.Scale()),
+        AvailableCredit:  decimal.New(wallet.AvailableCredit(), -currency.Scale()),
+    }, nil
+}
+
+// Transfer списывает сумму с кошелька op.WalletID и зачисляет её на op.TargetWalletID
+// в одной транзакции; поддерживаются только кошельки в одной валюте
+func (uc *walletUsecase) Transfer(ctx context.Context, op models.WalletOperation) (*models.OperationResult, error) {
+    uc.logStart(op)
+
+    if op.WalletID == op.TargetWalletID {
+        return nil, ErrSameWallet
+    }
+
+    source, err := uc.getWallet(ctx, op.WalletID)
+    if err != nil {
+        if errors.Is(err, ErrWalletNotFound) {
+            uc.recordMissingSource(ctx, op, err)
+        }
+        return nil, err
     }
 
-    amount, err = uc.checkBalance(wallet, amount, op.OperationType)
+    currency, err := uc.getCurrency(ctx, source)
     if err != nil {
-        return decimal.Zero, err
+        return nil, err
     }
 
-    newBalance, err := uc.repo.ExecuteTxWithRetry(ctx, wallet.ID, amount, op.OperationType)
+    amount, err := uc.convertAmountToMinorUnits(op.Amount, currency)
     if err != nil {
-        return decimal.Zero, err
+        return nil, err
     }
 
-    newBalanceStr, err := uc.convertAmountFromMinorUnits(newBalance, currency)
+    params := uc.operationParams(op, source.ID, amount)
+    params.TargetWalletID = op.TargetWalletID
+
+    target, err := uc.lookupWallet(ctx, op.TargetWalletID)
     if err != nil {
-        return decimal.Zero, err
+        uc.recordFailure(ctx, params, err)
+        return nil, err
+    }
+
+    if source.CurrencyCode != target.CurrencyCode {
+        uc.log.Warn("Transfer currency mismatch",
+            logger.StringField("source_currency", source.CurrencyCode),
+            logger.StringField("target_currency", target.CurrencyCode))
+        uc.recordFailure(ctx, params, ErrCurrencyMismatch)
+        return nil, ErrCurrencyMismatch
     }
 
-    return newBalanceStr, nil
+    if op.OperationID == "" {
+        if err := uc.checkStatus(source, models.OperationTransferOut); err != nil {
+            uc.recordFailure(ctx, params, err)
+            return nil, err
+        }
+        if err := uc.checkStatus(target, models.OperationTransferIn); err != nil {
+            uc.recordFailure(ctx, params, err)
+            return nil, err
+        }
+        if _, err := uc.checkBalance(source, amount, models.OperationWithdraw); err != nil {
+            uc.recordFailure(ctx, params, err)
+            return nil, err
+        }
+    }
+
+    newBalance, err := uc.repo.TransferTxWithRetry(ctx, params)
+    if err != nil {
+        err = mapRepositoryError(err)
+        uc.recordFailure(ctx, params, err)
+        return nil, err
+    }
+
+    return uc.operationResult(newBalance, currency)
+}
+
+// recordMissingSource сохраняет отказ в переводе с несуществующего кошелька в истории получателя.
+// Сумма переводится в минимальные единицы по валюте получателя: перевод возможен только в одной валюте.
+func (uc *walletUsecase) recordMissingSource(ctx context.Context, op models.WalletOperation, err error) {
+    target, lookupErr := uc.lookupWallet(ctx, op.TargetWalletID)
+    if lookupErr != nil {
+        return
+    }
+    currency, currencyErr := uc.getCurrency(ctx, target)
+    if currencyErr != nil {
+        return
+    }
+    amount, amountErr := uc.convertAmountToMinorUnits(op.Amount, currency)
+    if amountErr != nil {
+        return
+    }
+
+    params := uc.operationParams(op, op.WalletID, amount)
+    params.TargetWalletID = op.TargetWalletID
+    uc.recordFailure(ctx, params, err)
+}
+
+// operationParams собирает параметры операции для хранилища. Предварительная проверка баланса
+// для идемпотентных запросов пропускается: повтор уже выполненного списания должен вернуть
+// исходный ответ, а не ошибку о нехватке средств, поэтому баланс проверяется только в транзакции.
+func (uc *walletUsecase) operationParams(op models.WalletOperation, walletID uuid.UUID, amount int64) repository.OperationParams {
+    params := repository.OperationParams{
+        WalletID:      walletID,
+        Amount:        amount,
+        OperationType: op.OperationType,
+        Actor:         op.Actor,
+    }
+    if op.OperationID != "" {
+        params.IdempotencyKey = op.OperationID
+        params.RequestHash = requestHash(op, amount)
+    }
+    return params
+}
+
+// recordFailure сохраняет отклонённую операцию в истории кошелька. Записываются только отказы
+// по бизнес-причинам; ошибка записи не подменяет ошибку самой операции и только логируется.
+func (uc *walletUsecase) recordFailure(ctx context.Context, params repository.OperationParams, err error) {
+    var reason models.FailureReason
+    switch {
+    case errors.Is(err, ErrInsufficientFunds):
+        reason = models.FailureInsufficientFunds
+    case errors.Is(err, ErrWalletNotFound):
+        reason = models.FailureWalletNotFound
+    case errors.Is(err, ErrCurrencyMismatch):
+        reason = models.FailureCurrencyMismatch
+    case errors.Is(err, ErrRetriesExhausted):
+        reason = models.FailureRetriesExhausted
+    case errors.Is(err, ErrLimitExceeded):
+        reason = models.FailureLimitExceeded
+    case errors.Is(err, ErrWalletNotActive):
+        reason = models.FailureWalletNotActive
+    default:
+        return
+    }
+
+    // Отказ фиксируется, даже если клиент уже отключился
+    if recErr := uc.repo.RecordFailedOperation(context.WithoutCancel(ctx), params, reason); recErr != nil {
+        uc.log.Error("Failed to record declined operation",
+            logger.StringField("wallet_id", params.WalletID.String()),
+            logger.StringField("reason", string(reason)),
+            logger.ErrorField("error", recErr))
+    }
+}
+
+// requestHash вычисляет отпечаток запроса по нормализованным полям: "100" и "100.00" совпадают
+func requestHash(op models.WalletOperation, amount int64) string {
+    payload := fmt.Sprintf("%s|%s|%s|%d", op.OperationType, op.WalletID, op.TargetWalletID, amount)
+    sum := sha256.Sum256([]byte(payload))
+    return hex.EncodeToString(sum[:])
 }
 
 func (uc *walletUsecase) logStart(op models.WalletOperation) {
@@ -67,12 +329,47 @@ func (uc *walletUsecase) logStart(op models.WalletOperation) {
         logger.StringField("amount", op.Amount))
 }
 
-func (uc *walletUsecase) getWallet(ctx context.Context, op models.WalletOperation) (*models.Wallet, error) {
-    wallet, err := uc.repo.GetByID(ctx, op.WalletID)
+// getWallet возвращает кошелёк, если он доступен клиенту, выполняющему запрос
+func (uc *walletUsecase) getWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+    wallet, err := uc.lookupWallet(ctx, id)
     if err != nil {
+        return nil, err
+    }
+    if err := uc.authorizeWallet(ctx, wallet); err != nil {
+        return nil, err
+    }
+    return wallet, nil
+}
+
+func (uc *walletUsecase) AuthorizeWallet(ctx context.Context, id uuid.UUID) error {
+    _, err := uc.getWallet(ctx, id)
+    return err
+}
+
+// authorizeWallet проверяет, что клиенту доступен кошелёк. Внутренние операции сервиса
+// выполняются без клиента в контексте и не ограничены.
+func (uc *walletUsecase) authorizeWallet(ctx context.Context, wallet *models.Wallet) error {
+    principal, ok := auth.FromContext(ctx)
+    if !ok || principal.CanAccessWallet(wallet) {
+        return nil
+    }
+    uc.log.Warn("Wallet access denied",
+        logger.StringField("wallet_id", wallet.ID.String()),
+        logger.StringField("actor", principal.Actor))
+    return fmt.Errorf("%w: %s", ErrForbidden, wallet.ID)
+}
+
+// lookupWallet возвращает кошелёк без проверки доступа: получателем перевода может быть чужой кошелёк
+func (uc *walletUsecase) lookupWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+    wallet, err := uc.repo.GetByID(ctx, id)
+    if err != nil {
+        if errors.Is(err, repository.ErrNotFound) {
+            uc.log.Warn("Wallet not found", logger.StringField("wallet_id", id.String()))
+            return nil, fmt.Errorf("get wallet: %w", ErrWalletNotFound)
+        }
         uc.log.Error("Wallet lookup failed", 
             logger.ErrorField("error", err),
-            logger.AnyField("op", op))
+            logger.StringField("wallet_id", id.String()))
         return nil, fmt.Errorf("get wallet: %w", err)
     }
     return wallet, nil
@@ -89,34 +386,138 @@ func (uc *walletUsecase) getCurrency(ctx context.Context, wallet *models.Wallet)
     return currency, nil
 }
 
+// convertAmountToMinorUnits переводит положительную сумму из основных единиц в минимальные.
+// Допустимая точность определяется валютой: "0.5" отклоняется для JPY, "1.005" - для RUB,
+// но принимается для KWD. Незначащие нули в дробной части не считаются лишней точностью.
 func (uc *walletUsecase) convertAmountToMinorUnits(amountStr string, currency *models.Currency) (int64, error) {
-    normalAmount := strings.ReplaceAll(amountStr, ",", ".")
-    amount, err := decimal.NewFromString(normalAmount)
+    normalized := strings.ReplaceAll(strings.ReplaceAll(amountStr, " ", ""), ",", ".")
+    if !amountRegexp.MatchString(normalized) {
+        return 0, fmt.Errorf("%w: invalid amount format %q", ErrInvalidAmount, amountStr)
+    }
+
+    amount, err := decimal.NewFromString(normalized)
     if err != nil {
-        uc.log.Error("Amount conversion error",
+        return 0, fmt.Errorf("%w: %v", ErrInvalidAmount, err)
+    }
+
+    minor := amount.Shift(currency.Scale())
+    if !minor.Equal(minor.Truncate(0)) {
+        uc.log.Warn("Amount precision exceeds currency scale",
             logger.StringField("input", amountStr),
-            logger.ErrorField("error", err))
-        return 0, fmt.Errorf("convert amount: %w", err)
+            logger.StringField("currency", currency.Code))
+        return 0, fmt.Errorf("%w: %s allows %d decimal places", ErrAmountPrecision, currency.Code, currency.MinorUnits)
     }
-    multiplier := decimal.NewFromInt(10).Pow(decimal.NewFromInt(currency.MinorUnits))
-    return amount.Mul(multiplier).IntPart(), nil
+
+    if minor.Sign() <= 0 {
+        return 0, ErrInvalidAmount
+    }
+    if minor.GreaterThan(maxMinorAmount) {
+        return 0, fmt.Errorf("%w: amount is too large", ErrInvalidAmount)
+    }
+
+    return minor.IntPart(), nil
 }
 
 func (uc *walletUsecase) convertAmountFromMinorUnits(minorUnits int64, currency *models.Currency) (decimal.Decimal, error) {
-	if currency.MinorUnits <= 0 {
+	if currency.MinorUnits < 0 {
 		return decimal.Zero, fmt.Errorf("invalid currency minor units: %d", currency.MinorUnits)
 	}
-    divisor := decimal.NewFromInt(10).Pow(decimal.NewFromInt(currency.MinorUnits))
-	return decimal.NewFromInt(minorUnits).Div(divisor), nil
+	return decimal.New(minorUnits, -currency.Scale()), nil
+}
+
+func (uc *walletUsecase) operationResult(balance int64, currency *models.Currency) (*models.OperationResult, error) {
+    amount, err := uc.convertAmountFromMinorUnits(balance, currency)
+    if err != nil {
+        return nil, err
+    }
+    return &models.OperationResult{Currency: currency, Balance: amount}, nil
 }
 
 func (uc *walletUsecase) checkBalance(wallet *models.Wallet, amount int64, opType models.OperationType) (int64, error) {
-    if opType == models.OperationWithdraw && wallet.Balance < amount {
+    // Резервы здесь не учитываются: часть из них может уже истечь, а освобождаются они в транзакции
+    // операции, где доступный баланс проверяется окончательно
+    unheld := *wallet
+    unheld.HeldBalance = 0
+    if opType == models.OperationWithdraw && !unheld.CanDebit(amount) {
         uc.log.Warn("Insufficient funds",
             logger.Int64Field("balance", wallet.Balance),
+            logger.Int64Field("held", wallet.HeldBalance),
+            logger.Int64Field("overdraft_limit", wallet.OverdraftLimit),
             logger.Int64Field("requested", amount))
         return 0, ErrInsufficientFunds
     }
     return amount, nil
 }
 
+// checkStatus отклоняет операцию, которую запрещает статус кошелька. Окончательно статус
+// проверяется в транзакции операции; здесь отсекаются заведомо неудачные запросы.
+func (uc *walletUsecase) checkStatus(wallet *models.Wallet, opType models.OperationType) error {
+    allowed := wallet.Status.AllowsCredit()
+    if opType.Sign() < 0 || opType == models.OperationHold {
+        allowed = wallet.Status.AllowsDebit()
+    }
+    if !allowed {
+        uc.log.Warn("Operation not allowed for wallet status",
+            logger.StringField("wallet_id", wallet.ID.String()),
+            logger.StringField("status", string(wallet.Status)),
+            logger.StringField("type", string(opType)))
+        return fmt.Errorf("%w: wallet %s is %s", ErrWalletNotActive, wallet.ID, wallet.Status)
+    }
+    return nil
+}
+
+// CreateWallet создаёт кошелёк с нулевым балансом в поддерживаемой валюте
+func (uc *walletUsecase) CreateWallet(ctx context.Context, req models.CreateWalletRequest) (*models.Wallet, error) {
+    code := strings.ToUpper(strings.TrimSpace(req.CurrencyCode))
+    if !currencyCodeRegexp.MatchString(code) {
+        return nil, ErrInvalidCurrency
+    }
+
+    currency, err := uc.repo.GetCurrencyByCode(ctx, code)
+    if err != nil {
+        if errors.Is(err, repository.ErrNotFound) {
+            return nil, fmt.Errorf("%w: %s", ErrCurrencyNotFound, code)
+        }
+        return nil, fmt.Errorf("get currency: %w", err)
+    }
+    if !currency.IsActive {
+        return nil, fmt.Errorf("%w: %s", ErrCurrencyInactive, code)
+    }
+
+    // Пользователь открывает кошелёк только на себя
+    ownerRef := req.OwnerRef
+    if principal, ok := auth.FromContext(ctx); ok && principal.Subject != "" {
+        if ownerRef != nil && *ownerRef != principal.Subject {
+            return nil, fmt.Errorf("%w: owner_ref must match the authenticated user", ErrForbidden)
+        }
+        ownerRef = &principal.Subject
+    }
+
+    wallet := &models.Wallet{
+        ID:           uuid.New(),
+        CurrencyCode: currency.Code,
+        Status:       models.WalletActive,
+        OwnerRef:     ownerRef,
+    }
+    if req.WalletID != nil && *req.WalletID != uuid.Nil {
+        wallet.ID = *req.WalletID
+    }
+
+    if err := uc.repo.Create(ctx, wallet); err != nil {
+        if errors.Is(err, repository.ErrAlreadyExists) {
+            return nil, fmt.Errorf("%w: %s", ErrWalletAlreadyExists, wallet.ID)
+        }
+        if errors.Is(err, repository.ErrNotFound) {
+            return nil, fmt.Errorf("%w: %s", ErrCurrencyNotFound, code)
+        }
+        uc.log.Error("Wallet creation failed",
+            logger.ErrorField("error", err),
+            logger.StringField("wallet_id", wallet.ID.String()))
+        return nil, fmt.Errorf("create wallet: %w", err)
+    }
+
+    uc.log.Info("Wallet created",
+        logger.StringField("wallet_id", wallet.ID.String()),
+        logger.StringField("currency", wallet.CurrencyCode))
+    return wallet, nil
+}
@@ -0,0 +1,232 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	usd = &models.Currency{Code: "USD", MinorUnits: 2, IsFractional: true, IsActive: true}
+	eur = &models.Currency{Code: "EUR", MinorUnits: 2, IsFractional: true, IsActive: true}
+	jpy = &models.Currency{Code: "JPY", MinorUnits: 0, IsActive: true}
+	kwd = &models.Currency{Code: "KWD", MinorUnits: 3, IsFractional: true, IsActive: true}
+)
+
+type failedOperation struct {
+	params repository.OperationParams
+	reason models.FailureReason
+}
+
+// walletRepoStub хранит кошельки и валюты в памяти и запоминает записанные отказы;
+// остальные методы хранилища переопределяются в тестах, которым они нужны
+type walletRepoStub struct {
+	repository.WalletRepository
+
+	wallets    map[uuid.UUID]*models.Wallet
+	currencies map[string]*models.Currency
+	failures   []failedOperation
+}
+
+func newWalletRepoStub(wallets ...*models.Wallet) *walletRepoStub {
+	repo := &walletRepoStub{
+		wallets:    make(map[uuid.UUID]*models.Wallet),
+		currencies: make(map[string]*models.Currency),
+	}
+	for _, currency := range []*models.Currency{usd, eur, jpy, kwd} {
+		repo.currencies[currency.Code] = currency
+	}
+	for _, wallet := range wallets {
+		repo.wallets[wallet.ID] = wallet
+	}
+	return repo
+}
+
+func (r *walletRepoStub) GetByID(_ context.Context, id uuid.UUID) (*models.Wallet, error) {
+	wallet, ok := r.wallets[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: wallet %s", repository.ErrNotFound, id)
+	}
+	copied := *wallet
+	return &copied, nil
+}
+
+func (r *walletRepoStub) GetCurrencyByCode(_ context.Context, code string) (*models.Currency, error) {
+	currency, ok := r.currencies[code]
+	if !ok {
+		return nil, fmt.Errorf("%w: currency %s", repository.ErrNotFound, code)
+	}
+	return currency, nil
+}
+
+func (r *walletRepoStub) RecordFailedOperation(_ context.Context, params repository.OperationParams, reason models.FailureReason) error {
+	r.failures = append(r.failures, failedOperation{params: params, reason: reason})
+	return nil
+}
+
+func newWallet(currency string, balance int64) *models.Wallet {
+	return &models.Wallet{ID: uuid.New(), Balance: balance, CurrencyCode: currency, Status: models.WalletActive}
+}
+
+func newTestUsecase(t *testing.T, repo repository.WalletRepository) *walletUsecase {
+	t.Helper()
+	log, cleanup := logger.NewLogger()
+	t.Cleanup(cleanup)
+	return &walletUsecase{repo: repo, log: log}
+}
+
+func TestTransferFromMissingWalletRecordsFailure(t *testing.T) {
+	target := newWallet("KWD", 0)
+	repo := newWalletRepoStub(target)
+	uc := newTestUsecase(t, repo)
+
+	missing := uuid.New()
+	_, err := uc.Transfer(context.Background(), models.WalletOperation{
+		WalletID: missing, TargetWalletID: target.ID, OperationType: models.OperationTransfer, Amount: "1.5",
+	})
+	require.ErrorIs(t, err, ErrWalletNotFound)
+
+	// Сумма переводится по валюте получателя, отправитель сохраняется для записи в его историю
+	require.Len(t, repo.failures, 1)
+	failure := repo.failures[0]
+	assert.Equal(t, models.FailureWalletNotFound, failure.reason)
+	assert.Equal(t, missing, failure.params.WalletID)
+	assert.Equal(t, target.ID, failure.params.TargetWalletID)
+	assert.Equal(t, int64(1500), failure.params.Amount)
+	assert.Equal(t, models.OperationTransfer, failure.params.OperationType)
+}
+
+func TestTransferBetweenMissingWalletsIsNotRecorded(t *testing.T) {
+	repo := newWalletRepoStub()
+	uc := newTestUsecase(t, repo)
+
+	_, err := uc.Transfer(context.Background(), models.WalletOperation{
+		WalletID: uuid.New(), TargetWalletID: uuid.New(), OperationType: models.OperationTransfer, Amount: "1",
+	})
+	require.ErrorIs(t, err, ErrWalletNotFound)
+	assert.Empty(t, repo.failures)
+}
+
+func TestTransferToMissingWalletRecordsFailure(t *testing.T) {
+	source := newWallet("USD", 1000)
+	repo := newWalletRepoStub(source)
+	uc := newTestUsecase(t, repo)
+
+	missing := uuid.New()
+	_, err := uc.Transfer(context.Background(), models.WalletOperation{
+		WalletID: source.ID, TargetWalletID: missing, OperationType: models.OperationTransfer, Amount: "2",
+	})
+	require.ErrorIs(t, err, ErrWalletNotFound)
+
+	require.Len(t, repo.failures, 1)
+	assert.Equal(t, models.FailureWalletNotFound, repo.failures[0].reason)
+	assert.Equal(t, source.ID, repo.failures[0].params.WalletID)
+	assert.Equal(t, int64(200), repo.failures[0].params.Amount)
+}
+
+func TestConvertAmountToMinorUnits(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		currency *models.Currency
+		want     int64
+		err      error
+	}{
+		{"JPY whole amount", "1500", jpy, 1500, nil},
+		{"JPY trailing zeros", "1500.00", jpy, 1500, nil},
+		{"JPY fraction", "0.5", jpy, 0, ErrAmountPrecision},
+		{"KWD three places", "1.005", kwd, 1005, nil},
+		{"KWD fewer places", "2.5", kwd, 2500, nil},
+		{"KWD four places", "1.0005", kwd, 0, ErrAmountPrecision},
+		{"USD three places", "1.005", usd, 0, ErrAmountPrecision},
+		{"USD trailing zero beyond scale", "1.050", usd, 105, nil},
+		{"comma separator and spaces", "1 000,25", usd, 100025, nil},
+		{"zero", "0.00", usd, 0, ErrInvalidAmount},
+		{"negative", "-1", usd, 0, ErrInvalidAmount},
+		{"exponent", "1e3", usd, 0, ErrInvalidAmount},
+		{"too large", "92233720368547758.08", usd, 0, ErrInvalidAmount},
+		{"largest amount", "92233720368547758.07", usd, 9223372036854775807, nil},
+	}
+	uc := newTestUsecase(t, newWalletRepoStub())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := uc.convertAmountToMinorUnits(tt.amount, tt.currency)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConvertAmountFromMinorUnits(t *testing.T) {
+	tests := []struct {
+		minor    int64
+		currency *models.Currency
+		want     string
+	}{
+		{1500, jpy, "1500"},
+		{1005, kwd, "1.005"},
+		{-2500, kwd, "-2.5"},
+		{100025, usd, "1000.25"},
+		{0, usd, "0"},
+	}
+	uc := newTestUsecase(t, newWalletRepoStub())
+	for _, tt := range tests {
+		t.Run(tt.currency.Code+" "+tt.want, func(t *testing.T) {
+			got, err := uc.convertAmountFromMinorUnits(tt.minor, tt.currency)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+
+	// Сумма, переведённая в минимальные единицы и обратно, не меняется
+	for _, currency := range []*models.Currency{jpy, usd, kwd} {
+		amount := decimal.New(123456, -currency.Scale())
+		minor, err := uc.convertAmountToMinorUnits(amount.String(), currency)
+		require.NoError(t, err)
+		back, err := uc.convertAmountFromMinorUnits(minor, currency)
+		require.NoError(t, err)
+		assert.True(t, amount.Equal(back), "%s: %s -> %d -> %s", currency.Code, amount, minor, back)
+	}
+}
+
+func TestCheckBalance(t *testing.T) {
+	tests := []struct {
+		name   string
+		wallet *models.Wallet
+		amount int64
+		opType models.OperationType
+		err    error
+	}{
+		{"funds cover amount", &models.Wallet{Balance: 100}, 100, models.OperationWithdraw, nil},
+		{"funds short of amount", &models.Wallet{Balance: 100}, 101, models.OperationWithdraw, ErrInsufficientFunds},
+		{"credit line covers shortfall", &models.Wallet{Balance: 100, OverdraftLimit: 50}, 150, models.OperationWithdraw, nil},
+		{"credit line exceeded", &models.Wallet{Balance: 100, OverdraftLimit: 50}, 151, models.OperationWithdraw, ErrInsufficientFunds},
+		{"negative balance beyond reduced credit line", &models.Wallet{Balance: -80, OverdraftLimit: 50}, 1, models.OperationWithdraw, ErrInsufficientFunds},
+		// Резервы могут уже истечь, поэтому окончательно они учитываются в транзакции
+		{"held funds are checked in the transaction", &models.Wallet{Balance: 100, HeldBalance: 100}, 100, models.OperationWithdraw, nil},
+		{"deposit is not checked", &models.Wallet{Balance: -80}, 1, models.OperationDeposit, nil},
+	}
+	uc := newTestUsecase(t, newWalletRepoStub())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := uc.checkBalance(tt.wallet, tt.amount, tt.opType)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/google/uuid"
+)
+
+// WebhookUsecase управляет подписками партнёров на события кошельков
+type WebhookUsecase interface {
+	CreateSubscription(ctx context.Context, req models.WebhookSubscriptionRequest) (*models.WebhookSubscription, error)
+	GetSubscription(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context, walletID *uuid.UUID) ([]models.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id uuid.UUID) error
+	ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, status models.DeliveryStatus) ([]models.WebhookDelivery, error)
+	Redeliver(ctx context.Context, deliveryID uuid.UUID) (*models.WebhookDelivery, error)
+}
+
+type webhookUsecase struct {
+	repo repository.WebhookRepository
+	log  logger.Logger
+}
+
+func NewWebhookUsecase(repo repository.WebhookRepository, log logger.Logger) WebhookUsecase {
+	return &webhookUsecase{repo: repo, log: log}
+}
+
+// CreateSubscription создаёт подписку; если секрет не передан, он генерируется
+func (uc *webhookUsecase) CreateSubscription(ctx context.Context, req models.WebhookSubscriptionRequest) (*models.WebhookSubscription, error) {
+	target, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidWebhook)
+	}
+	for _, eventType := range req.EventTypes {
+		if !eventType.IsValid() {
+			return nil, fmt.Errorf("%w: unknown event type %s", ErrInvalidWebhook, eventType)
+		}
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("generate webhook secret: %w", err)
+		}
+		secret = hex.EncodeToString(buf)
+	}
+
+	subscription := &models.WebhookSubscription{
+		ID:         uuid.New(),
+		WalletID:   req.WalletID,
+		URL:        target.String(),
+		Secret:     secret,
+		EventTypes: req.EventTypes,
+	}
+	if subscription.EventTypes == nil {
+		subscription.EventTypes = []models.EventType{}
+	}
+
+	if err := uc.repo.CreateSubscription(ctx, subscription); err != nil {
+		return nil, mapWebhookError(err)
+	}
+
+	uc.log.Info("Webhook subscription created",
+		logger.StringField("subscription_id", subscription.ID.String()),
+		logger.StringField("url", subscription.URL))
+	return subscription, nil
+}
+
+func (uc *webhookUsecase) GetSubscription(ctx context.Context, id uuid.UUID) (*models.WebhookSubscription, error) {
+	subscription, err := uc.repo.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, mapWebhookError(err)
+	}
+	return subscription, nil
+}
+
+// ListSubscriptions возвращает подписки кошелька вместе с общими; без кошелька - все подписки
+func (uc *webhookUsecase) ListSubscriptions(ctx context.Context, walletID *uuid.UUID) ([]models.WebhookSubscription, error) {
+	return uc.repo.ListSubscriptions(ctx, walletID)
+}
+
+func (uc *webhookUsecase) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	if err := uc.repo.DeleteSubscription(ctx, id); err != nil {
+		return mapWebhookError(err)
+	}
+
+	uc.log.Info("Webhook subscription deleted", logger.StringField("subscription_id", id.String()))
+	return nil
+}
+
+// ListDeliveries возвращает последние доставки подписки, при необходимости только в заданном состоянии
+func (uc *webhookUsecase) ListDeliveries(ctx context.Context, subscriptionID uuid.UUID, status models.DeliveryStatus) ([]models.WebhookDelivery, error) {
+	switch status {
+	case "", models.DeliveryPending, models.DeliveryDelivered, models.DeliveryDead:
+	default:
+		return nil, fmt.Errorf("%w: unknown delivery status %s", ErrInvalidWebhook, status)
+	}
+
+	if _, err := uc.repo.GetSubscription(ctx, subscriptionID); err != nil {
+		return nil, mapWebhookError(err)
+	}
+	return uc.repo.ListDeliveries(ctx, subscriptionID, status)
+}
+
+// Redeliver возвращает доставку в очередь с обнулённым счётчиком попыток
+func (uc *webhookUsecase) Redeliver(ctx context.Context, deliveryID uuid.UUID) (*models.WebhookDelivery, error) {
+	delivery, err := uc.repo.Redeliver(ctx, deliveryID)
+	if err != nil {
+		return nil, mapWebhookError(err)
+	}
+
+	uc.log.Info("Webhook delivery requeued", logger.StringField("delivery_id", deliveryID.String()))
+	return delivery, nil
+}
+
+func mapWebhookError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrSubscriptionNotFound):
+		return fmt.Errorf("%w: %v", ErrSubscriptionNotFound, err)
+	case errors.Is(err, repository.ErrDeliveryNotFound):
+		return fmt.Errorf("%w: %v", ErrDeliveryNotFound, err)
+	case errors.Is(err, repository.ErrDeliveryPending):
+		return fmt.Errorf("%w: %v", ErrDeliveryPending, err)
+	case errors.Is(err, repository.ErrNotFound):
+		return fmt.Errorf("%w: %v", ErrWalletNotFound, err)
+	}
+	return err
+}
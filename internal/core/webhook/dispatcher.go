@@ -0,0 +1,168 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultBatchSize - число доставок, которое диспетчер отправляет за один проход
+	DefaultBatchSize = 50
+	// MaxAttempts - число попыток, после которого доставка переходит в DEAD
+	MaxAttempts = 12
+
+	baseBackoff    = 30 * time.Second
+	maxBackoff     = 6 * time.Hour
+	requestTimeout = 10 * time.Second
+	// claimLease - время, на которое выданная доставка скрывается от других экземпляров сервиса
+	claimLease = time.Minute
+	// defaultWorkers - число подписок, доставки которым отправляются одновременно
+	defaultWorkers = 8
+)
+
+// Dispatcher отправляет подписчикам доставки, срок попытки которых наступил
+type Dispatcher struct {
+	store   repository.WebhookRepository
+	client  *http.Client
+	log     logger.Logger
+	now     func() time.Time
+	workers int
+}
+
+func NewDispatcher(store repository.WebhookRepository, log logger.Logger) *Dispatcher {
+	return &Dispatcher{
+		store:   store,
+		client:  &http.Client{Timeout: requestTimeout},
+		log:     log,
+		now:     time.Now,
+		workers: defaultWorkers,
+	}
+}
+
+// RunOnce отправляет очередную пачку доставок и возвращает число обработанных
+func (d *Dispatcher) RunOnce(ctx context.Context) (int, error) {
+	deliveries, err := d.store.ClaimDueDeliveries(ctx, DefaultBatchSize, claimLease)
+	if err != nil {
+		return 0, err
+	}
+
+	// Доставки одной подписки отправляются по очереди в порядке выдачи, разные подписки -
+	// одновременно: медленный подписчик не задерживает доставки остальным
+	var subscriptions []uuid.UUID
+	queues := make(map[uuid.UUID][]models.WebhookDelivery)
+	for _, delivery := range deliveries {
+		if _, ok := queues[delivery.SubscriptionID]; !ok {
+			subscriptions = append(subscriptions, delivery.SubscriptionID)
+		}
+		queues[delivery.SubscriptionID] = append(queues[delivery.SubscriptionID], delivery)
+	}
+
+	jobs := make(chan []models.WebhookDelivery)
+	errs := make(chan error, len(subscriptions))
+	var wg sync.WaitGroup
+	for i := 0; i < min(d.workers, len(subscriptions)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for queue := range jobs {
+				for _, delivery := range queue {
+					if err := d.deliver(ctx, delivery); err != nil {
+						// Оставшиеся доставки подписки вернутся в очередь по истечении аренды
+						errs <- err
+						break
+					}
+				}
+			}
+		}()
+	}
+	for _, subscriptionID := range subscriptions {
+		jobs <- queues[subscriptionID]
+	}
+	close(jobs)
+	wg.Wait()
+	close(errs)
+
+	var failed []error
+	for err := range errs {
+		failed = append(failed, err)
+	}
+	if len(failed) > 0 {
+		return 0, errors.Join(failed...)
+	}
+	return len(deliveries), nil
+}
+
+// deliver выполняет одну попытку и записывает её результат
+func (d *Dispatcher) deliver(ctx context.Context, delivery models.WebhookDelivery) error {
+	statusCode, err := d.send(ctx, delivery)
+	if err == nil {
+		return d.store.MarkDelivered(ctx, delivery.ID, statusCode)
+	}
+	if ctx.Err() != nil {
+		// Сервис останавливается; доставка вернётся в очередь по истечении аренды
+		return ctx.Err()
+	}
+
+	attempt := models.DeliveryAttempt{Error: err.Error()}
+	if statusCode != 0 {
+		attempt.StatusCode = &statusCode
+	}
+	attempts := delivery.Attempts + 1
+	if attempts < MaxAttempts {
+		retryAt := d.now().Add(Backoff(attempts))
+		attempt.RetryAt = &retryAt
+	}
+
+	d.log.Warn("Webhook delivery failed",
+		logger.StringField("delivery_id", delivery.ID.String()),
+		logger.StringField("subscription_id", delivery.SubscriptionID.String()),
+		logger.Int64Field("attempt", int64(attempts)),
+		logger.ErrorField("error", err))
+	return d.store.MarkFailed(ctx, delivery.ID, attempt)
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Webhook-Id", delivery.EventID.String())
+	req.Header.Set("Webhook-Event", string(delivery.EventType))
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, d.now(), delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Backoff возвращает паузу после неудачной попытки с номером attempt: 30s, 1m, 2m, ... не более 6h
+func Backoff(attempt int) time.Duration {
+	delay := baseBackoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return delay
+}
@@ -0,0 +1,224 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Nzyazin/itk/internal/core/logger"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deliveryStore хранит доставки в памяти и переводит их в состояния так же, как репозиторий
+type deliveryStore struct {
+	repository.WebhookRepository
+
+	mu         sync.Mutex
+	now        func() time.Time
+	deliveries []*models.WebhookDelivery
+	attempts   map[uuid.UUID][]models.DeliveryAttempt
+	delivered  chan uuid.UUID
+}
+
+func newDeliveryStore(now func() time.Time, deliveries ...*models.WebhookDelivery) *deliveryStore {
+	return &deliveryStore{
+		now:        now,
+		deliveries: deliveries,
+		attempts:   make(map[uuid.UUID][]models.DeliveryAttempt),
+		delivered:  make(chan uuid.UUID, len(deliveries)),
+	}
+}
+
+func (s *deliveryStore) ClaimDueDeliveries(_ context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []models.WebhookDelivery
+	for _, delivery := range s.deliveries {
+		if len(due) == limit {
+			break
+		}
+		if delivery.Status == models.DeliveryPending && !delivery.NextAttemptAt.After(s.now()) {
+			due = append(due, *delivery)
+			delivery.NextAttemptAt = s.now().Add(lease)
+		}
+	}
+	return due, nil
+}
+
+func (s *deliveryStore) MarkDelivered(_ context.Context, id uuid.UUID, _ int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delivery := s.find(id)
+	delivery.Status = models.DeliveryDelivered
+	delivery.Attempts++
+	s.delivered <- id
+	return nil
+}
+
+func (s *deliveryStore) MarkFailed(_ context.Context, id uuid.UUID, attempt models.DeliveryAttempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delivery := s.find(id)
+	delivery.Attempts++
+	if attempt.RetryAt == nil {
+		delivery.Status = models.DeliveryDead
+	} else {
+		delivery.NextAttemptAt = *attempt.RetryAt
+	}
+	s.attempts[id] = append(s.attempts[id], attempt)
+	return nil
+}
+
+func (s *deliveryStore) find(id uuid.UUID) *models.WebhookDelivery {
+	for _, delivery := range s.deliveries {
+		if delivery.ID == id {
+			return delivery
+		}
+	}
+	panic("unknown delivery " + id.String())
+}
+
+func newDelivery(url string) *models.WebhookDelivery {
+	return &models.WebhookDelivery{
+		ID:             uuid.New(),
+		SubscriptionID: uuid.New(),
+		EventID:        uuid.New(),
+		EventType:      models.EventWalletCredited,
+		Payload:        []byte(`{"type":"WalletCredited"}`),
+		Status:         models.DeliveryPending,
+		URL:            url,
+		Secret:         "secret",
+	}
+}
+
+func newTestDispatcher(store repository.WebhookRepository, now func() time.Time) (*Dispatcher, func()) {
+	log, cleanup := logger.NewLogger()
+	dispatcher := NewDispatcher(store, log)
+	dispatcher.now = now
+	return dispatcher, cleanup
+}
+
+func TestDispatcherRetriesWithBackoffUntilDead(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	delivery := newDelivery(server.URL)
+	delivery.NextAttemptAt = now
+	store := newDeliveryStore(clock, delivery)
+	dispatcher, cleanup := newTestDispatcher(store, clock)
+	defer cleanup()
+
+	ctx := context.Background()
+	for attempt := 1; attempt <= MaxAttempts; attempt++ {
+		sent, err := dispatcher.RunOnce(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, sent, "attempt %d", attempt)
+
+		// До наступления срока повтора доставка не выдаётся
+		sent, err = dispatcher.RunOnce(ctx)
+		require.NoError(t, err)
+		require.Zero(t, sent, "attempt %d", attempt)
+
+		recorded := store.attempts[delivery.ID][attempt-1]
+		assert.Equal(t, "unexpected status 503", recorded.Error)
+		require.NotNil(t, recorded.StatusCode)
+		assert.Equal(t, http.StatusServiceUnavailable, *recorded.StatusCode)
+
+		if attempt == MaxAttempts {
+			assert.Nil(t, recorded.RetryAt, "last attempt must not be retried")
+			break
+		}
+		require.NotNil(t, recorded.RetryAt, "attempt %d", attempt)
+		assert.Equal(t, now.Add(Backoff(attempt)), *recorded.RetryAt, "attempt %d", attempt)
+		now = *recorded.RetryAt
+	}
+
+	assert.Equal(t, models.DeliveryDead, delivery.Status)
+	assert.Equal(t, MaxAttempts, delivery.Attempts)
+
+	now = now.Add(365 * 24 * time.Hour)
+	sent, err := dispatcher.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, sent, "dead deliveries are not retried")
+}
+
+func TestDispatcherSignsDelivery(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	var header http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	clock := func() time.Time { return now }
+	delivery := newDelivery(server.URL)
+	store := newDeliveryStore(clock, delivery)
+	dispatcher, cleanup := newTestDispatcher(store, clock)
+	defer cleanup()
+
+	sent, err := dispatcher.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, models.DeliveryDelivered, delivery.Status)
+
+	assert.Equal(t, delivery.EventID.String(), header.Get("Webhook-Id"))
+	assert.Equal(t, string(models.EventWalletCredited), header.Get("Webhook-Event"))
+	require.NoError(t, Verify("secret", header.Get(SignatureHeader), body, now, DefaultTolerance))
+}
+
+func TestDispatcherSlowEndpointDoesNotBlockOthers(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer fast.Close()
+
+	deliveries := []*models.WebhookDelivery{newDelivery(slow.URL)}
+	for i := 0; i < DefaultBatchSize-1; i++ {
+		deliveries = append(deliveries, newDelivery(fast.URL))
+	}
+	store := newDeliveryStore(time.Now, deliveries...)
+	dispatcher, cleanup := newTestDispatcher(store, time.Now)
+	defer cleanup()
+
+	done := make(chan error)
+	go func() {
+		_, err := dispatcher.RunOnce(context.Background())
+		done <- err
+	}()
+
+	// Все доставки быстрому подписчику проходят, пока медленный ещё не ответил
+	timeout := time.After(5 * time.Second)
+	for i := 0; i < DefaultBatchSize-1; i++ {
+		select {
+		case id := <-store.delivered:
+			assert.NotEqual(t, deliveries[0].ID, id)
+		case <-timeout:
+			t.Fatalf("only %d of %d deliveries completed while the slow endpoint was pending", i, DefaultBatchSize-1)
+		}
+	}
+
+	close(release)
+	require.NoError(t, <-done)
+	assert.Equal(t, models.DeliveryDelivered, deliveries[0].Status)
+}
@@ -0,0 +1,32 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/repository"
+)
+
+// Publisher ставит событие из outbox в очередь доставки всем подходящим подпискам. Ретранслятор
+// передаёт только события зафиксированных операций, поэтому подписчики не узнают об откаченных.
+// Повторная публикация того же события не создаёт дублей доставок.
+type Publisher struct {
+	store repository.WebhookRepository
+}
+
+func NewPublisher(store repository.WebhookRepository) *Publisher {
+	return &Publisher{store: store}
+}
+
+func (p *Publisher) Publish(ctx context.Context, event models.OutboxEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	if _, err := p.store.EnqueueDeliveries(ctx, event, payload); err != nil {
+		return err
+	}
+	return nil
+}
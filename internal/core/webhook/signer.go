@@ -0,0 +1,75 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader содержит время отправки и подпись тела запроса: "t=<unix>,v1=<hex>".
+// Подписывается строка "<unix>.<тело>" ключом HMAC-SHA256, равным секрету подписки.
+const SignatureHeader = "Webhook-Signature"
+
+// DefaultTolerance - допустимое расхождение времени подписи при проверке; защищает от повтора старых запросов
+const DefaultTolerance = 5 * time.Minute
+
+var (
+	ErrInvalidSignatureHeader = errors.New("invalid signature header")
+	ErrSignatureMismatch      = errors.New("signature mismatch")
+	ErrSignatureExpired       = errors.New("signature timestamp outside tolerance")
+)
+
+// Sign возвращает значение заголовка SignatureHeader для тела запроса
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	return fmt.Sprintf("t=%d,v1=%s", timestamp.Unix(), computeSignature(secret, timestamp.Unix(), body))
+}
+
+// Verify проверяет заголовок SignatureHeader на стороне получателя
+func Verify(secret, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	var timestamp int64
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return ErrInvalidSignatureHeader
+		}
+		switch key {
+		case "t":
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return ErrInvalidSignatureHeader
+			}
+			timestamp = parsed
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if timestamp == 0 || len(signatures) == 0 {
+		return ErrInvalidSignatureHeader
+	}
+
+	if diff := now.Sub(time.Unix(timestamp, 0)); diff > tolerance || diff < -tolerance {
+		return ErrSignatureExpired
+	}
+
+	expected := computeSignature(secret, timestamp, body)
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+	return ErrSignatureMismatch
+}
+
+func computeSignature(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
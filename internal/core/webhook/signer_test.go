@@ -0,0 +1,35 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"id":"e1","type":"WalletCredited"}`)
+	now := time.Unix(1700000000, 0)
+	header := Sign("secret", now, body)
+
+	require.NoError(t, Verify("secret", header, body, now.Add(time.Minute), DefaultTolerance))
+
+	assert.ErrorIs(t, Verify("other", header, body, now, DefaultTolerance), ErrSignatureMismatch)
+	assert.ErrorIs(t, Verify("secret", header, []byte(`{}`), now, DefaultTolerance), ErrSignatureMismatch)
+	assert.ErrorIs(t, Verify("secret", header, body, now.Add(time.Hour), DefaultTolerance), ErrSignatureExpired)
+	assert.ErrorIs(t, Verify("secret", "v1=abc", body, now, DefaultTolerance), ErrInvalidSignatureHeader)
+}
+
+func TestSignFormat(t *testing.T) {
+	// Подпись сверена с: printf '1700000000.body' | openssl dgst -sha256 -hmac secret
+	header := Sign("secret", time.Unix(1700000000, 0), []byte("body"))
+	assert.Equal(t, "t=1700000000,v1=42ac6f0448c1d9c3e1e82b9726248f58fef84afffcbad5188246e96070e0ea46", header)
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, Backoff(1))
+	assert.Equal(t, time.Minute, Backoff(2))
+	assert.Equal(t, 4*time.Minute, Backoff(4))
+	assert.Equal(t, 6*time.Hour, Backoff(MaxAttempts))
+}
@@ -4,14 +4,22 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 	"crypto/tls"
 
 	"github.com/gorilla/mux"
+	"github.com/Nzyazin/itk/internal/core/auth"
+	"github.com/Nzyazin/itk/internal/core/coalesce"
 	"github.com/Nzyazin/itk/internal/core/logger"
 	"github.com/Nzyazin/itk/internal/core/handler"
+	"github.com/Nzyazin/itk/internal/core/models"
+	"github.com/Nzyazin/itk/internal/core/events"
+	"github.com/Nzyazin/itk/internal/core/repository"
 	"github.com/Nzyazin/itk/internal/core/repository/postgres"
+	"github.com/Nzyazin/itk/internal/core/retry"
 	"github.com/Nzyazin/itk/internal/core/usecase"
+	"github.com/Nzyazin/itk/internal/core/webhook"
 	"github.com/Nzyazin/itk/pkg/config"
 	"github.com/Nzyazin/itk/pkg/postgresdb"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -21,12 +29,32 @@ import (
 	middlWre "github.com/Nzyazin/itk/internal/core/middleware"
 )
 
+// holdExpiryInterval задаёт период освобождения истёкших резервов
+const holdExpiryInterval = 10 * time.Second
+
+// outboxRelayInterval задаёт период публикации событий из outbox
+const outboxRelayInterval = time.Second
+
+// webhookDispatchInterval задаёт период отправки доставок вебхуков
+const webhookDispatchInterval = time.Second
+
 type Server struct {
 	router *mux.Router
 	log    logger.Logger
 	httpServer *http.Server
 	walletHandler *handler.WalletHandler
+	currencyHandler *handler.CurrencyHandler
+	webhookHandler *handler.WebhookHandler
+	apiKeyHandler *handler.APIKeyHandler
+	authenticator auth.Authenticator
+	walletUsecase usecase.WalletUsecase
+	relay *events.Relay
+	dispatcher *webhook.Dispatcher
+	coalescer *coalesce.Repository
 	db *postgresdb.Database
+
+	stopBackground context.CancelFunc
+	background     sync.WaitGroup
 }
 
 func NewServer(log logger.Logger) (*Server, error) {
@@ -41,13 +69,73 @@ func NewServer(log logger.Logger) (*Server, error) {
 		return nil, err
 	}
 
-	walletRepository := postgres.NewPostgresWalletRepo(db.DB, log)
-	walletUsecase := usecase.NewWalletUsecase(walletRepository, log)
+	retryPolicy, err := loadRetryPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	txStrategy, err := postgres.ParseTxStrategy(cfgDB.TxStrategy)
+	if err != nil {
+		return nil, err
+	}
+
+	walletRepository := postgres.NewPostgresWalletRepo(db.DB, log,
+		postgres.WithRetryPolicy(retryPolicy), postgres.WithTxStrategy(txStrategy))
+	cfgCoalesce, err := config.LoadConfigCoalesce()
+	if err != nil {
+		return nil, err
+	}
+	var coalescer *coalesce.Repository
+	if cfgCoalesce.Window > 0 {
+		coalescer = coalesce.New(walletRepository, coalesce.Config{
+			Window:   cfgCoalesce.Window,
+			MaxBatch: cfgCoalesce.MaxBatch,
+		}, log)
+		walletRepository = coalescer
+	}
+	exchangeRates := postgres.NewPostgresExchangeRateRepo(db.DB, log)
+	walletUsecase := usecase.NewWalletUsecase(walletRepository, exchangeRates, log)
 	walletHandler := handler.NewWalletHandler(walletUsecase, log)
+	currencyRepository := postgres.NewPostgresCurrencyRepo(db.DB, log, retryPolicy)
+	currencyHandler := handler.NewCurrencyHandler(usecase.NewCurrencyUsecase(currencyRepository, log), log)
+
+	cfgOutbox, err := config.LoadConfigOutbox()
+	if err != nil {
+		return nil, err
+	}
+	// Вебхуки получают события от ретранслятора, поэтому он работает и без файла событий
+	webhookRepository := postgres.NewPostgresWebhookRepo(db.DB, log)
+	webhookHandler := handler.NewWebhookHandler(usecase.NewWebhookUsecase(webhookRepository, log), log)
+	apiKeyRepository := postgres.NewPostgresAPIKeyRepo(db.DB, log)
+	apiKeyHandler := handler.NewAPIKeyHandler(usecase.NewAPIKeyUsecase(apiKeyRepository, log), log)
+
+	authenticator, err := newAuthenticator(apiKeyRepository)
+	if err != nil {
+		return nil, err
+	}
+
+	publishers := []events.Publisher{webhook.NewPublisher(webhookRepository)}
+	if cfgOutbox.Publisher == "file" {
+		publisher, err := events.NewFilePublisher(cfgOutbox.FilePath)
+		if err != nil {
+			return nil, err
+		}
+		publishers = append(publishers, publisher)
+	}
+	relay := events.NewRelay(postgres.NewPostgresOutboxRepo(db.DB, log), events.FanOut(publishers...), log)
+
 	server := &Server{
 		log:    log,
 		router: mux.NewRouter(),
 		walletHandler: walletHandler,
+		currencyHandler: currencyHandler,
+		webhookHandler: webhookHandler,
+		apiKeyHandler: apiKeyHandler,
+		authenticator: authenticator,
+		walletUsecase: walletUsecase,
+		relay: relay,
+		dispatcher: webhook.NewDispatcher(webhookRepository, log),
+		coalescer: coalescer,
 		db: db,
 	}
 
@@ -57,23 +145,119 @@ func NewServer(log logger.Logger) (*Server, error) {
 		Recorder: prometheus.NewRecorder(prometheus.Config{}),
 	})
 	
-	server.router.Use(func(next http.Handler) http.Handler {
-		return std.Handler("", mw, next)
-	})
+	server.router.Use(metricsMiddleware(mw))
 
 	server.RegisterRoutes()
+	server.startBackground()
 
 	return server, nil
 }
 
+// loadRetryPolicy дополняет политику по умолчанию значениями из конфигурации
+func loadRetryPolicy() (retry.Policy, error) {
+	cfgRetry, err := config.LoadConfigRetry()
+	if err != nil {
+		return retry.Policy{}, err
+	}
+
+	policy := retry.DefaultPolicy()
+	if cfgRetry.MaxAttempts != 0 {
+		policy.MaxAttempts = cfgRetry.MaxAttempts
+	}
+	if cfgRetry.MaxElapsed != 0 {
+		policy.MaxElapsed = cfgRetry.MaxElapsed
+	}
+	if cfgRetry.BaseDelay != 0 {
+		policy.BaseDelay = cfgRetry.BaseDelay
+	}
+	if cfgRetry.MaxDelay != 0 {
+		policy.MaxDelay = cfgRetry.MaxDelay
+	}
+	return policy, policy.Validate()
+}
+
+// newAuthenticator принимает API-ключи и, если задан JWKS, токены пользователей
+func newAuthenticator(apiKeys repository.APIKeyRepository) (auth.Authenticator, error) {
+	cfgAuth := config.LoadConfigAuth()
+	apiKeyAuthenticator := auth.NewAPIKeyAuthenticator(apiKeys)
+	if cfgAuth.JWKSFile == "" {
+		return apiKeyAuthenticator, nil
+	}
+
+	jwtAuthenticator, err := auth.NewJWTAuthenticator(cfgAuth.JWKSFile, auth.JWTConfig{
+		Issuer:   cfgAuth.JWTIssuer,
+		Audience: cfgAuth.JWTAudience,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return auth.Chain(apiKeyAuthenticator, jwtAuthenticator), nil
+}
+
+// startBackground запускает фоновые процессы, которые останавливаются в Shutdown
+func (s *Server) startBackground() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopBackground = cancel
+
+	s.runPeriodically(ctx, "hold expiry", holdExpiryInterval, func(ctx context.Context) error {
+		_, err := s.walletUsecase.ExpireHolds(ctx)
+		return err
+	})
+
+	s.runPeriodically(ctx, "outbox relay", outboxRelayInterval, func(ctx context.Context) error {
+		// Пачки публикуются подряд, пока outbox не опустеет
+		for {
+			published, err := s.relay.RunOnce(ctx)
+			if err != nil || published < events.DefaultBatchSize {
+				return err
+			}
+		}
+	})
+
+	s.runPeriodically(ctx, "webhook dispatch", webhookDispatchInterval, func(ctx context.Context) error {
+		for {
+			sent, err := s.dispatcher.RunOnce(ctx)
+			if err != nil || sent < webhook.DefaultBatchSize {
+				return err
+			}
+		}
+	})
+}
+
+func (s *Server) runPeriodically(ctx context.Context, name string, interval time.Duration, fn func(ctx context.Context) error) {
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := fn(ctx); err != nil && ctx.Err() == nil {
+					s.log.Error("background task failed",
+						logger.StringField("task", name),
+						logger.ErrorField("error", err))
+				}
+			}
+		}
+	}()
+}
+
 func (s *Server) RegisterRoutes() {
 	s.router.Use(
 		middlWre.WithErrorHandler(s.log),
 		middlWre.Recovery(s.log),
+		auth.Middleware(s.authenticator, s.log),
 	)
-	s.router.HandleFunc("/api/v1/wallet", s.walletHandler.ProcessWalletOperation).Methods("POST")
-	s.router.Handle("/metrics", promhttp.Handler()).Methods("GET")
-	s.router.PathPrefix("/debug/pprof/").Handler(http.DefaultServeMux)
+	s.walletHandler.RegisterRoutes(s.router)
+	s.currencyHandler.RegisterRoutes(s.router)
+	s.webhookHandler.RegisterRoutes(s.router)
+	s.apiKeyHandler.RegisterRoutes(s.router)
+	s.router.Handle("/metrics", auth.Require(models.ScopeAdmin, promhttp.Handler().ServeHTTP)).Methods("GET")
+	s.router.PathPrefix("/debug/pprof/").Handler(auth.Require(models.ScopeAdmin, http.DefaultServeMux.ServeHTTP))
 }
 
 func (s *Server) Run(addr string) error {
@@ -104,6 +288,15 @@ func (s *Server) Shutdown(ctx context.Context) error {
 			}
 		}
 
+		if s.stopBackground != nil {
+			s.stopBackground()
+			s.background.Wait()
+		}
+
+		if s.coalescer != nil {
+			s.coalescer.Close()
+		}
+
 		if s.db != nil {
 			err := s.db.Close()
 			if err != nil {
@@ -151,3 +344,41 @@ func loggingMiddleware(log logger.Logger) mux.MiddlewareFunc {
 		})
 	}
 }
+
+// originalWriterKey хранит в контексте запроса ResponseWriter, полученный от сервера
+type originalWriterKey struct{}
+
+// metricsMiddleware измеряет запросы. Перехватчик go-http-metrics не реализует Unwrap, поэтому
+// обработчик получает обёртку, через которую http.ResponseController находит исходный
+// ResponseWriter: без неё выгрузка выписки не могла бы продлить срок записи ответа.
+func metricsMiddleware(mw middleware.Middleware) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		measured := std.Handler("", mw, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			original, _ := r.Context().Value(originalWriterKey{}).(http.ResponseWriter)
+			next.ServeHTTP(&unwrappingWriter{ResponseWriter: w, original: original}, r)
+		}))
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			measured.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), originalWriterKey{}, w)))
+		})
+	}
+}
+
+// unwrappingWriter пишет через перехватчик метрик, а управление соединением передаёт
+// исходному ResponseWriter
+type unwrappingWriter struct {
+	http.ResponseWriter
+	original http.ResponseWriter
+}
+
+func (u *unwrappingWriter) Flush() {
+	if f, ok := u.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (u *unwrappingWriter) Unwrap() http.ResponseWriter {
+	if u.original == nil {
+		return u.ResponseWriter
+	}
+	return u.original
+}
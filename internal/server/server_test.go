@@ -0,0 +1,43 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/slok/go-http-metrics/metrics"
+	"github.com/slok/go-http-metrics/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Обработчик за middleware метрик должен управлять сроком записи и сбросом ответа:
+// на этом держится выгрузка больших выписок
+func TestMetricsMiddlewareKeepsResponseController(t *testing.T) {
+	mw := middleware.New(middleware.Config{Recorder: metrics.Dummy})
+	handler := metricsMiddleware(mw)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		controller := http.NewResponseController(w)
+		if err := controller.SetWriteDeadline(time.Now().Add(time.Minute)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		io.WriteString(w, "ok")
+		if err := controller.Flush(); err != nil {
+			t.Errorf("flush: %v", err)
+		}
+	}))
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	assert.Equal(t, "ok", string(body))
+}
@@ -0,0 +1,2 @@
+DROP INDEX idx_wallets_owner_ref;
+ALTER TABLE wallets DROP COLUMN owner_ref;
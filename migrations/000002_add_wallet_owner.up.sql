@@ -0,0 +1,4 @@
+-- Внешняя ссылка на владельца кошелька (например, идентификатор клиента в системе онбординга)
+ALTER TABLE wallets ADD COLUMN owner_ref TEXT;
+
+CREATE INDEX idx_wallets_owner_ref ON wallets (owner_ref);
@@ -0,0 +1 @@
+DROP INDEX idx_transactions_wallet_created_id;
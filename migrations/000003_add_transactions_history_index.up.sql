@@ -0,0 +1,2 @@
+-- Индекс для постраничной выборки истории операций кошелька по (created_at, id)
+CREATE INDEX idx_transactions_wallet_created_id ON transactions (wallet_id, created_at DESC, id DESC);
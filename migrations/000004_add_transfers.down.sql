@@ -0,0 +1,8 @@
+ALTER TABLE transactions DROP COLUMN related_transaction_id;
+ALTER TABLE transactions DROP COLUMN counterparty_wallet_id;
+
+DELETE FROM transactions WHERE operation_type IN ('TRANSFER_OUT', 'TRANSFER_IN');
+ALTER TABLE transactions DROP CONSTRAINT transactions_operation_type_check;
+ALTER TABLE transactions ALTER COLUMN operation_type TYPE VARCHAR(10);
+ALTER TABLE transactions ADD CONSTRAINT transactions_operation_type_check
+    CHECK (operation_type IN ('DEPOSIT', 'WITHDRAW'));
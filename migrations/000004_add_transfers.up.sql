@@ -0,0 +1,9 @@
+-- Переводы между кошельками: каждая сторона перевода записывается отдельной операцией,
+-- операции связаны друг с другом через related_transaction_id
+ALTER TABLE transactions DROP CONSTRAINT transactions_operation_type_check;
+ALTER TABLE transactions ALTER COLUMN operation_type TYPE VARCHAR(20);
+ALTER TABLE transactions ADD CONSTRAINT transactions_operation_type_check
+    CHECK (operation_type IN ('DEPOSIT', 'WITHDRAW', 'TRANSFER_OUT', 'TRANSFER_IN'));
+
+ALTER TABLE transactions ADD COLUMN counterparty_wallet_id UUID REFERENCES wallets(id);
+ALTER TABLE transactions ADD COLUMN related_transaction_id UUID REFERENCES transactions(id) DEFERRABLE INITIALLY DEFERRED;
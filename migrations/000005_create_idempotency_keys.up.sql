@@ -0,0 +1,10 @@
+-- Ключи идемпотентности: результат операции сохраняется в той же транзакции,
+-- что и сама операция, поэтому повтор запроса не выполняет её второй раз
+CREATE TABLE idempotency_keys (
+    key TEXT PRIMARY KEY,
+    request_hash TEXT NOT NULL,
+    wallet_id UUID NOT NULL REFERENCES wallets(id),
+    transaction_id UUID NOT NULL REFERENCES transactions(id),
+    response_balance BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,10 @@
+DELETE FROM transactions WHERE operation_type IN ('HOLD', 'CAPTURE');
+ALTER TABLE transactions DROP CONSTRAINT transactions_operation_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_operation_type_check
+    CHECK (operation_type IN ('DEPOSIT', 'WITHDRAW', 'TRANSFER_OUT', 'TRANSFER_IN'));
+
+ALTER TABLE transactions DROP COLUMN hold_id;
+ALTER TABLE transactions DROP COLUMN updated_at;
+
+DROP TABLE holds;
+ALTER TABLE wallets DROP COLUMN held_balance;
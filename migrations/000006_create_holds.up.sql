@@ -0,0 +1,31 @@
+-- Резервы уменьшают доступный баланс кошелька (balance - held_balance), не меняя учётный
+ALTER TABLE wallets ADD COLUMN held_balance BIGINT NOT NULL DEFAULT 0 CHECK (held_balance >= 0);
+
+CREATE TABLE holds (
+    id UUID PRIMARY KEY,
+    wallet_id UUID NOT NULL REFERENCES wallets(id),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    captured_amount BIGINT NOT NULL DEFAULT 0 CHECK (captured_amount >= 0 AND captured_amount <= amount),
+    status VARCHAR(10) NOT NULL DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'CAPTURED', 'VOIDED', 'EXPIRED')),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_holds_wallet_active ON holds (wallet_id, expires_at) WHERE status = 'ACTIVE';
+CREATE INDEX idx_holds_active_expiry ON holds (expires_at) WHERE status = 'ACTIVE';
+
+CREATE TRIGGER update_holds_updated_at
+BEFORE UPDATE ON holds
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+-- Триггер update_transactions_updated_at существует с первой миграции, но колонки не было:
+-- без неё любое изменение статуса операции завершалось ошибкой
+ALTER TABLE transactions ADD COLUMN updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP;
+
+ALTER TABLE transactions ADD COLUMN hold_id UUID REFERENCES holds(id);
+
+ALTER TABLE transactions DROP CONSTRAINT transactions_operation_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_operation_type_check
+    CHECK (operation_type IN ('DEPOSIT', 'WITHDRAW', 'TRANSFER_OUT', 'TRANSFER_IN', 'HOLD', 'CAPTURE'));
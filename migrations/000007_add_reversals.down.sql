@@ -0,0 +1,12 @@
+DROP INDEX idx_transactions_related;
+
+DELETE FROM transactions WHERE operation_type IN ('REVERSAL_DEBIT', 'REVERSAL_CREDIT');
+ALTER TABLE transactions DROP CONSTRAINT transactions_operation_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_operation_type_check
+    CHECK (operation_type IN ('DEPOSIT', 'WITHDRAW', 'TRANSFER_OUT', 'TRANSFER_IN', 'HOLD', 'CAPTURE'));
+
+UPDATE transactions SET status = 'COMPLETED' WHERE status IN ('REVERSED', 'PARTIALLY_REVERSED');
+ALTER TABLE transactions ALTER COLUMN status TYPE VARCHAR(10);
+
+ALTER TABLE transactions DROP CONSTRAINT transactions_refunded_amount_check;
+ALTER TABLE transactions DROP COLUMN refunded_amount;
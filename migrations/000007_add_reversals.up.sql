@@ -0,0 +1,14 @@
+-- Возвраты: компенсирующая операция ссылается на исходную через related_transaction_id,
+-- а исходная хранит уже возвращённую сумму, что исключает возврат сверх исходной суммы
+ALTER TABLE transactions ADD COLUMN refunded_amount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD CONSTRAINT transactions_refunded_amount_check
+    CHECK (refunded_amount >= 0 AND refunded_amount <= amount);
+
+ALTER TABLE transactions ALTER COLUMN status TYPE VARCHAR(20);
+
+ALTER TABLE transactions DROP CONSTRAINT transactions_operation_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_operation_type_check
+    CHECK (operation_type IN ('DEPOSIT', 'WITHDRAW', 'TRANSFER_OUT', 'TRANSFER_IN', 'HOLD', 'CAPTURE',
+        'REVERSAL_DEBIT', 'REVERSAL_CREDIT'));
+
+CREATE INDEX idx_transactions_related ON transactions (related_transaction_id);
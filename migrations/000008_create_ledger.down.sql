@@ -0,0 +1,9 @@
+DROP TRIGGER wallets_create_ledger_account ON wallets;
+DROP FUNCTION create_wallet_ledger_account();
+
+DROP TABLE postings;
+DROP TABLE journal_entries;
+DROP TABLE ledger_accounts;
+
+DROP FUNCTION forbid_ledger_modification();
+DROP FUNCTION check_journal_entry_balanced();
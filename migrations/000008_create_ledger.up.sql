@@ -0,0 +1,112 @@
+-- Журнал двойной записи: каждое движение денег - запись журнала с проводками по счетам,
+-- сумма проводок записи в каждой валюте равна нулю. wallets.balance остаётся
+-- материализованным остатком счёта кошелька и сверяется с проводками
+CREATE TABLE ledger_accounts (
+    id UUID PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE,
+    account_type VARCHAR(10) NOT NULL CHECK (account_type IN ('WALLET', 'SYSTEM')),
+    wallet_id UUID UNIQUE REFERENCES wallets(id),
+    currency_code TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((account_type = 'WALLET') = (wallet_id IS NOT NULL))
+);
+
+CREATE TABLE journal_entries (
+    id UUID PRIMARY KEY,
+    entry_type VARCHAR(20) NOT NULL,
+    transaction_id UUID REFERENCES transactions(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_journal_entries_transaction ON journal_entries (transaction_id);
+
+CREATE TABLE postings (
+    id BIGSERIAL PRIMARY KEY,
+    entry_id UUID NOT NULL REFERENCES journal_entries(id),
+    account_id UUID NOT NULL REFERENCES ledger_accounts(id),
+    amount BIGINT NOT NULL CHECK (amount <> 0),
+    currency_code TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_postings_entry ON postings (entry_id);
+CREATE INDEX idx_postings_account ON postings (account_id);
+
+-- Баланс записи проверяется при фиксации транзакции, когда все её проводки уже вставлены
+CREATE OR REPLACE FUNCTION check_journal_entry_balanced()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM postings
+        WHERE entry_id = NEW.entry_id
+        GROUP BY currency_code
+        HAVING SUM(amount) <> 0
+    ) THEN
+        RAISE EXCEPTION 'journal entry % is not balanced', NEW.entry_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER postings_balanced
+AFTER INSERT ON postings
+DEFERRABLE INITIALLY DEFERRED
+FOR EACH ROW
+EXECUTE FUNCTION check_journal_entry_balanced();
+
+-- Журнал только дополняется: ошибки исправляются новыми записями, а не правкой старых
+CREATE OR REPLACE FUNCTION forbid_ledger_modification()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger is append-only: % on % is not allowed', TG_OP, TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER postings_append_only
+BEFORE UPDATE OR DELETE ON postings
+FOR EACH ROW
+EXECUTE FUNCTION forbid_ledger_modification();
+
+CREATE TRIGGER journal_entries_append_only
+BEFORE UPDATE OR DELETE ON journal_entries
+FOR EACH ROW
+EXECUTE FUNCTION forbid_ledger_modification();
+
+-- Счёт кошелька открывается вместе с кошельком; его идентификатор совпадает с идентификатором кошелька
+CREATE OR REPLACE FUNCTION create_wallet_ledger_account()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO ledger_accounts (id, code, account_type, wallet_id, currency_code)
+    VALUES (NEW.id, 'wallet:' || NEW.id, 'WALLET', NEW.id, NEW.currency_code);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER wallets_create_ledger_account
+AFTER INSERT ON wallets
+FOR EACH ROW
+EXECUTE FUNCTION create_wallet_ledger_account();
+
+-- Перенос существующих остатков: счёт для каждого кошелька и входящий остаток
+-- против служебного счёта system:opening:<валюта>
+INSERT INTO ledger_accounts (id, code, account_type, wallet_id, currency_code)
+SELECT id, 'wallet:' || id, 'WALLET', id, currency_code FROM wallets;
+
+INSERT INTO ledger_accounts (id, code, account_type, currency_code)
+SELECT gen_random_uuid(), 'system:opening:' || currency_code, 'SYSTEM', currency_code
+FROM (SELECT DISTINCT currency_code FROM wallets WHERE balance <> 0) c;
+
+WITH opening AS (
+    SELECT gen_random_uuid() AS entry_id, id AS wallet_id, balance, currency_code
+    FROM wallets
+    WHERE balance <> 0
+), entries AS (
+    INSERT INTO journal_entries (id, entry_type)
+    SELECT entry_id, 'OPENING_BALANCE' FROM opening
+)
+INSERT INTO postings (entry_id, account_id, amount, currency_code)
+SELECT o.entry_id, o.wallet_id, o.balance, o.currency_code FROM opening o
+UNION ALL
+SELECT o.entry_id, a.id, -o.balance, o.currency_code
+FROM opening o
+JOIN ledger_accounts a ON a.code = 'system:opening:' || o.currency_code;
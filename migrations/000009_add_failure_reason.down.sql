@@ -0,0 +1,5 @@
+DROP INDEX idx_transactions_failed;
+
+DELETE FROM transactions WHERE status = 'FAILED';
+ALTER TABLE transactions DROP CONSTRAINT transactions_failure_reason_check;
+ALTER TABLE transactions DROP COLUMN failure_reason;
@@ -0,0 +1,6 @@
+-- Отклонённые операции сохраняются со статусом FAILED и кодом причины отказа
+ALTER TABLE transactions ADD COLUMN failure_reason VARCHAR(30);
+ALTER TABLE transactions ADD CONSTRAINT transactions_failure_reason_check
+    CHECK (failure_reason IS NULL OR status = 'FAILED');
+
+CREATE INDEX idx_transactions_failed ON transactions (created_at DESC) WHERE status = 'FAILED';
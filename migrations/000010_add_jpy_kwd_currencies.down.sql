@@ -0,0 +1,3 @@
+DELETE FROM currencies
+WHERE code IN ('JPY', 'KWD', 'BHD')
+  AND NOT EXISTS (SELECT 1 FROM wallets WHERE currency_code IN ('JPY', 'KWD', 'BHD'));
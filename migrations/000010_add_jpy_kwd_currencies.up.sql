@@ -0,0 +1,7 @@
+-- Валюты без дробной части и с тремя знаками после запятой
+INSERT INTO currencies (code, name, minor_units)
+VALUES
+  ('JPY', 'Japanese Yen', 0),
+  ('KWD', 'Kuwaiti Dinar', 3),
+  ('BHD', 'Bahraini Dinar', 3)
+ON CONFLICT (code) DO NOTHING;
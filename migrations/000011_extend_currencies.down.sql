@@ -0,0 +1,9 @@
+DROP INDEX idx_wallets_currency;
+
+DROP TRIGGER update_currencies_updated_at ON currencies;
+
+ALTER TABLE currencies DROP CONSTRAINT currencies_minor_units_check;
+ALTER TABLE currencies DROP COLUMN updated_at;
+ALTER TABLE currencies DROP COLUMN is_active;
+ALTER TABLE currencies DROP COLUMN is_fractional;
+ALTER TABLE currencies DROP COLUMN minor_unit_name;
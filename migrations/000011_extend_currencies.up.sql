@@ -0,0 +1,18 @@
+-- Справочник валют управляется через API: название минимальной единицы, признак активности
+-- и дата изменения. Дробность валюты выводится из точности и не может с ней разойтись
+ALTER TABLE currencies ADD COLUMN minor_unit_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE currencies ADD COLUMN is_fractional BOOLEAN GENERATED ALWAYS AS (minor_units > 0) STORED;
+ALTER TABLE currencies ADD COLUMN is_active BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE currencies ADD COLUMN updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE currencies ADD CONSTRAINT currencies_minor_units_check CHECK (minor_units BETWEEN 0 AND 8);
+
+CREATE TRIGGER update_currencies_updated_at
+BEFORE UPDATE ON currencies
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+UPDATE currencies SET minor_unit_name = 'цент' WHERE code IN ('USD', 'EUR');
+UPDATE currencies SET minor_unit_name = 'копейка' WHERE code = 'RUB';
+UPDATE currencies SET minor_unit_name = 'филс' WHERE code IN ('KWD', 'BHD');
+
+CREATE INDEX idx_wallets_currency ON wallets (currency_code);
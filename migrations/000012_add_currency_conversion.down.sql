@@ -0,0 +1,12 @@
+-- Обмены уже проведены в журнале, который только дополняется, поэтому откат возможен,
+-- только пока операций CONVERSION_OUT и CONVERSION_IN нет
+ALTER TABLE transactions DROP CONSTRAINT transactions_operation_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_operation_type_check
+    CHECK (operation_type IN ('DEPOSIT', 'WITHDRAW', 'TRANSFER_OUT', 'TRANSFER_IN', 'HOLD', 'CAPTURE',
+        'REVERSAL_DEBIT', 'REVERSAL_CREDIT'));
+
+ALTER TABLE transactions DROP COLUMN quote_id;
+ALTER TABLE transactions DROP COLUMN exchange_rate;
+
+DROP TABLE fx_quotes;
+DROP TABLE exchange_rates;
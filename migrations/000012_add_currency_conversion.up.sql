@@ -0,0 +1,38 @@
+-- Курсы обмена с периодами действия: rate - стоимость единицы base_currency в quote_currency
+CREATE TABLE exchange_rates (
+    id BIGSERIAL PRIMARY KEY,
+    base_currency TEXT NOT NULL,
+    quote_currency TEXT NOT NULL,
+    rate NUMERIC(30, 12) NOT NULL CHECK (rate > 0),
+    valid_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    valid_to TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (base_currency <> quote_currency),
+    CHECK (valid_to IS NULL OR valid_to > valid_from)
+);
+
+CREATE INDEX idx_exchange_rates_pair ON exchange_rates (base_currency, quote_currency, valid_from DESC);
+
+-- Котировка фиксирует курс и суммы обмена до expires_at и используется не более одного раза
+CREATE TABLE fx_quotes (
+    id UUID PRIMARY KEY,
+    wallet_id UUID NOT NULL REFERENCES wallets(id),
+    target_wallet_id UUID NOT NULL REFERENCES wallets(id),
+    source_currency TEXT NOT NULL,
+    target_currency TEXT NOT NULL,
+    source_amount BIGINT NOT NULL CHECK (source_amount > 0),
+    target_amount BIGINT NOT NULL CHECK (target_amount > 0),
+    rate NUMERIC(30, 12) NOT NULL CHECK (rate > 0),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    transaction_id UUID REFERENCES transactions(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE transactions ADD COLUMN exchange_rate NUMERIC(30, 12);
+ALTER TABLE transactions ADD COLUMN quote_id UUID REFERENCES fx_quotes(id);
+
+ALTER TABLE transactions DROP CONSTRAINT transactions_operation_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_operation_type_check
+    CHECK (operation_type IN ('DEPOSIT', 'WITHDRAW', 'TRANSFER_OUT', 'TRANSFER_IN', 'HOLD', 'CAPTURE',
+        'REVERSAL_DEBIT', 'REVERSAL_CREDIT', 'CONVERSION_OUT', 'CONVERSION_IN'));
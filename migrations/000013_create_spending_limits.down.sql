@@ -0,0 +1,2 @@
+DROP INDEX idx_transactions_wallet_type_created;
+DROP TABLE spending_limits;
@@ -0,0 +1,19 @@
+-- Лимиты операций задаются для кошелька или для всех кошельков валюты. Суммы указаны
+-- в минимальных единицах валюты; NULL означает, что лимит не задан.
+CREATE TABLE spending_limits (
+    wallet_id UUID REFERENCES wallets(id) ON DELETE CASCADE,
+    currency_code CHAR(3) REFERENCES currencies(code) ON DELETE CASCADE,
+    max_withdrawal BIGINT CHECK (max_withdrawal > 0),
+    daily_withdrawal BIGINT CHECK (daily_withdrawal > 0),
+    monthly_withdrawal BIGINT CHECK (monthly_withdrawal > 0),
+    hourly_deposits INTEGER CHECK (hourly_deposits > 0),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT spending_limits_scope_check CHECK ((wallet_id IS NULL) <> (currency_code IS NULL))
+);
+
+CREATE UNIQUE INDEX idx_spending_limits_wallet ON spending_limits (wallet_id) WHERE wallet_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_spending_limits_currency ON spending_limits (currency_code) WHERE currency_code IS NOT NULL;
+
+-- Суммы списаний и число пополнений за период считаются по истории кошелька
+CREATE INDEX idx_transactions_wallet_type_created ON transactions (wallet_id, operation_type, created_at)
+    WHERE status <> 'FAILED';
@@ -0,0 +1,3 @@
+-- Кредитная линия кошелька: доступный баланс (balance - held_balance) может опускаться до -overdraft_limit.
+-- Снижение лимита не затрагивает уже использованный кредит, но запрещает новые списания сверх лимита.
+ALTER TABLE wallets ADD COLUMN overdraft_limit BIGINT NOT NULL DEFAULT 0 CHECK (overdraft_limit >= 0);
@@ -0,0 +1,3 @@
+DROP TABLE wallet_status_history;
+ALTER TABLE wallets DROP CONSTRAINT wallets_closed_empty_check;
+ALTER TABLE wallets DROP COLUMN status;
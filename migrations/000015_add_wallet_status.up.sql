@@ -0,0 +1,19 @@
+-- Статус кошелька: FROZEN запрещает списания, BLOCKED - любые операции, CLOSED - конечный статус
+-- для кошелька без средств и резервов
+ALTER TABLE wallets ADD COLUMN status VARCHAR(10) NOT NULL DEFAULT 'ACTIVE'
+    CHECK (status IN ('ACTIVE', 'FROZEN', 'BLOCKED', 'CLOSED'));
+ALTER TABLE wallets ADD CONSTRAINT wallets_closed_empty_check
+    CHECK (status <> 'CLOSED' OR (balance = 0 AND held_balance = 0));
+
+-- История смены статусов с причиной и инициатором каждого изменения
+CREATE TABLE wallet_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    wallet_id UUID NOT NULL REFERENCES wallets(id),
+    from_status VARCHAR(10) NOT NULL,
+    to_status VARCHAR(10) NOT NULL,
+    reason TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_wallet_status_history_wallet ON wallet_status_history (wallet_id, created_at DESC, id DESC);
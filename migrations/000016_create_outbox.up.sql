@@ -0,0 +1,16 @@
+-- События об изменениях кошельков записываются в одной транзакции с самим изменением
+-- и публикуются ретранслятором. Порядок id совпадает с порядком изменений каждого кошелька:
+-- событие записывается после блокировки строки кошелька.
+CREATE TABLE outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    event_id UUID NOT NULL UNIQUE,
+    wallet_id UUID NOT NULL,
+    event_type VARCHAR(40) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP WITH TIME ZONE,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT
+);
+
+CREATE INDEX idx_outbox_events_pending ON outbox_events (id) WHERE published_at IS NULL;
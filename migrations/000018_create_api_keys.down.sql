@@ -0,0 +1,2 @@
+ALTER TABLE transactions DROP COLUMN actor;
+DROP TABLE api_keys;
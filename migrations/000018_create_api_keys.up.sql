@@ -0,0 +1,17 @@
+-- API-ключи хранятся только в виде SHA-256 хэша; сам ключ показывается один раз при выпуске.
+-- Пустой список wallet_ids - доступ ко всем кошелькам.
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL CHECK (cardinality(scopes) > 0),
+    wallet_ids UUID[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    rotated_to UUID REFERENCES api_keys(id)
+);
+
+-- Инициатор операции: аутентифицированный клиент API
+ALTER TABLE transactions ADD COLUMN actor TEXT;
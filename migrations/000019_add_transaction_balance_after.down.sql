@@ -0,0 +1,5 @@
+DROP INDEX idx_transactions_wallet_posted;
+ALTER TABLE transactions
+    DROP COLUMN seq,
+    DROP COLUMN posted_at,
+    DROP COLUMN balance_after;
@@ -0,0 +1,44 @@
+-- Баланс кошелька после операции, изменившей баланс. created_at - время начала транзакции и может
+-- не совпадать с порядком конкурирующих операций одного кошелька, поэтому момент проведения posted_at
+-- и номер seq присваиваются при записи операции, когда кошелёк уже заблокирован.
+ALTER TABLE transactions
+    ADD COLUMN balance_after BIGINT,
+    ADD COLUMN posted_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN seq BIGINT;
+
+CREATE SEQUENCE transactions_seq OWNED BY transactions.seq;
+
+-- Существующие операции упорядочиваются по времени создания, а балансы восстанавливаются
+-- от текущего баланса кошелька назад, поэтому баланс после последней из них равен wallets.balance.
+-- Порядок фиксации прежних операций не сохранён, поэтому для одновременных операций одного кошелька
+-- восстановленные промежуточные балансы приблизительны: они соответствуют порядку created_at.
+WITH moves AS (
+    SELECT t.id, t.wallet_id, t.created_at,
+        CASE WHEN t.operation_type IN ('DEPOSIT', 'TRANSFER_IN', 'REVERSAL_CREDIT', 'CONVERSION_IN')
+            THEN t.amount ELSE -t.amount END AS delta
+    FROM transactions t
+    WHERE t.status <> 'FAILED'
+        AND t.operation_type IN ('DEPOSIT', 'TRANSFER_IN', 'REVERSAL_CREDIT', 'CONVERSION_IN',
+            'WITHDRAW', 'TRANSFER_OUT', 'CAPTURE', 'REVERSAL_DEBIT', 'CONVERSION_OUT')
+), ordered AS (
+    SELECT m.id, m.created_at,
+        ROW_NUMBER() OVER (ORDER BY m.created_at, m.id) AS seq,
+        w.balance - COALESCE(SUM(m.delta) OVER (
+            PARTITION BY m.wallet_id ORDER BY m.created_at DESC, m.id DESC
+            ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING), 0) AS balance_after
+    FROM moves m
+    JOIN wallets w ON w.id = m.wallet_id
+)
+UPDATE transactions t
+SET balance_after = o.balance_after, posted_at = o.created_at, seq = o.seq
+FROM ordered o
+WHERE t.id = o.id;
+
+SELECT setval('transactions_seq', COALESCE(MAX(seq), 0) + 1, false) FROM transactions;
+
+ALTER TABLE transactions
+    ALTER COLUMN seq SET DEFAULT nextval('transactions_seq'),
+    ALTER COLUMN posted_at SET DEFAULT clock_timestamp();
+
+CREATE INDEX idx_transactions_wallet_posted ON transactions (wallet_id, posted_at, seq)
+    WHERE balance_after IS NOT NULL;
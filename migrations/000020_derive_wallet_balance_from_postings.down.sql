@@ -0,0 +1,17 @@
+CREATE OR REPLACE FUNCTION create_wallet_ledger_account()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO ledger_accounts (id, code, account_type, wallet_id, currency_code)
+    VALUES (NEW.id, 'wallet:' || NEW.id, 'WALLET', NEW.id, NEW.currency_code);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER ledger_accounts_balance_matches_wallet ON ledger_accounts;
+DROP TRIGGER wallets_balance_matches_ledger ON wallets;
+DROP FUNCTION check_wallet_balance_matches_ledger();
+
+DROP TRIGGER postings_apply_to_wallet_account ON postings;
+DROP FUNCTION apply_posting_to_wallet_account();
+
+ALTER TABLE ledger_accounts DROP COLUMN balance;
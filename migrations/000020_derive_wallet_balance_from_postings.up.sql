@@ -0,0 +1,94 @@
+-- Остаток счёта кошелька выводится из проводок: каждая проводка по счёту кошелька увеличивает его
+-- на свою сумму. wallets.balance обязан совпадать с ним при фиксации любой транзакции, поэтому
+-- изменить баланс кошелька без проводок в журнале нельзя. Остатки служебных счетов не ведутся:
+-- их проводки есть почти в каждой операции, и общая строка стала бы точкой конкуренции.
+ALTER TABLE ledger_accounts ADD COLUMN balance BIGINT NOT NULL DEFAULT 0;
+
+UPDATE ledger_accounts a
+SET balance = p.total
+FROM (SELECT account_id, SUM(amount) AS total FROM postings GROUP BY account_id) p
+WHERE a.id = p.account_id AND a.account_type = 'WALLET';
+
+-- Строка счёта кошелька изменяется только в транзакциях, уже изменивших строку кошелька,
+-- поэтому она не добавляет новых блокировок
+CREATE OR REPLACE FUNCTION apply_posting_to_wallet_account()
+RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE ledger_accounts
+    SET balance = balance + NEW.amount
+    WHERE id = NEW.account_id AND account_type = 'WALLET';
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER postings_apply_to_wallet_account
+AFTER INSERT ON postings
+FOR EACH ROW
+EXECUTE FUNCTION apply_posting_to_wallet_account();
+
+-- Сверка проверяется при фиксации транзакции, когда баланс кошелька и проводки уже записаны
+CREATE OR REPLACE FUNCTION check_wallet_balance_matches_ledger()
+RETURNS TRIGGER AS $$
+DECLARE
+    checked_wallet_id UUID;
+    wallet_balance BIGINT;
+    ledger_balance BIGINT;
+BEGIN
+    IF TG_TABLE_NAME = 'wallets' THEN
+        checked_wallet_id := NEW.id;
+    ELSE
+        checked_wallet_id := NEW.wallet_id;
+    END IF;
+
+    SELECT w.balance, a.balance INTO wallet_balance, ledger_balance
+    FROM wallets w
+    JOIN ledger_accounts a ON a.wallet_id = w.id
+    WHERE w.id = checked_wallet_id;
+
+    IF wallet_balance IS DISTINCT FROM ledger_balance THEN
+        RAISE EXCEPTION 'wallet % balance % does not match ledger balance %',
+            checked_wallet_id, wallet_balance, ledger_balance;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER wallets_balance_matches_ledger
+AFTER INSERT OR UPDATE OF balance ON wallets
+DEFERRABLE INITIALLY DEFERRED
+FOR EACH ROW
+EXECUTE FUNCTION check_wallet_balance_matches_ledger();
+
+CREATE CONSTRAINT TRIGGER ledger_accounts_balance_matches_wallet
+AFTER UPDATE OF balance ON ledger_accounts
+DEFERRABLE INITIALLY DEFERRED
+FOR EACH ROW
+WHEN (NEW.account_type = 'WALLET')
+EXECUTE FUNCTION check_wallet_balance_matches_ledger();
+
+-- Кошелёк, созданный с ненулевым балансом, получает входящий остаток против system:opening:<валюта>,
+-- как кошельки, перенесённые при переходе на журнал
+CREATE OR REPLACE FUNCTION create_wallet_ledger_account()
+RETURNS TRIGGER AS $$
+DECLARE
+    opening_account_id UUID;
+    opening_entry_id UUID;
+BEGIN
+    INSERT INTO ledger_accounts (id, code, account_type, wallet_id, currency_code)
+    VALUES (NEW.id, 'wallet:' || NEW.id, 'WALLET', NEW.id, NEW.currency_code);
+
+    IF NEW.balance <> 0 THEN
+        INSERT INTO ledger_accounts (id, code, account_type, currency_code)
+        VALUES (gen_random_uuid(), 'system:opening:' || NEW.currency_code, 'SYSTEM', NEW.currency_code)
+        ON CONFLICT (code) DO NOTHING;
+        SELECT id INTO opening_account_id FROM ledger_accounts WHERE code = 'system:opening:' || NEW.currency_code;
+
+        opening_entry_id := gen_random_uuid();
+        INSERT INTO journal_entries (id, entry_type) VALUES (opening_entry_id, 'OPENING_BALANCE');
+        INSERT INTO postings (entry_id, account_id, amount, currency_code)
+        VALUES (opening_entry_id, NEW.id, NEW.balance, NEW.currency_code),
+            (opening_entry_id, opening_account_id, -NEW.balance, NEW.currency_code);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
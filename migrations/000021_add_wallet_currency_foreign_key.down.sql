@@ -0,0 +1 @@
+ALTER TABLE wallets DROP CONSTRAINT wallets_currency_code_fkey;
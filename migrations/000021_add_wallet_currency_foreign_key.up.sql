@@ -0,0 +1,4 @@
+-- Кошелёк ссылается на валюту справочника: валюту нельзя удалить, пока в ней открыт хотя бы
+-- один кошелёк, в том числе созданный одновременно с удалением
+ALTER TABLE wallets ADD CONSTRAINT wallets_currency_code_fkey
+    FOREIGN KEY (currency_code) REFERENCES currencies (code);
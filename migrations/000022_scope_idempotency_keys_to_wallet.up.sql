@@ -0,0 +1,4 @@
+-- Ключ идемпотентности выбирает клиент, поэтому он уникален только в пределах кошелька:
+-- одинаковые ключи разных клиентов для разных кошельков не конфликтуют
+ALTER TABLE idempotency_keys DROP CONSTRAINT idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD CONSTRAINT idempotency_keys_pkey PRIMARY KEY (wallet_id, key);
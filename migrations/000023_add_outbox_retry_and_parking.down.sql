@@ -0,0 +1,6 @@
+DROP INDEX idx_outbox_events_failed;
+DROP INDEX idx_outbox_events_pending;
+CREATE INDEX idx_outbox_events_pending ON outbox_events (id) WHERE published_at IS NULL;
+
+ALTER TABLE outbox_events DROP COLUMN parked_at;
+ALTER TABLE outbox_events DROP COLUMN retry_at;
@@ -0,0 +1,11 @@
+-- Неудачная публикация повторяется с задержкой (retry_at). Событие, которое не удалось опубликовать
+-- за отведённое число попыток, откладывается (parked_at) и больше не выдаётся ретранслятору;
+-- следующие события его кошелька ждут, пока событие не вернут в очередь.
+ALTER TABLE outbox_events ADD COLUMN retry_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE outbox_events ADD COLUMN parked_at TIMESTAMP WITH TIME ZONE;
+
+DROP INDEX idx_outbox_events_pending;
+CREATE INDEX idx_outbox_events_pending ON outbox_events (id) WHERE published_at IS NULL AND parked_at IS NULL;
+
+-- Кошельки, публикация событий которых остановлена неудачным событием
+CREATE INDEX idx_outbox_events_failed ON outbox_events (wallet_id, id) WHERE published_at IS NULL AND attempts > 0;
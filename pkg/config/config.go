@@ -6,6 +6,7 @@ import (
 	"fmt"
 	
 	"path/filepath"
+	"time"
 	"github.com/joho/godotenv"
 )
 
@@ -18,6 +19,7 @@ type DBConfig struct {
 	Name     string
 	MaxOpenConns int
     MaxIdleConns int
+	TxStrategy   string // "serializable" (по умолчанию) или "row_lock"
 }
 
 func LoadConfigDB() (*DBConfig, error) {
@@ -49,5 +51,113 @@ func LoadConfigDB() (*DBConfig, error) {
 		Name:     os.Getenv("DB_NAME"),
 		MaxOpenConns: maxOpen,
 		MaxIdleConns: maxIdle,
+		TxStrategy:   os.Getenv("DB_TX_STRATEGY"),
 	}, nil
-}
\ No newline at end of file
+}
+
+// OutboxConfig задаёт публикацию событий из outbox
+type OutboxConfig struct {
+	Publisher string // "file" или "none"
+	FilePath  string
+}
+
+// LoadConfigOutbox читает настройки публикации событий; переменные окружения уже загружены LoadConfigDB
+func LoadConfigOutbox() (*OutboxConfig, error) {
+	cfg := &OutboxConfig{
+		Publisher: os.Getenv("OUTBOX_PUBLISHER"),
+		FilePath:  os.Getenv("OUTBOX_FILE"),
+	}
+	if cfg.Publisher == "" {
+		cfg.Publisher = "file"
+	}
+	if cfg.FilePath == "" {
+		cfg.FilePath = filepath.Join("logs", "events.jsonl")
+	}
+	if cfg.Publisher != "file" && cfg.Publisher != "none" {
+		return nil, fmt.Errorf("invalid OUTBOX_PUBLISHER: %s", cfg.Publisher)
+	}
+	return cfg, nil
+}
+
+// AuthConfig задаёт проверку JWT пользователей; без JWKSFile принимаются только API-ключи
+type AuthConfig struct {
+	JWKSFile    string
+	JWTIssuer   string
+	JWTAudience string
+}
+
+// LoadConfigAuth читает настройки аутентификации; переменные окружения уже загружены LoadConfigDB
+func LoadConfigAuth() *AuthConfig {
+	return &AuthConfig{
+		JWKSFile:    os.Getenv("AUTH_JWKS_FILE"),
+		JWTIssuer:   os.Getenv("AUTH_JWT_ISSUER"),
+		JWTAudience: os.Getenv("AUTH_JWT_AUDIENCE"),
+	}
+}
+
+// RetryConfig задаёт повторы транзакций при конфликтах сериализации; нулевое значение - по умолчанию
+type RetryConfig struct {
+	MaxAttempts int
+	MaxElapsed  time.Duration
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// LoadConfigRetry читает настройки повторов; переменные окружения уже загружены LoadConfigDB
+func LoadConfigRetry() (*RetryConfig, error) {
+	cfg := &RetryConfig{}
+	if value := os.Getenv("DB_RETRY_MAX_ATTEMPTS"); value != "" {
+		attempts, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DB_RETRY_MAX_ATTEMPTS: %w", err)
+		}
+		cfg.MaxAttempts = attempts
+	}
+
+	durations := []struct {
+		name   string
+		target *time.Duration
+	}{
+		{"DB_RETRY_MAX_ELAPSED", &cfg.MaxElapsed},
+		{"DB_RETRY_BASE_DELAY", &cfg.BaseDelay},
+		{"DB_RETRY_MAX_DELAY", &cfg.MaxDelay},
+	}
+	for _, d := range durations {
+		value := os.Getenv(d.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", d.name, err)
+		}
+		*d.target = parsed
+	}
+	return cfg, nil
+}
+
+// CoalesceConfig задаёт группировку одновременных операций одного кошелька; нулевое окно отключает её
+type CoalesceConfig struct {
+	Window   time.Duration
+	MaxBatch int
+}
+
+// LoadConfigCoalesce читает настройки группировки; переменные окружения уже загружены LoadConfigDB
+func LoadConfigCoalesce() (*CoalesceConfig, error) {
+	cfg := &CoalesceConfig{}
+	if value := os.Getenv("COALESCE_WINDOW"); value != "" {
+		window, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid COALESCE_WINDOW: %w", err)
+		}
+		cfg.Window = window
+	}
+	if value := os.Getenv("COALESCE_MAX_BATCH"); value != "" {
+		maxBatch, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid COALESCE_MAX_BATCH: %w", err)
+		}
+		cfg.MaxBatch = maxBatch
+	}
+	return cfg, nil
+}